@@ -0,0 +1,72 @@
+package events
+
+import (
+	"log/slog"
+	"sync"
+)
+
+const (
+	OrderAdded    = "order-added"
+	OrderRemoved  = "order-removed"
+	MemberJoined  = "member-joined"
+	MemberLeft    = "member-left"
+	EnergyChanged = "energy-changed"
+	StateChanged  = "state-changed"
+	BudgetChanged = "budget-changed"
+)
+
+// subscriberBuffer 为每个订阅者缓存的事件数，写满后丢弃新事件，避免慢客户端阻塞发布方。
+const subscriberBuffer = 32
+
+type Event struct {
+	Type    string `json:"type"`
+	PartyID int    `json:"party_id"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Hub 是进程内按 Party ID 分组的发布/订阅中心。
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[int]map[chan Event]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[int]map[chan Event]struct{})}
+}
+
+// Subscribe 订阅 Party 的事件，调用返回的取消函数后通道会被关闭。
+func (h *Hub) Subscribe(partyID int) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	h.mu.Lock()
+	if h.subs[partyID] == nil {
+		h.subs[partyID] = make(map[chan Event]struct{})
+	}
+	h.subs[partyID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[partyID], ch)
+			if len(h.subs[partyID]) == 0 {
+				delete(h.subs, partyID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (h *Hub) Publish(partyID int, eventType string, data any) {
+	e := Event{Type: eventType, PartyID: partyID, Data: data}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs[partyID] {
+		select {
+		case ch <- e:
+		default:
+			slog.Warn("订阅者处理过慢，丢弃事件", "party_id", partyID, "event", eventType)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// writeCSV 依次输出各表格，表格之间空一行，单元格经 safeCell 处理；开头写入 BOM 以便 Excel 正确识别 UTF-8。
+func writeCSV(w io.Writer, sheet *Sheet) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	var records [][]string
+	add := func(cells ...string) {
+		safe := make([]string, len(cells))
+		for i, cell := range cells {
+			safe[i] = safeCell(cell)
+		}
+		records = append(records, safe)
+	}
+	add(sheet.Title)
+	for _, line := range sheet.Lines {
+		add(line)
+	}
+	for _, table := range sheet.Tables {
+		add()
+		add(table.Title)
+		for _, line := range table.Lines {
+			add(line)
+		}
+		add(table.Header...)
+		for _, row := range table.Rows {
+			add(row...)
+		}
+	}
+	return cw.WriteAll(records)
+}
@@ -0,0 +1,218 @@
+package export
+
+import (
+	"DineTogether/models"
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const formulaNote = `=HYPERLINK("http://x")`
+
+func testSheet() *Sheet {
+	party := &models.Party{Name: "午餐", State: models.PartyLocked}
+	orders := []models.OrderItem{
+		{Username: "alice", MenuName: "牛肉面", EnergyCost: 3, Quantity: 2, Note: formulaNote},
+		{Username: "bob", MenuName: "@SUM(A1)", EnergyCost: 5, Quantity: 1, Modifiers: []string{"加辣"}},
+	}
+	groups := []models.RestaurantOrder{{
+		Items: []models.KitchenItem{
+			{MenuName: "牛肉面", Quantity: 2, Energy: 6, Notes: []string{formulaNote}},
+			{MenuName: "@SUM(A1)", Quantity: 1, Energy: 5, Modifiers: []string{"加辣"}},
+		},
+		Subtotal: 11,
+	}}
+	return Build(party, orders, groups, time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC))
+}
+
+func TestSafeCell(t *testing.T) {
+	cases := map[string]string{
+		"":              "",
+		"牛肉面":           "牛肉面",
+		"=1+1":          "'=1+1",
+		"+86 123":       "'+86 123",
+		"-cmd":          "'-cmd",
+		"@SUM(A1)":      "'@SUM(A1)",
+		"\t=1":          "'\t=1",
+		"\r=1":          "'\r=1",
+		"-3":            "-3",
+		"42":            "42",
+		"a=HYPERLINK()": "a=HYPERLINK()",
+	}
+	for in, want := range cases {
+		if got := safeCell(in); got != want {
+			t.Errorf("safeCell(%q) = %q, 期望 %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "csv", testSheet()); err != nil {
+		t.Fatal(err)
+	}
+	data := strings.TrimPrefix(buf.String(), "\ufeff")
+	r := csv.NewReader(strings.NewReader(data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notes, dishes int
+	for _, record := range records {
+		for _, cell := range record {
+			switch cell {
+			case "'" + formulaNote:
+				notes++
+			case "'@SUM(A1)":
+				dishes++
+			case formulaNote, "@SUM(A1)":
+				t.Fatalf("未转义的公式单元格: %q", cell)
+			}
+		}
+	}
+	// 菜品汇总与成员明细各出现一次
+	if notes != 2 || dishes != 2 {
+		t.Fatalf("转义后的备注 %d 个、菜品 %d 个", notes, dishes)
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "xlsx", testSheet()); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(body)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		body, ok := files[name]
+		if !ok {
+			t.Fatalf("缺少 %s", name)
+		}
+		if err := xml.Unmarshal([]byte(body), new(struct{})); err != nil {
+			t.Fatalf("%s 不是合法的 XML: %v", name, err)
+		}
+	}
+
+	var ws struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R string `xml:"r,attr"`
+				T string `xml:"t,attr"`
+				V string `xml:"v"`
+				F string `xml:"f"`
+				S string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal([]byte(files["xl/worksheets/sheet1.xml"]), &ws); err != nil {
+		t.Fatal(err)
+	}
+	if len(ws.Rows) == 0 || ws.Rows[0].Cells[0].S != "午餐 出餐单" {
+		t.Fatalf("首行应为标题: %+v", ws.Rows)
+	}
+	var notes, numbers int
+	for _, row := range ws.Rows {
+		for _, c := range row.Cells {
+			if c.F != "" {
+				t.Fatalf("单元格 %s 含公式 %q", c.R, c.F)
+			}
+			if !strings.HasSuffix(c.R, strconv.Itoa(row.R)) {
+				t.Fatalf("单元格 %s 不在第 %d 行", c.R, row.R)
+			}
+			switch {
+			case c.T == "":
+				if _, err := strconv.Atoi(c.V); err != nil {
+					t.Fatalf("数值单元格 %s = %q", c.R, c.V)
+				}
+				numbers++
+			case c.S == formulaNote:
+				t.Fatalf("未转义的备注: %s", c.R)
+			case c.S == "'"+formulaNote:
+				notes++
+			}
+		}
+	}
+	if notes != 2 || numbers == 0 {
+		t.Fatalf("转义后的备注 %d 个、数值单元格 %d 个", notes, numbers)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	sheet := testSheet()
+	// 足够多的行以产生分页
+	for i := 0; i < 120; i++ {
+		sheet.Tables[1].Rows = append(sheet.Tables[1].Rows, []string{"carol", "饺子", "", "1", "2", ""})
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, "pdf", sheet); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("缺少 PDF 文件头或结尾")
+	}
+
+	// startxref 指向 xref 表，表中每项偏移都指向对应对象的开头
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("缺少 startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref %d 未指向 xref 表", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(string(e[1]))
+		if want := strconv.Itoa(i+1) + " 0 obj\n"; !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Fatalf("对象 %d 的偏移 %d 错误", i+1, offset)
+		}
+	}
+
+	// 每个内容流的 /Length 与实际长度一致
+	streams := regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)endstream`).FindAllSubmatch(data, -1)
+	if len(streams) < 2 {
+		t.Fatalf("应分为多页，实际 %d 页", len(streams))
+	}
+	for i, s := range streams {
+		if n, _ := strconv.Atoi(string(s[1])); n != len(s[2]) {
+			t.Fatalf("第 %d 页内容长度 %d，声明为 %d", i+1, len(s[2]), n)
+		}
+	}
+	if !bytes.Contains(data, []byte("/Count "+strconv.Itoa(len(streams)))) {
+		t.Fatalf("页数与 /Count 不一致")
+	}
+	if !bytes.Contains(data, []byte("<"+pdfHex("午餐 出餐单")+">")) {
+		t.Fatal("缺少标题文本")
+	}
+}
+
+func TestPDFHex(t *testing.T) {
+	if got := pdfHex("A中😀"); got != "00414E2D003F" {
+		t.Fatalf("pdfHex = %s", got)
+	}
+}
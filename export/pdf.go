@@ -0,0 +1,234 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PDF 使用 A4 纵向页面，字体为 PDF 阅读器内置的 STSong-Light（Adobe-GB1），无需嵌入字体文件即可显示中文。
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 40.0
+	bodySize   = 10.0
+	lineHeight = bodySize * 1.3
+	cellPad    = 4.0
+)
+
+type pdfDoc struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+}
+
+func writePDF(w io.Writer, sheet *Sheet) error {
+	doc := &pdfDoc{}
+	doc.newPage()
+	doc.paragraph(sheet.Title, 16)
+	for _, line := range sheet.Lines {
+		doc.paragraph(line, bodySize)
+	}
+	for _, table := range sheet.Tables {
+		doc.y -= bodySize
+		doc.ensure(bodySize * 6)
+		doc.paragraph(table.Title, 13)
+		for _, line := range table.Lines {
+			doc.paragraph(line, bodySize)
+		}
+		doc.table(table.Header, table.Rows)
+	}
+	for i, page := range doc.pages {
+		footer := fmt.Sprintf("第 %d / %d 页", i+1, len(doc.pages))
+		fmt.Fprintf(page, "BT /F1 9 Tf %.2f %.2f Td <%s> Tj ET\n", pageWidth/2-textWidth(footer, 9)/2, margin/2, pdfHex(footer))
+	}
+	_, err := w.Write(doc.bytes())
+	return err
+}
+
+func (d *pdfDoc) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pageHeight - margin
+}
+
+// ensure 在当前页剩余空间不足 height 时换页，返回是否换页。
+func (d *pdfDoc) ensure(height float64) bool {
+	if d.y-height < margin {
+		d.newPage()
+		return true
+	}
+	return false
+}
+
+func (d *pdfDoc) text(x, baseline, size float64, s string) {
+	fmt.Fprintf(d.page, "BT /F1 %.1f Tf %.2f %.2f Td <%s> Tj ET\n", size, x, baseline, pdfHex(s))
+}
+
+func (d *pdfDoc) paragraph(s string, size float64) {
+	for _, line := range wrapText(s, pageWidth-2*margin, size) {
+		d.ensure(size * 1.5)
+		d.text(margin, d.y-size, size, line)
+		d.y -= size * 1.5
+	}
+}
+
+// table 绘制带表头底色与行分隔线的表格，单元格内容自动换行，跨页时重复表头。
+func (d *pdfDoc) table(header []string, rows [][]string) {
+	widths := columnWidths(header, rows, pageWidth-2*margin)
+	headerCells, headerHeight := layoutRow(header, widths)
+	d.ensure(headerHeight)
+	d.drawCells(headerCells, widths, headerHeight, true)
+	for _, row := range rows {
+		cells, height := layoutRow(row, widths)
+		if d.ensure(height) {
+			d.drawCells(headerCells, widths, headerHeight, true)
+		}
+		d.drawCells(cells, widths, height, false)
+	}
+}
+
+// layoutRow 将各单元格按列宽折行，返回折行结果与行高。
+func layoutRow(row []string, widths []float64) ([][]string, float64) {
+	cells := make([][]string, len(widths))
+	lines := 1
+	for i := range widths {
+		if i < len(row) {
+			cells[i] = wrapText(row[i], widths[i]-2*cellPad, bodySize)
+		}
+		lines = max(lines, len(cells[i]))
+	}
+	return cells, float64(lines)*lineHeight + 2*cellPad
+}
+
+func (d *pdfDoc) drawCells(cells [][]string, widths []float64, height float64, shaded bool) {
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	if shaded {
+		fmt.Fprintf(d.page, "0.92 g %.2f %.2f %.2f %.2f re f 0 g\n", margin, d.y-height, total, height)
+	}
+	x := margin
+	for i, lines := range cells {
+		for j, line := range lines {
+			if line == "" {
+				continue
+			}
+			d.text(x+cellPad, d.y-cellPad-float64(j)*lineHeight-bodySize, bodySize, line)
+		}
+		x += widths[i]
+	}
+	d.y -= height
+	fmt.Fprintf(d.page, "0.5 w %.2f %.2f m %.2f %.2f l S\n", margin, d.y, margin+total, d.y)
+}
+
+// columnWidths 按内容宽度分配列宽，总宽超出 available 时只压缩较宽的列。
+func columnWidths(header []string, rows [][]string, available float64) []float64 {
+	natural := make([]float64, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if i < len(natural) {
+				natural[i] = max(natural[i], textWidth(cell, bodySize)+2*cellPad)
+			}
+		}
+	}
+	total := 0.0
+	for _, w := range natural {
+		total += w
+	}
+	widths := make([]float64, len(natural))
+	if total <= available {
+		copy(widths, natural)
+		widths[len(widths)-1] += available - total
+		return widths
+	}
+	share := available / float64(len(natural))
+	narrow, wide := 0.0, 0.0
+	for _, w := range natural {
+		if w <= share {
+			narrow += w
+		} else {
+			wide += w
+		}
+	}
+	for i, w := range natural {
+		if w <= share {
+			widths[i] = w
+		} else {
+			widths[i] = w * (available - narrow) / wide
+		}
+	}
+	return widths
+}
+
+func textWidth(s string, size float64) float64 {
+	return float64(displayWidth(s)) * size / 2
+}
+
+// wrapText 按宽度折行，返回至少一行。
+func wrapText(s string, width, size float64) []string {
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0.0
+	for _, r := range s {
+		w := textWidth(string(r), size)
+		if lineWidth+w > width && line.Len() > 0 {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+		line.WriteRune(r)
+		lineWidth += w
+	}
+	return append(lines, line.String())
+}
+
+// pdfHex 将文本编码为 UniGB-UCS2-H 使用的 UCS-2 十六进制串，超出基本平面的字符以 ? 代替。
+func pdfHex(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r > 0xFFFF {
+			r = '?'
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	return b.String()
+}
+
+func (d *pdfDoc) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	const firstPage = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>")
+	object("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>")
+	object("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
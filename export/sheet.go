@@ -0,0 +1,178 @@
+// Package export 将 Party 的订单生成可发给餐厅的出餐单，支持 CSV、XLSX、PDF 与纯文本格式。
+package export
+
+import (
+	"DineTogether/models"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats 为支持的导出格式及对应的 Content-Type。
+var Formats = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"pdf":  "application/pdf",
+	"txt":  "text/plain; charset=utf-8",
+}
+
+// Table 为出餐单中的一个表格，Lines 为表格上方的说明文字。
+type Table struct {
+	Title  string
+	Lines  []string
+	Header []string
+	Rows   [][]string
+}
+
+// Sheet 为与格式无关的出餐单内容，由 Build 生成后交给 Write 按格式输出。
+type Sheet struct {
+	Title  string
+	Lines  []string
+	Tables []Table
+}
+
+var stateLabels = map[string]string{
+	models.PartyDraft:     "草稿",
+	models.PartyOpen:      "点餐中",
+	models.PartyLocked:    "已锁定",
+	models.PartySubmitted: "已提交",
+	models.PartyArchived:  "已归档",
+}
+
+// Build 汇总出餐单：按餐厅列出菜品合计，再按成员列出明细，最后给出总精力。
+func Build(party *models.Party, orders []models.OrderItem, groups []models.RestaurantOrder, now time.Time) *Sheet {
+	sheet := &Sheet{Title: fmt.Sprintf("%s 出餐单", party.Name)}
+
+	dishEnergy, deliveryFee := 0, 0
+	for _, g := range groups {
+		table := Table{
+			Title:  "菜品汇总",
+			Header: []string{"菜品", "选项", "数量", "精力", "备注"},
+		}
+		if g.Restaurant != nil {
+			table.Title = g.Restaurant.Name
+			table.Lines = restaurantLines(&g)
+			if g.Subtotal > 0 {
+				deliveryFee += g.Restaurant.DeliveryFee
+			}
+		} else if len(groups) > 1 {
+			table.Title = "未关联餐厅"
+		}
+		quantity := 0
+		for _, item := range g.Items {
+			table.Rows = append(table.Rows, []string{
+				item.MenuName, strings.Join(item.Modifiers, "、"), strconv.Itoa(item.Quantity), strconv.Itoa(item.Energy), strings.Join(item.Notes, "；"),
+			})
+			quantity += item.Quantity
+		}
+		table.Rows = append(table.Rows, []string{"合计", "", strconv.Itoa(quantity), strconv.Itoa(g.Subtotal), ""})
+		dishEnergy += g.Subtotal
+		sheet.Tables = append(sheet.Tables, table)
+	}
+	sheet.Tables = append(sheet.Tables, memberTable(orders))
+
+	state := stateLabels[party.State]
+	if state == "" {
+		state = party.State
+	}
+	sheet.Lines = []string{
+		"状态: " + state,
+		"导出时间: " + now.Format("2006-01-02 15:04"),
+		fmt.Sprintf("菜品精力: %d，配送费: %d，总精力: %d", dishEnergy, deliveryFee, dishEnergy+deliveryFee),
+	}
+	return sheet
+}
+
+func restaurantLines(g *models.RestaurantOrder) []string {
+	r := g.Restaurant
+	var contact []string
+	if r.Contact != "" {
+		contact = append(contact, "电话: "+r.Contact)
+	}
+	if r.Address != "" {
+		contact = append(contact, "地址: "+r.Address)
+	}
+	if r.OpeningHours != "" {
+		contact = append(contact, "营业: "+r.OpeningHours)
+	}
+	var lines []string
+	if len(contact) > 0 {
+		lines = append(lines, strings.Join(contact, "  "))
+	}
+	cost := fmt.Sprintf("小计 %d 精力，配送费 %d 精力", g.Subtotal, r.DeliveryFee)
+	if r.MinOrder > 0 {
+		cost += fmt.Sprintf("，起送 %d 精力", r.MinOrder)
+	}
+	lines = append(lines, cost)
+	if g.BelowMinimum {
+		lines = append(lines, fmt.Sprintf("未达起送精力，还差 %d", r.MinOrder-g.Subtotal))
+	}
+	return lines
+}
+
+// memberTable 按成员列出订单明细，每位成员之后附一行小计。
+func memberTable(orders []models.OrderItem) Table {
+	table := Table{
+		Title:  "成员明细",
+		Header: []string{"成员", "菜品", "选项", "数量", "精力", "备注"},
+	}
+	sorted := append([]models.OrderItem(nil), orders...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Username < sorted[j].Username })
+	for i := 0; i < len(sorted); {
+		username := sorted[i].Username
+		quantity, energy := 0, 0
+		for ; i < len(sorted) && sorted[i].Username == username; i++ {
+			o := sorted[i]
+			table.Rows = append(table.Rows, []string{
+				username, o.MenuName, strings.Join(o.Modifiers, "、"), strconv.Itoa(o.Quantity), strconv.Itoa(o.EnergyCost * o.Quantity), o.Note,
+			})
+			quantity += o.Quantity
+			energy += o.EnergyCost * o.Quantity
+		}
+		table.Rows = append(table.Rows, []string{username, "小计", "", strconv.Itoa(quantity), strconv.Itoa(energy), ""})
+	}
+	return table
+}
+
+// Write 按 format 输出出餐单，format 须为 Formats 中的键。
+func Write(w io.Writer, format string, sheet *Sheet) error {
+	switch format {
+	case "csv":
+		return writeCSV(w, sheet)
+	case "xlsx":
+		return writeXLSX(w, sheet)
+	case "pdf":
+		return writePDF(w, sheet)
+	case "txt":
+		return writeText(w, sheet)
+	}
+	return fmt.Errorf("不支持的导出格式: %s", format)
+}
+
+// safeCell 在以 = + - @、制表符或回车开头的文本前加 '，避免成员填写的备注、菜品名被表格软件当作公式执行。
+// 整数原样返回，以便负数仍按数值显示。
+func safeCell(s string) string {
+	if s == "" || strings.IndexByte("=+-@\t\r", s[0]) < 0 {
+		return s
+	}
+	if _, err := strconv.Atoi(s); err == nil {
+		return s
+	}
+	return "'" + s
+}
+
+// displayWidth 估算文本的显示宽度，全角字符按 2 计。
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if r < 0x80 {
+			width++
+		} else {
+			width += 2
+		}
+	}
+	return width
+}
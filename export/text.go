@@ -0,0 +1,39 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// writeText 输出按列对齐的纯文本，适合直接粘贴到聊天消息中。
+func writeText(w io.Writer, sheet *Sheet) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(sheet.Title + "\n")
+	for _, line := range sheet.Lines {
+		bw.WriteString(line + "\n")
+	}
+	for _, table := range sheet.Tables {
+		bw.WriteString("\n【" + table.Title + "】\n")
+		for _, line := range table.Lines {
+			bw.WriteString(line + "\n")
+		}
+		widths := make([]int, len(table.Header))
+		for _, row := range append([][]string{table.Header}, table.Rows...) {
+			for i, cell := range row {
+				widths[i] = max(widths[i], displayWidth(cell))
+			}
+		}
+		for _, row := range append([][]string{table.Header}, table.Rows...) {
+			var line strings.Builder
+			for i, cell := range row {
+				line.WriteString(cell)
+				if i < len(row)-1 {
+					line.WriteString(strings.Repeat(" ", widths[i]-displayWidth(cell)+2))
+				}
+			}
+			bw.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+		}
+	}
+	return bw.Flush()
+}
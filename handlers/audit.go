@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"DineTogether/logging"
+	"DineTogether/models"
+	"DineTogether/store"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordAudit 记录一次成功的操作，before/after 为操作前后的数据（为 nil 时不记录），targetID 为 nil 表示无具体对象。
+// 审计写入失败只记录日志，不影响请求结果。调用方需先清除数据中的密码哈希。
+func recordAudit(c *gin.Context, audit store.AuditStore, action, targetType string, targetID, before, after any) {
+	event := models.AuditEvent{
+		Action:     action,
+		TargetType: targetType,
+		IP:         c.ClientIP(),
+		Before:     auditJSON(c, before),
+		After:      auditJSON(c, after),
+	}
+	if targetID != nil {
+		event.TargetID = fmt.Sprint(targetID)
+	}
+	if userID, ok := currentUserID(c); ok {
+		event.ActorID = &userID
+	}
+	if err := audit.Record(&event); err != nil {
+		logging.From(c).Error("记录审计日志失败", "action", action, "target_type", targetType, "target_id", event.TargetID, "err", err)
+	}
+}
+
+func auditJSON(c *gin.Context, v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		logging.From(c).Error("序列化审计数据失败", "err", err)
+		return nil
+	}
+	// 查询失败时传入的空指针同样不记录
+	if string(data) == "null" {
+		return nil
+	}
+	return data
+}
+
+// GetAuditEvents 按操作者、动作、对象与时间范围筛选审计日志，分页参数同历史记录。
+func GetAuditEvents(audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		history, page, pageSize, msg := parseHistoryFilter(c)
+		if msg != "" {
+			badRequest(c, msg)
+			return
+		}
+		filter := store.AuditFilter{
+			HistoryFilter: history,
+			Action:        c.Query("action"),
+			TargetType:    c.Query("target_type"),
+			TargetID:      c.Query("target_id"),
+		}
+		if actor := c.Query("actor_id"); actor != "" {
+			id, err := strconv.Atoi(actor)
+			if err != nil || id <= 0 {
+				badRequest(c, "无效的用户 ID")
+				return
+			}
+			filter.ActorID = id
+		}
+		events, total, err := audit.List(filter)
+		if err != nil {
+			logging.From(c).Error("获取审计日志失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		success(c, "获取审计日志成功", gin.H{
+			"events":    events,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		})
+	}
+}
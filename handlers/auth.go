@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"DineTogether/logging"
 	"DineTogether/middleware"
 	"DineTogether/models"
-	"database/sql"
+	"DineTogether/store"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
@@ -20,10 +22,14 @@ func ValidatePassword(password string) error {
 	return nil
 }
 
-func SetupAdmin(db *sql.DB) gin.HandlerFunc {
+func SetupAdmin(users store.UserStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var count int
-		db.QueryRow("SELECT COUNT(*) FROM users WHERE role = 'admin'").Scan(&count)
+		count, err := users.CountAdmins()
+		if err != nil {
+			logging.From(c).Error("查询管理员数量失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
 		if count > 0 {
 			badRequest(c, "管理员已存在")
 			return
@@ -43,27 +49,27 @@ func SetupAdmin(db *sql.DB) gin.HandlerFunc {
 		}
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 		if err != nil {
-			log.Printf("密码加密失败: %v", err)
+			logging.From(c).Error("密码加密失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		result, err := db.Exec("INSERT INTO users (username, password, role) VALUES (?, ?, 'admin')", user.Username, hashedPassword)
+		id, err := users.Create(&models.User{Username: user.Username, Password: string(hashedPassword), Role: models.RoleAdmin})
 		if err != nil {
-			if isUniqueConstraint(err) {
+			if errors.Is(err, store.ErrDuplicate) {
 				badRequest(c, "用户名已存在")
 			} else {
-				log.Printf("创建管理员失败: %v", err)
+				logging.From(c).Error("创建管理员失败", "err", err)
 				serverError(c, "服务器错误")
 			}
 			return
 		}
-		id, _ := result.LastInsertId()
-		log.Printf("首次管理员创建成功: %s (id=%d)", user.Username, id)
+		logging.From(c).Info("首次管理员创建成功", "username", user.Username, "user_id", id)
+		recordAudit(c, audit, "user.setup_admin", "user", id, nil, gin.H{"id": id, "username": user.Username, "role": models.RoleAdmin})
 		success(c, "管理员创建成功", gin.H{"user_id": id})
 	}
 }
 
-func Register(db *sql.DB) gin.HandlerFunc {
+func Register(users store.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var user models.User
 		if err := c.ShouldBindJSON(&user); err != nil {
@@ -80,26 +86,25 @@ func Register(db *sql.DB) gin.HandlerFunc {
 		}
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 		if err != nil {
-			log.Printf("密码加密失败: %v", err)
+			logging.From(c).Error("密码加密失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		result, err := db.Exec("INSERT INTO users (username, password, role) VALUES (?, ?, 'guest')", user.Username, hashedPassword)
+		id, err := users.Create(&models.User{Username: user.Username, Password: string(hashedPassword), Role: models.RoleGuest})
 		if err != nil {
-			if isUniqueConstraint(err) {
+			if errors.Is(err, store.ErrDuplicate) {
 				badRequest(c, "用户名已存在")
 			} else {
-				log.Printf("注册用户失败: %v", err)
+				logging.From(c).Error("注册用户失败", "err", err)
 				serverError(c, "服务器错误")
 			}
 			return
 		}
-		id, _ := result.LastInsertId()
 		success(c, "注册成功", gin.H{"user_id": id})
 	}
 }
 
-func Login(db *sql.DB) gin.HandlerFunc {
+func Login(users store.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var loginRequest struct {
 			Username string `json:"username"`
@@ -113,48 +118,84 @@ func Login(db *sql.DB) gin.HandlerFunc {
 			badRequest(c, "用户名和密码不能为空")
 			return
 		}
-		var user models.User
-		row := db.QueryRow("SELECT id, username, password, role FROM users WHERE username = ?", loginRequest.Username)
-		if err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Role); err != nil {
-			log.Printf("用户 %s 不存在: %v", loginRequest.Username, err)
+		user, err := users.GetByUsername(loginRequest.Username)
+		if err != nil {
+			logging.From(c).Warn("用户不存在", "username", loginRequest.Username, "err", err)
 			unauthorized(c, "用户名或密码错误")
 			return
 		}
 		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginRequest.Password)); err != nil {
-			log.Printf("用户 %s 密码错误", loginRequest.Username)
+			logging.From(c).Warn("用户密码错误", "username", loginRequest.Username)
 			unauthorized(c, "用户名或密码错误")
 			return
 		}
 		session := sessions.Default(c)
 		session.Clear()
 		session.Set("user_id", user.ID)
-		session.Set("role", user.Role)
 		if err := session.Save(); err != nil {
-			log.Printf("保存 session 失败: %v", err)
+			logging.From(c).Error("保存 session 失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		log.Printf("用户 %s 登录成功，角色: %s", user.Username, user.Role)
+		logging.From(c).Info("用户登录成功", "username", user.Username, "role", user.Role)
 		success(c, "登录成功", gin.H{"user_id": user.ID, "role": user.Role})
 	}
 }
 
-func AuthMiddleware(db *sql.DB) gin.HandlerFunc {
+// RequirePermission 要求当前用户拥有 permissions 中的任一权限，角色取自 LoadUser 从数据库加载的用户。
+func RequirePermission(permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		session := sessions.Default(c)
-		role := session.Get("role")
-		userID := session.Get("user_id")
-		if role != "admin" {
-			forbidden(c, "需要管理员权限")
+		user, ok := CurrentUser(c)
+		if !ok {
+			unauthorized(c, "用户未登录")
 			c.Abort()
 			return
 		}
-		var exists bool
-		row := db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", userID)
-		if err := row.Scan(&exists); err != nil || !exists {
-			session.Clear()
-			session.Save()
-			unauthorized(c, "用户不存在或会话已过期")
+		for _, p := range permissions {
+			if user.Can(p) {
+				c.Next()
+				return
+			}
+		}
+		logging.From(c).Warn("权限不足", "role", user.Role, "required", permissions)
+		forbidden(c, "权限不足")
+		c.Abort()
+	}
+}
+
+// RequirePartyAccess 要求当前用户拥有 permission，或是路径参数 id 对应 Party 的所有者。
+func RequirePartyAccess(parties store.PartyStore, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok {
+			unauthorized(c, "用户未登录")
+			c.Abort()
+			return
+		}
+		if user.Can(permission) {
+			c.Next()
+			return
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			c.Abort()
+			return
+		}
+		party, err := parties.Get(id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "资源未找到")
+			} else {
+				logging.From(c).Error("获取 Party 失败", "party_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			c.Abort()
+			return
+		}
+		if !party.OwnedBy(user.ID) {
+			logging.From(c).Warn("权限不足", "role", user.Role, "required", permission, "party_id", id)
+			forbidden(c, "权限不足")
 			c.Abort()
 			return
 		}
@@ -162,7 +203,7 @@ func AuthMiddleware(db *sql.DB) gin.HandlerFunc {
 	}
 }
 
-func Logout(db *sql.DB) gin.HandlerFunc {
+func Logout() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
 		session.Clear()
@@ -185,3 +226,8 @@ func GetCSRFToken() gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"csrf_token": token.(string), "success": true})
 	}
 }
+
+func sessionInt(session sessions.Session, key string) (int, bool) {
+	v, ok := session.Get(key).(int)
+	return v, ok && v > 0
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"DineTogether/models"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequirePartyAccess(t *testing.T) {
+	ts := newTestServer(t)
+	ts.router.GET("/party/:id", RequirePartyAccess(ts.st.Parties, models.PermPartyRead), GetPartyByID(ts.st.Parties))
+	ts.router.PUT("/party/:id", RequirePartyAccess(ts.st.Parties, models.PermPartyWrite), UpdateParty(ts.st.Parties, ts.st.Audit))
+	ownerID := ts.addUser("owner", models.RoleOrganizer)
+	ts.addUser("other", models.RoleOrganizer)
+	ts.addUser("guest", models.RoleGuest)
+	ts.addUser("admin", models.RoleAdmin)
+	partyID := ts.addParty("lunch", 10, ownerID)
+	path := fmt.Sprintf("/party/%d", partyID)
+	update := gin.H{"name": "lunch", "energy_left": 20}
+
+	for _, username := range []string{"other", "guest"} {
+		c := ts.login(username)
+		if status, body := c.do("GET", path, nil); status != http.StatusForbidden {
+			t.Fatalf("%s 查看他人 Party: %d %v", username, status, body)
+		}
+		if status, body := c.do("PUT", path, update); status != http.StatusForbidden {
+			t.Fatalf("%s 修改他人 Party: %d %v", username, status, body)
+		}
+	}
+	for _, username := range []string{"owner", "admin"} {
+		c := ts.login(username)
+		if status, body := c.do("GET", path, nil); status != http.StatusOK {
+			t.Fatalf("%s 查看 Party: %d %v", username, status, body)
+		}
+		if status, body := c.do("PUT", path, update); status != http.StatusOK {
+			t.Fatalf("%s 修改 Party: %d %v", username, status, body)
+		}
+	}
+	if status, _ := ts.login("other").do("GET", "/party/999", nil); status != http.StatusNotFound {
+		t.Fatalf("不存在的 Party: %d", status)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"DineTogether/logging"
+	"DineTogether/models"
+	"DineTogether/store"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetCategories(categories store.CategoryStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := categories.List()
+		if err != nil {
+			logging.From(c).Error("查询分类失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		success(c, "获取分类列表成功", gin.H{"categories": list})
+	}
+}
+
+func CreateCategory(categories store.CategoryStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var category models.Category
+		if err := c.ShouldBindJSON(&category); err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		category.Name = strings.TrimSpace(category.Name)
+		if category.Name == "" {
+			badRequest(c, "分类名称不能为空")
+			return
+		}
+		id, err := categories.Create(&category)
+		if err != nil {
+			if errors.Is(err, store.ErrDuplicate) {
+				badRequest(c, "分类名称已存在")
+			} else {
+				logging.From(c).Error("创建分类失败", "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		category.ID = id
+		recordAudit(c, audit, "category.create", "category", id, nil, category)
+		success(c, "分类创建成功", gin.H{"category_id": id})
+	}
+}
+
+func UpdateCategory(categories store.CategoryStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		var category models.Category
+		if err := c.ShouldBindJSON(&category); err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		category.Name = strings.TrimSpace(category.Name)
+		if category.Name == "" {
+			badRequest(c, "分类名称不能为空")
+			return
+		}
+		category.ID = id
+		before, _ := categories.Get(id)
+		if err := categories.Update(&category); err != nil {
+			switch {
+			case errors.Is(err, store.ErrDuplicate):
+				badRequest(c, "分类名称已存在")
+			case errors.Is(err, store.ErrNotFound):
+				notFound(c, "分类不存在")
+			default:
+				logging.From(c).Error("更新分类失败", "category_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		after, _ := categories.Get(id)
+		recordAudit(c, audit, "category.update", "category", id, before, after)
+		success(c, "分类更新成功")
+	}
+}
+
+func DeleteCategory(categories store.CategoryStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		before, _ := categories.Get(id)
+		if err := categories.Delete(id); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "分类不存在")
+			} else {
+				logging.From(c).Error("删除分类失败", "category_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		recordAudit(c, audit, "category.delete", "category", id, before, nil)
+		success(c, "分类删除成功")
+	}
+}
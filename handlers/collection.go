@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"DineTogether/logging"
+	"DineTogether/models"
+	"DineTogether/store"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetCollections(collections store.CollectionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := collections.List()
+		if err != nil {
+			logging.From(c).Error("查询菜单集失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		success(c, "获取菜单集列表成功", gin.H{"collections": list})
+	}
+}
+
+func GetCollection(collections store.CollectionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		collection, err := collections.Get(id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "菜单集不存在")
+			} else {
+				logging.From(c).Error("查询菜单集失败", "collection_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		success(c, "获取菜单集成功", gin.H{"collection": collection})
+	}
+}
+
+func CreateCollection(collections store.CollectionStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var collection models.Collection
+		if err := c.ShouldBindJSON(&collection); err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		collection.Name = strings.TrimSpace(collection.Name)
+		if collection.Name == "" {
+			badRequest(c, "菜单集名称不能为空")
+			return
+		}
+		id, err := collections.Create(&collection)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrDuplicate):
+				badRequest(c, "菜单集名称已存在")
+			case errors.Is(err, store.ErrInvalidMenu):
+				badRequest(c, "菜品不存在")
+			default:
+				logging.From(c).Error("创建菜单集失败", "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		collection.ID = id
+		recordAudit(c, audit, "collection.create", "collection", id, nil, collection)
+		success(c, "菜单集创建成功", gin.H{"collection_id": id})
+	}
+}
+
+func UpdateCollection(collections store.CollectionStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		var collection models.Collection
+		if err := c.ShouldBindJSON(&collection); err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		collection.Name = strings.TrimSpace(collection.Name)
+		if collection.Name == "" {
+			badRequest(c, "菜单集名称不能为空")
+			return
+		}
+		collection.ID = id
+		before, _ := collections.Get(id)
+		if err := collections.Update(&collection); err != nil {
+			switch {
+			case errors.Is(err, store.ErrDuplicate):
+				badRequest(c, "菜单集名称已存在")
+			case errors.Is(err, store.ErrInvalidMenu):
+				badRequest(c, "菜品不存在")
+			case errors.Is(err, store.ErrNotFound):
+				notFound(c, "菜单集不存在")
+			default:
+				logging.From(c).Error("更新菜单集失败", "collection_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		after, _ := collections.Get(id)
+		recordAudit(c, audit, "collection.update", "collection", id, before, after)
+		success(c, "菜单集更新成功")
+	}
+}
+
+// DeleteCollection 删除菜单集，使用它的 Party 恢复为可点全部菜品。
+func DeleteCollection(collections store.CollectionStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		before, _ := collections.Get(id)
+		if err := collections.Delete(id); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "菜单集不存在")
+			} else {
+				logging.From(c).Error("删除菜单集失败", "collection_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		recordAudit(c, audit, "collection.delete", "collection", id, before, nil)
+		success(c, "菜单集删除成功")
+	}
+}
+
+func CloneCollection(collections store.CollectionStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			badRequest(c, "菜单集名称不能为空")
+			return
+		}
+		newID, err := collections.Clone(id, req.Name)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrDuplicate):
+				badRequest(c, "菜单集名称已存在")
+			case errors.Is(err, store.ErrNotFound):
+				notFound(c, "菜单集不存在")
+			default:
+				logging.From(c).Error("复制菜单集失败", "collection_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		after, _ := collections.Get(newID)
+		recordAudit(c, audit, "collection.clone", "collection", newID, nil, gin.H{"source_id": id, "collection": after})
+		success(c, "菜单集复制成功", gin.H{"collection_id": newID})
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"DineTogether/logging"
+	"DineTogether/models"
+	"DineTogether/store"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const currentUserKey = "current_user"
+
+// SessionUser 为当前请求的登录用户，角色以数据库为准而非 session 中保存的值。
+type SessionUser struct {
+	ID       int
+	Username string
+	Role     string
+}
+
+// Can 判断用户角色是否拥有权限。
+func (u *SessionUser) Can(permission string) bool {
+	return models.HasPermission(u.Role, permission)
+}
+
+// UserCache 按用户 ID 缓存用户名与角色，ttl 不大于 0 时每次都查询数据库。
+// 修改或删除用户的 handler 须调用 Invalidate，否则旧角色在 ttl 内仍然有效。
+type UserCache struct {
+	users   store.UserStore
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[int]userCacheEntry
+}
+
+type userCacheEntry struct {
+	user    SessionUser
+	expires time.Time
+}
+
+func NewUserCache(users store.UserStore, ttl time.Duration) *UserCache {
+	return &UserCache{users: users, ttl: ttl, entries: make(map[int]userCacheEntry)}
+}
+
+// Get 返回用户，用户不存在时返回 store.ErrNotFound。
+func (uc *UserCache) Get(id int) (*SessionUser, error) {
+	now := time.Now()
+	if uc.ttl > 0 {
+		uc.mu.Lock()
+		entry, ok := uc.entries[id]
+		uc.mu.Unlock()
+		if ok && now.Before(entry.expires) {
+			user := entry.user
+			return &user, nil
+		}
+	}
+	u, err := uc.users.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	user := SessionUser{ID: u.ID, Username: u.Username, Role: u.Role}
+	if uc.ttl > 0 {
+		uc.mu.Lock()
+		// 顺带清理过期项，避免已删除用户长期占用
+		for k, e := range uc.entries {
+			if !now.Before(e.expires) {
+				delete(uc.entries, k)
+			}
+		}
+		uc.entries[id] = userCacheEntry{user: user, expires: now.Add(uc.ttl)}
+		uc.mu.Unlock()
+	}
+	return &user, nil
+}
+
+// Invalidate 移除用户的缓存项，下次请求重新从数据库读取。
+func (uc *UserCache) Invalidate(id int) {
+	uc.mu.Lock()
+	delete(uc.entries, id)
+	uc.mu.Unlock()
+}
+
+// LoadUser 根据 session 中的 user_id 从数据库（经缓存）加载当前用户放入 gin context，
+// 用户已被删除时清空 session。需注册在 sessions 中间件之后。
+func LoadUser(cache *UserCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := sessions.Default(c)
+		userID, ok := sessionInt(session, "user_id")
+		if !ok {
+			c.Next()
+			return
+		}
+		user, err := cache.Get(userID)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			logging.From(c).Warn("会话用户不存在", "user_id", userID)
+			session.Clear()
+			session.Save()
+		case err != nil:
+			logging.From(c).Error("加载当前用户失败", "user_id", userID, "err", err)
+			serverError(c, "服务器错误")
+			c.Abort()
+			return
+		default:
+			c.Set(currentUserKey, user)
+		}
+		c.Next()
+	}
+}
+
+// CurrentUser 返回 LoadUser 加载的当前用户，未登录时返回 false。
+func CurrentUser(c *gin.Context) (*SessionUser, bool) {
+	user, ok := c.Get(currentUserKey)
+	if !ok {
+		return nil, false
+	}
+	u, ok := user.(*SessionUser)
+	return u, ok
+}
+
+// currentUserID 返回当前用户的 ID，未登录时返回 false。
+func currentUserID(c *gin.Context) (int, bool) {
+	if user, ok := CurrentUser(c); ok {
+		return user.ID, true
+	}
+	return 0, false
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"DineTogether/logging"
+	"DineTogether/models"
+	"DineTogether/store"
+	"errors"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetDietaryProfile(users store.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			unauthorized(c, "用户未登录")
+			return
+		}
+		profile, err := users.DietaryProfile(userID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "用户不存在")
+			} else {
+				logging.From(c).Error("获取用户饮食档案失败", "user_id", userID, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		success(c, "获取饮食档案成功", gin.H{
+			"profile":   profile,
+			"allergens": models.Allergens,
+			"diets":     models.Diets,
+		})
+	}
+}
+
+func UpdateDietaryProfile(users store.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			unauthorized(c, "用户未登录")
+			return
+		}
+		var profile models.DietaryProfile
+		if err := c.ShouldBindJSON(&profile); err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		if profile.Allergies, ok = normalizeLabels(profile.Allergies, models.ValidAllergen); !ok {
+			badRequest(c, "无效的过敏原")
+			return
+		}
+		if profile.Diets, ok = normalizeLabels(profile.Diets, models.ValidDiet); !ok {
+			badRequest(c, "无效的饮食限制")
+			return
+		}
+		if err := users.UpdateDietaryProfile(userID, &profile); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "用户不存在")
+			} else {
+				logging.From(c).Error("更新用户饮食档案失败", "user_id", userID, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		success(c, "饮食档案更新成功")
+	}
+}
+
+// dietaryConflicts 返回购物车中与用户饮食档案冲突的菜品，菜品不存在时跳过，交由下单流程报错。
+func dietaryConflicts(menus store.MenuStore, profile *models.DietaryProfile, items []models.CartItem) ([]models.DietaryConflict, error) {
+	conflicts := []models.DietaryConflict{}
+	if len(profile.Allergies) == 0 && len(profile.Diets) == 0 {
+		return conflicts, nil
+	}
+	checked := make(map[int]bool, len(items))
+	for _, item := range items {
+		if checked[item.MenuID] {
+			continue
+		}
+		checked[item.MenuID] = true
+		menu, err := menus.Get(item.MenuID)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if found := profile.Conflicts(menu.Allergens); len(found) > 0 {
+			conflicts = append(conflicts, models.DietaryConflict{MenuID: menu.ID, MenuName: menu.Name, Conflicts: found})
+		}
+	}
+	return conflicts, nil
+}
+
+// normalizeLabels 去重并校验取值，出现无效取值时返回 false。
+func normalizeLabels(values []string, valid func(string) bool) ([]string, bool) {
+	labels := make([]string, 0, len(values))
+	for _, v := range values {
+		if !valid(v) {
+			return nil, false
+		}
+		if !slices.Contains(labels, v) {
+			labels = append(labels, v)
+		}
+	}
+	return labels, true
+}
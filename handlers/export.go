@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"DineTogether/export"
+	"DineTogether/logging"
+	"DineTogether/store"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportParty 生成 Party 的出餐单，?format=csv|xlsx|pdf|txt，默认 csv。
+func ExportParty(parties store.PartyStore, orders store.OrderStore, restaurants store.RestaurantStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		format := c.DefaultQuery("format", "csv")
+		contentType, ok := export.Formats[format]
+		if !ok {
+			badRequest(c, "无效的导出格式")
+			return
+		}
+		party, err := parties.Get(id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "资源未找到")
+			} else {
+				logging.From(c).Error("查询 Party 失败", "party_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		list, err := orders.ListByParty(id)
+		if err != nil {
+			logging.From(c).Error("获取 Party 订单失败", "party_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		summary, err := orders.KitchenSummary(id)
+		if err != nil {
+			logging.From(c).Error("汇总 Party 订单失败", "party_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		restaurantList, err := restaurants.List()
+		if err != nil {
+			logging.From(c).Error("查询餐厅失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		sheet := export.Build(party, list, splitByRestaurant(summary, party, restaurantList), time.Now())
+		var buf bytes.Buffer
+		if err := export.Write(&buf, format, sheet); err != nil {
+			logging.From(c).Error("导出 Party 出餐单失败", "party_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		filename := fmt.Sprintf("%s-出餐单.%s", party.Name, format)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="party-%d.%s"; filename*=UTF-8''%s`, id, format, url.PathEscape(filename)))
+		c.Data(200, contentType, buf.Bytes())
+	}
+}
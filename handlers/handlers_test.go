@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"DineTogether/events"
+	"DineTogether/models"
+	"DineTogether/store"
+	"DineTogether/store/memory"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "secret123"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// testServer 以内存 store 运行 handler，路由由各测试自行注册，/login 已注册。
+type testServer struct {
+	t      *testing.T
+	st     store.Stores
+	hub    *events.Hub
+	cache  *UserCache
+	router *gin.Engine
+	srv    *httptest.Server
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	ts := &testServer{t: t, st: memory.New(), hub: events.NewHub()}
+	ts.cache = NewUserCache(ts.st.Users, 0)
+	ts.router = gin.New()
+	ts.router.Use(sessions.Sessions("session", cookie.NewStore([]byte("test secret"))), LoadUser(ts.cache))
+	ts.router.POST("/login", Login(ts.st.Users))
+	ts.srv = httptest.NewServer(ts.router)
+	t.Cleanup(ts.srv.Close)
+	return ts
+}
+
+// addUser 直接在 store 中创建用户并返回 ID。
+func (ts *testServer) addUser(username, role string) int {
+	ts.t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	id, err := ts.st.Users.Create(&models.User{Username: username, Password: string(hash), Role: role})
+	if err != nil {
+		ts.t.Fatalf("创建用户 %s 失败: %v", username, err)
+	}
+	return id
+}
+
+// addParty 创建开放状态的 Party，ownerID 为 0 表示无所有者。
+func (ts *testServer) addParty(name string, energy, ownerID int) int {
+	ts.t.Helper()
+	party := models.Party{Name: name, Password: "pw", EnergyLeft: energy, State: models.PartyOpen, BudgetMode: models.BudgetShared}
+	if ownerID != 0 {
+		party.OwnerID = &ownerID
+	}
+	id, err := ts.st.Parties.Create(&party)
+	if err != nil {
+		ts.t.Fatalf("创建 Party 失败: %v", err)
+	}
+	return id
+}
+
+type testClient struct {
+	ts   *testServer
+	http *http.Client
+}
+
+// client 返回未登录的客户端。
+func (ts *testServer) client() *testClient {
+	jar, _ := cookiejar.New(nil)
+	return &testClient{ts: ts, http: &http.Client{Jar: jar}}
+}
+
+// login 通过 /login 登录并返回携带会话 Cookie 的客户端。
+func (ts *testServer) login(username string) *testClient {
+	ts.t.Helper()
+	c := ts.client()
+	if status, body := c.do("POST", "/login", gin.H{"username": username, "password": testPassword}); status != http.StatusOK {
+		ts.t.Fatalf("登录 %s 失败: %d %v", username, status, body)
+	}
+	return c
+}
+
+// do 发送 JSON 请求，返回状态码与解析后的响应。
+func (c *testClient) do(method, path string, body any) (int, map[string]any) {
+	c.ts.t.Helper()
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.ts.t.Fatal(err)
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.ts.srv.URL+path, r)
+	if err != nil {
+		c.ts.t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.ts.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var result map[string]any
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	ts := newTestServer(t)
+	ts.router.POST("/register", Register(ts.st.Users))
+	c := ts.client()
+
+	if status, body := c.do("POST", "/register", gin.H{"username": "alice", "password": testPassword}); status != http.StatusOK || body["message"] != "注册成功" {
+		t.Fatalf("注册: %d %v", status, body)
+	}
+	if status, body := c.do("POST", "/register", gin.H{"username": "alice", "password": testPassword}); status != http.StatusBadRequest || body["error"] != "用户名已存在" {
+		t.Fatalf("重复注册: %d %v", status, body)
+	}
+	if status, body := c.do("POST", "/login", gin.H{"username": "alice", "password": "wrong-password"}); status != http.StatusUnauthorized {
+		t.Fatalf("错误密码登录: %d %v", status, body)
+	}
+	status, body := c.do("POST", "/login", gin.H{"username": "alice", "password": testPassword})
+	if status != http.StatusOK || body["role"] != models.RoleGuest {
+		t.Fatalf("登录: %d %v", status, body)
+	}
+}
+
+func TestJoinPartyAndPlaceOrder(t *testing.T) {
+	ts := newTestServer(t)
+	ts.router.POST("/join-party", JoinParty(ts.st.Parties, ts.hub, ts.st.Audit))
+	ts.router.POST("/order", PlaceOrder(ts.st.Orders, ts.st.Menus, ts.st.Users, ts.st.Parties, ts.hub, ts.st.Audit))
+
+	ts.addUser("alice", models.RoleGuest)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost)
+	partyID, err := ts.st.Parties.Create(&models.Party{Name: "lunch", Password: string(hash), EnergyLeft: 10, State: models.PartyOpen, BudgetMode: models.BudgetShared})
+	if err != nil {
+		t.Fatal(err)
+	}
+	menuID, err := ts.st.Menus.Create(&models.Menu{Name: "noodles", EnergyCost: 4, Available: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := ts.login("alice")
+
+	if status, body := alice.do("POST", "/order", gin.H{"menu_id": menuID}); status != http.StatusBadRequest || body["error"] != "未加入任何 Party" {
+		t.Fatalf("未加入时点餐: %d %v", status, body)
+	}
+	if status, body := alice.do("POST", "/join-party", gin.H{"party_name": "lunch", "password": "wrong"}); status != http.StatusUnauthorized || body["error"] != "Party 密码错误" {
+		t.Fatalf("错误密码加入: %d %v", status, body)
+	}
+	if status, body := alice.do("POST", "/join-party", gin.H{"party_name": "lunch", "password": "pw"}); status != http.StatusOK {
+		t.Fatalf("加入 Party: %d %v", status, body)
+	}
+	if status, body := alice.do("POST", "/order", gin.H{"menu_id": menuID, "quantity": 2}); status != http.StatusOK || body["message"] != "点餐成功" {
+		t.Fatalf("点餐: %d %v", status, body)
+	}
+	if status, body := alice.do("POST", "/order", gin.H{"menu_id": menuID, "quantity": 1}); status != http.StatusConflict || body["error"] != "Party 精力不足" {
+		t.Fatalf("精力不足时点餐: %d %v", status, body)
+	}
+	party, err := ts.st.Parties.Get(partyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if party.EnergyLeft != 2 {
+		t.Fatalf("剩余精力 = %d, 期望 2", party.EnergyLeft)
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	ts := newTestServer(t)
+	ts.router.GET("/users", RequirePermission(models.PermUserRead), GetUsers(ts.st.Users))
+	ts.addUser("admin", models.RoleAdmin)
+	ts.addUser("editor", models.RoleMenuEditor)
+
+	if status, _ := ts.client().do("GET", "/users", nil); status != http.StatusUnauthorized {
+		t.Fatalf("未登录: %d", status)
+	}
+	if status, _ := ts.login("editor").do("GET", "/users", nil); status != http.StatusForbidden {
+		t.Fatalf("menu_editor: %d", status)
+	}
+	status, body := ts.login("admin").do("GET", "/users", nil)
+	if status != http.StatusOK {
+		t.Fatalf("admin: %d %v", status, body)
+	}
+	if users, _ := body["users"].([]any); len(users) != 2 {
+		t.Fatalf("用户列表 = %v", body["users"])
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"DineTogether/logging"
+	"DineTogether/models"
+	"DineTogether/store"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// parseHistoryFilter 解析 from/to 时间范围与 page/page_size 分页参数。
+func parseHistoryFilter(c *gin.Context) (store.HistoryFilter, int, int, string) {
+	var filter store.HistoryFilter
+	var ok bool
+	if filter.From, filter.To, ok = parseTimeRange(c); !ok {
+		return filter, 0, 0, "无效的时间范围"
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return filter, 0, 0, "无效的分页参数"
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(DefaultPageSize)))
+	if err != nil || pageSize < 1 || pageSize > MaxPageSize {
+		return filter, 0, 0, "无效的分页参数"
+	}
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+	return filter, page, pageSize, ""
+}
+
+// parseTimeRange 解析 from/to 参数，可为 RFC 3339 时间或 YYYY-MM-DD 日期（按服务器本地时区），
+// 日期形式的 to 包含当天。未指定的一端返回零值。
+func parseTimeRange(c *gin.Context) (from, to time.Time, ok bool) {
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = parseHistoryTime(v, false); err != nil {
+			return from, to, false
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = parseHistoryTime(v, true); err != nil {
+			return from, to, false
+		}
+	}
+	return from, to, from.IsZero() || to.IsZero() || to.After(from)
+}
+
+func parseHistoryTime(v string, end bool) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, v, time.Local); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// GetMyOrders 返回当前用户在所有 Party 中的历史订单。
+func GetMyOrders(orders store.OrderStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			unauthorized(c, "用户未登录")
+			return
+		}
+		filter, page, pageSize, msg := parseHistoryFilter(c)
+		if msg != "" {
+			badRequest(c, msg)
+			return
+		}
+		list, total, err := orders.ListByUser(userID, filter)
+		if err != nil {
+			logging.From(c).Error("获取用户历史订单失败", "user_id", userID, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		success(c, "获取历史订单成功", gin.H{
+			"orders":    list,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		})
+	}
+}
+
+// GetArchivedParties 分页返回已归档的 Party 列表。
+func GetArchivedParties(parties store.PartyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, page, pageSize, msg := parseHistoryFilter(c)
+		if msg != "" {
+			badRequest(c, msg)
+			return
+		}
+		list, total, err := parties.ListArchived(filter)
+		if err != nil {
+			logging.From(c).Error("获取历史 Party 列表失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		success(c, "获取历史 Party 列表成功", gin.H{
+			"parties":   list,
+			"total":     total,
+			"page":      page,
+			"page_size": pageSize,
+		})
+	}
+}
+
+// GetArchivedParty 返回已归档 Party 的最终订单、成员精力消耗与状态历史。
+func GetArchivedParty(parties store.PartyStore, orders store.OrderStore, restaurants store.RestaurantStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		party, err := parties.Get(id)
+		if err != nil {
+			logging.From(c).Warn("Party 不存在", "party_id", id, "err", err)
+			notFound(c, "资源未找到")
+			return
+		}
+		if party.State != models.PartyArchived {
+			conflict(c, "Party 尚未归档")
+			return
+		}
+		party.Password = ""
+		list, err := orders.ListByParty(id)
+		if err != nil {
+			logging.From(c).Error("获取 Party 订单失败", "party_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		summary, err := orders.KitchenSummary(id)
+		if err != nil {
+			logging.From(c).Error("汇总 Party 订单失败", "party_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		restaurantList, err := restaurants.List()
+		if err != nil {
+			logging.From(c).Error("查询餐厅失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		members, err := parties.MemberBudgets(id)
+		if err != nil {
+			logging.From(c).Error("获取 Party 成员额度失败", "party_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		history, err := parties.History(id)
+		if err != nil {
+			logging.From(c).Error("获取 Party 状态历史失败", "party_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		energyUsed := 0
+		for _, m := range members {
+			energyUsed += m.Spent
+		}
+		success(c, "获取历史 Party 成功", gin.H{
+			"party":       party,
+			"orders":      list,
+			"restaurants": splitByRestaurant(summary, party, restaurantList),
+			"members":     members,
+			"history":     history,
+			"energy_used": energyUsed,
+		})
+	}
+}
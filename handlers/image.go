@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"DineTogether/logging"
+	"DineTogether/metrics"
+	"DineTogether/store"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -26,96 +28,99 @@ var allowedExts = map[string]bool{
 	".png":  true,
 }
 
-func UploadImage(uploadDir string) gin.HandlerFunc {
+func UploadImage(uploadDir string, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		form, err := c.MultipartForm()
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "无法解析表单数据", "success": false})
+			badRequest(c, "无法解析表单数据")
 			return
 		}
 		files := form.File["images"]
 		if len(files) == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "未上传任何图片", "success": false})
+			badRequest(c, "未上传任何图片")
 			return
 		}
 		if len(files) > MaxImages {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("最多上传 %d 张图片", MaxImages), "success": false})
+			badRequest(c, fmt.Sprintf("最多上传 %d 张图片", MaxImages))
 			return
 		}
 		var imageURLs []string
 		if err := os.MkdirAll(uploadDir, 0755); err != nil {
-			log.Printf("创建上传目录失败: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误", "success": false})
+			logging.From(c).Error("创建上传目录失败", "err", err)
+			serverError(c, "服务器错误")
 			return
 		}
 		for _, file := range files {
 			if file.Size > MaxFileSize {
-				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("图片 %s 超过2MB限制", file.Filename), "success": false})
+				badRequest(c, fmt.Sprintf("图片 %s 超过2MB限制", file.Filename))
 				return
 			}
 			ext := strings.ToLower(filepath.Ext(file.Filename))
 			if !allowedExts[ext] {
-				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("图片 %s 格式不支持，仅支持 jpg/png", file.Filename), "success": false})
+				badRequest(c, fmt.Sprintf("图片 %s 格式不支持，仅支持 jpg/png", file.Filename))
 				return
 			}
 			filename := fmt.Sprintf("%d-%s%s", time.Now().UnixNano(), strings.TrimSuffix(file.Filename, ext), ext)
 			dstPath := filepath.Join(uploadDir, filename)
 			src, err := file.Open()
 			if err != nil {
-				log.Printf("打开上传文件失败: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误", "success": false})
+				logging.From(c).Error("打开上传文件失败", "err", err)
+				serverError(c, "服务器错误")
 				return
 			}
 			dst, err := os.Create(dstPath)
 			if err != nil {
 				src.Close()
-				log.Printf("创建目标文件失败: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误", "success": false})
+				logging.From(c).Error("创建目标文件失败", "err", err)
+				serverError(c, "服务器错误")
 				return
 			}
 			if _, err := io.Copy(dst, src); err != nil {
 				src.Close()
 				dst.Close()
-				log.Printf("保存文件失败: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误", "success": false})
+				logging.From(c).Error("保存文件失败", "err", err)
+				serverError(c, "服务器错误")
 				return
 			}
 			src.Close()
 			dst.Close()
 			imageURLs = append(imageURLs, fmt.Sprintf("%s/%s", urlPrefix, filename))
 		}
+		metrics.ImagesUploaded.Add(float64(len(imageURLs)))
+		recordAudit(c, audit, "image.upload", "image", nil, nil, gin.H{"image_urls": imageURLs})
 		c.JSON(http.StatusOK, gin.H{"message": "图片上传成功", "image_urls": imageURLs})
 	}
 }
 
-func DeleteImage(uploadDir string) gin.HandlerFunc {
+func DeleteImage(uploadDir string, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var request struct {
 			ImageURL string `json:"image_url"`
 		}
 		if err := c.ShouldBindJSON(&request); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据", "success": false})
+			badRequest(c, "无效的请求数据")
 			return
 		}
 		if !strings.HasPrefix(request.ImageURL, urlPrefix+"/") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的图片路径", "success": false})
+			badRequest(c, "无效的图片路径")
 			return
 		}
 		filename := strings.TrimPrefix(request.ImageURL, urlPrefix+"/")
 		if filename == "" || strings.Contains(filename, "..") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的图片路径", "success": false})
+			badRequest(c, "无效的图片路径")
 			return
 		}
 		fullPath := filepath.Join(uploadDir, filename)
 		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "图片不存在", "success": false})
+			notFound(c, "图片不存在")
 			return
 		}
 		if err := os.Remove(fullPath); err != nil {
-			log.Printf("删除图片 %s 失败: %v", fullPath, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误", "success": false})
+			logging.From(c).Error("删除图片失败", "path", fullPath, "err", err)
+			serverError(c, "服务器错误")
 			return
 		}
+		recordAudit(c, audit, "image.delete", "image", request.ImageURL, gin.H{"image_url": request.ImageURL}, nil)
 		c.JSON(http.StatusOK, gin.H{"message": "图片删除成功"})
 	}
 }
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"DineTogether/events"
+	"DineTogether/invite"
+	"DineTogether/logging"
+	"DineTogether/models"
+	"DineTogether/qrcode"
+	"DineTogether/store"
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultInviteHours = 7 * 24
+	MaxInviteHours     = 30 * 24
+	// qrModuleSize 为二维码每个模块的像素数
+	qrModuleSize = 8
+)
+
+// inviteView 为返回给 Party 管理者的邀请，附带可分享的链接与二维码地址。
+type inviteView struct {
+	models.PartyInvite
+	Token string `json:"token"`
+	URL   string `json:"url"`
+	QRURL string `json:"qr_url"`
+}
+
+func newInviteView(c *gin.Context, signer *invite.Signer, publicURL string, inv models.PartyInvite) inviteView {
+	token := signer.Token(inv.ID, inv.ExpiresAt)
+	return inviteView{
+		PartyInvite: inv,
+		Token:       token,
+		URL:         joinURL(c, publicURL, token),
+		QRURL:       "/join/" + token + "/qr.png",
+	}
+}
+
+// joinURL 返回邀请链接的完整地址，publicURL 为空时按当前请求的协议与 Host 拼接。
+// Host 与 X-Forwarded-Proto 由客户端提供，未经校验，伪造的请求可以让返回的链接和二维码指向任意站点，
+// 因此只适合本地开发，生产环境必须配置 publicURL。
+func joinURL(c *gin.Context, publicURL, token string) string {
+	base := strings.TrimRight(publicURL, "/")
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	return base + "/join/" + token
+}
+
+// CreateInvite 为 Party 生成邀请链接，expires_in_hours 默认 7 天，max_uses 为空表示不限次数。
+func CreateInvite(invites store.InviteStore, parties store.PartyStore, signer *invite.Signer, publicURL string, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		var req struct {
+			ExpiresInHours *int `json:"expires_in_hours"`
+			MaxUses        *int `json:"max_uses"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		hours := DefaultInviteHours
+		if req.ExpiresInHours != nil {
+			hours = *req.ExpiresInHours
+		}
+		if hours < 1 || hours > MaxInviteHours {
+			badRequest(c, "邀请有效期必须在 1 到 720 小时之间")
+			return
+		}
+		if req.MaxUses != nil && *req.MaxUses <= 0 {
+			badRequest(c, "最大使用次数必须大于0")
+			return
+		}
+		party, err := parties.Get(id)
+		if err != nil || party.DeletedAt != nil {
+			logging.From(c).Warn("Party 不存在", "party_id", id, "err", err)
+			notFound(c, "资源未找到")
+			return
+		}
+		if party.State == models.PartyArchived {
+			conflict(c, "Party 已归档，无法创建邀请")
+			return
+		}
+		userID, _ := currentUserID(c)
+		now := time.Now().UTC().Truncate(time.Second)
+		inv := models.PartyInvite{
+			PartyID:   id,
+			CreatedBy: &userID,
+			ExpiresAt: now.Add(time.Duration(hours) * time.Hour),
+			MaxUses:   req.MaxUses,
+			CreatedAt: now,
+		}
+		inv.ID, err = invites.Create(&inv)
+		if err != nil {
+			logging.From(c).Error("创建 Party 邀请失败", "party_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		logging.From(c).Info("创建 Party 邀请", "party_id", id, "invite_id", inv.ID)
+		recordAudit(c, audit, "party.invite.create", "party", id, nil, inv)
+		success(c, "邀请创建成功", gin.H{"invite": newInviteView(c, signer, publicURL, inv)})
+	}
+}
+
+func GetInvites(invites store.InviteStore, signer *invite.Signer, publicURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		list, err := invites.ListByParty(id)
+		if err != nil {
+			logging.From(c).Error("获取 Party 邀请失败", "party_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		views := make([]inviteView, len(list))
+		for i, inv := range list {
+			views[i] = newInviteView(c, signer, publicURL, inv)
+		}
+		success(c, "获取邀请列表成功", gin.H{"invites": views})
+	}
+}
+
+func RevokeInvite(invites store.InviteStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		inviteID, err := strconv.Atoi(c.Param("invite_id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		// 路由只校验了对 :id 的权限，邀请必须属于该 Party
+		inv, err := invites.Get(inviteID)
+		if err != nil || inv.PartyID != id {
+			notFound(c, "邀请不存在")
+			return
+		}
+		if err := invites.Revoke(inviteID, time.Now()); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "邀请不存在")
+				return
+			}
+			logging.From(c).Error("作废 Party 邀请失败", "invite_id", inviteID, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		recordAudit(c, audit, "party.invite.revoke", "party", id, inv, nil)
+		success(c, "邀请已作废")
+	}
+}
+
+// parseInvite 校验令牌签名并读取邀请，失败时已写入响应。
+func parseInvite(c *gin.Context, invites store.InviteStore, signer *invite.Signer) (*models.PartyInvite, bool) {
+	id, _, err := signer.Parse(c.Param("token"))
+	if err != nil {
+		logging.From(c).Warn("邀请令牌无效", "err", err)
+		notFound(c, "邀请链接无效")
+		return nil, false
+	}
+	inv, err := invites.Get(id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			notFound(c, "邀请链接无效")
+		} else {
+			logging.From(c).Error("获取 Party 邀请失败", "invite_id", id, "err", err)
+			serverError(c, "服务器错误")
+		}
+		return nil, false
+	}
+	return inv, true
+}
+
+// GetInviteInfo 返回邀请对应的 Party 名称与状态，供邀请页面展示，无需登录。
+func GetInviteInfo(invites store.InviteStore, parties store.PartyStore, signer *invite.Signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inv, ok := parseInvite(c, invites, signer)
+		if !ok {
+			return
+		}
+		party, err := parties.Get(inv.PartyID)
+		if err != nil {
+			logging.From(c).Warn("Party 不存在", "party_id", inv.PartyID, "err", err)
+			notFound(c, "邀请链接无效")
+			return
+		}
+		remaining := -1
+		if inv.MaxUses != nil {
+			remaining = max(*inv.MaxUses-inv.Uses, 0)
+		}
+		success(c, "获取邀请成功", gin.H{
+			"party_name":  party.Name,
+			"party_state": party.State,
+			"expires_at":  inv.ExpiresAt,
+			"expired":     inv.RevokedAt != nil || !time.Now().Before(inv.ExpiresAt),
+			// -1 表示不限次数
+			"remaining_uses": remaining,
+		})
+	}
+}
+
+// JoinByInvite 通过邀请链接将当前用户加入 Party，无需 Party 密码。
+func JoinByInvite(invites store.InviteStore, parties store.PartyStore, signer *invite.Signer, hub *events.Hub, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			unauthorized(c, "用户未登录")
+			return
+		}
+		inv, ok := parseInvite(c, invites, signer)
+		if !ok {
+			return
+		}
+		partyID, err := invites.Redeem(inv.ID, userID, time.Now())
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrInviteExpired):
+				conflict(c, "邀请链接已失效")
+			case errors.Is(err, store.ErrInviteExhausted):
+				conflict(c, "邀请链接使用次数已达上限")
+			case errors.Is(err, store.ErrPartyNotOpen), errors.Is(err, store.ErrNotFound):
+				conflict(c, "Party 不存在或已关闭")
+			default:
+				logging.From(c).Error("通过邀请加入 Party 失败", "invite_id", inv.ID, "user_id", userID, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		party, err := parties.Get(partyID)
+		if err != nil {
+			logging.From(c).Error("获取 Party 失败", "party_id", partyID, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		session := sessions.Default(c)
+		session.Set("party_id", partyID)
+		if err := session.Save(); err != nil {
+			logging.From(c).Error("保存 session 失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		logging.From(c).Info("用户通过邀请加入 Party", "user_id", userID, "party_id", partyID, "invite_id", inv.ID)
+		hub.Publish(partyID, events.MemberJoined, gin.H{"user_id": userID})
+		recordAudit(c, audit, "party.join", "party", partyID, nil, gin.H{"invite_id": inv.ID})
+		success(c, "加入 Party 成功", gin.H{
+			"party_id":    party.ID,
+			"party_name":  party.Name,
+			"party_state": party.State,
+		})
+	}
+}
+
+// InviteQRCode 以 PNG 返回邀请链接的二维码，只校验令牌签名，不检查邀请是否仍然有效。
+func InviteQRCode(signer *invite.Signer, publicURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Param("token")
+		if _, _, err := signer.Parse(token); err != nil {
+			notFound(c, "邀请链接无效")
+			return
+		}
+		code, err := qrcode.Encode([]byte(joinURL(c, publicURL, token)), qrcode.M)
+		if err != nil {
+			logging.From(c).Error("生成二维码失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		var buf bytes.Buffer
+		if err := code.WritePNG(&buf, qrModuleSize); err != nil {
+			logging.From(c).Error("生成二维码失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		c.Header("Cache-Control", "private, max-age=3600")
+		c.Data(http.StatusOK, "image/png", buf.Bytes())
+	}
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestJoinURL(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/party/1/invites", nil)
+	c.Request.Host = "evil.example"
+	c.Request.Header.Set("X-Forwarded-Proto", "https")
+
+	// 配置了 publicURL 时不使用请求头
+	if got := joinURL(c, "https://dine.example.com/", "tok"); got != "https://dine.example.com/join/tok" {
+		t.Fatalf("joinURL = %q", got)
+	}
+	if got := joinURL(c, "", "tok"); got != "https://evil.example/join/tok" {
+		t.Fatalf("未配置 publicURL 时 joinURL = %q", got)
+	}
+}
@@ -1,84 +1,110 @@
 package handlers
 
 import (
+	"DineTogether/logging"
 	"DineTogether/models"
-	"database/sql"
-	"encoding/json"
-	"log"
+	"DineTogether/store"
+	"errors"
+	"fmt"
+	"slices"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
-func GetMenus(db *sql.DB) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		rows, err := db.Query("SELECT id, name, description, energy_cost, image_urls FROM menus")
-		if err != nil {
-			log.Printf("查询菜品失败: %v", err)
-			serverError(c, "服务器错误")
-			return
-		}
-		defer rows.Close()
+const (
+	MaxModifiers   = 20
+	MaxTags        = 10
+	MaxTagLength   = 20
+	MaxQueryLength = 50
+)
 
-		menus := make([]models.Menu, 0)
-		for rows.Next() {
-			var menu models.Menu
-			var description, imageURLs sql.NullString
-			if err := rows.Scan(&menu.ID, &menu.Name, &description, &menu.EnergyCost, &imageURLs); err != nil {
-				log.Printf("扫描菜品失败: %v", err)
-				serverError(c, "服务器错误")
-				return
-			}
-			menu.Description = description.String
-			if imageURLs.Valid {
-				if err := json.Unmarshal([]byte(imageURLs.String), &menu.ImageURLs); err != nil {
-					log.Printf("解析 image_urls 失败: %v", err)
+// GetMenus 支持 ?category=分类ID&restaurant=餐厅ID&tag=标签&q=关键字 筛选。
+// 当前 Party 绑定了菜单集时只返回其中的菜品，有 menu:write 权限的用户可通过 ?all=1 查看全部菜品。
+func GetMenus(menus store.MenuStore, parties store.PartyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var filter store.MenuFilter
+		session := sessions.Default(c)
+		if user, ok := CurrentUser(c); c.Query("all") != "1" || !ok || !user.Can(models.PermMenuWrite) {
+			if partyID, ok := sessionInt(session, "party_id"); ok {
+				party, err := parties.Get(partyID)
+				if err != nil && !errors.Is(err, store.ErrNotFound) {
+					logging.From(c).Error("查询 Party 失败", "party_id", partyID, "err", err)
 					serverError(c, "服务器错误")
 					return
 				}
-			} else {
-				menu.ImageURLs = []string{}
+				if party != nil && party.CollectionID != nil {
+					filter.CollectionID = *party.CollectionID
+				}
+			}
+		}
+		if category := c.Query("category"); category != "" {
+			id, err := strconv.Atoi(category)
+			if err != nil || id <= 0 {
+				badRequest(c, "无效的分类 ID")
+				return
 			}
-			menus = append(menus, menu)
+			filter.CategoryID = id
 		}
-		if err := rows.Err(); err != nil {
-			log.Printf("遍历菜品行失败: %v", err)
+		if restaurant := c.Query("restaurant"); restaurant != "" {
+			id, err := strconv.Atoi(restaurant)
+			if err != nil || id <= 0 {
+				badRequest(c, "无效的餐厅 ID")
+				return
+			}
+			filter.RestaurantID = id
+		}
+		filter.Tag = normalizeTag(c.Query("tag"))
+		filter.Query = strings.TrimSpace(c.Query("q"))
+		if utf8.RuneCountInString(filter.Query) > MaxQueryLength {
+			badRequest(c, fmt.Sprintf("搜索关键字不能超过 %d 个字符", MaxQueryLength))
+			return
+		}
+		list, err := menus.List(filter)
+		if err != nil {
+			logging.From(c).Error("查询菜品失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		success(c, "获取菜品列表成功", gin.H{"menus": menus})
+		success(c, "获取菜品列表成功", gin.H{"menus": list})
 	}
 }
 
-func CreateMenu(db *sql.DB) gin.HandlerFunc {
+func CreateMenu(menus store.MenuStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var menu models.Menu
 		if err := c.ShouldBindJSON(&menu); err != nil {
 			badRequest(c, "无效的请求数据")
 			return
 		}
-		if menu.Name == "" || menu.EnergyCost <= 0 {
-			badRequest(c, "菜品名称和精力消耗不能为空且精力消耗必须大于0")
-			return
-		}
-		imageURLsJSON, err := json.Marshal(menu.ImageURLs)
-		if err != nil {
-			log.Printf("序列化 image_urls 失败: %v", err)
-			serverError(c, "服务器错误")
+		if msg := validateMenu(&menu); msg != "" {
+			badRequest(c, msg)
 			return
 		}
-		result, err := db.Exec("INSERT INTO menus (name, description, energy_cost, image_urls) VALUES (?, ?, ?, ?)", menu.Name, menu.Description, menu.EnergyCost, string(imageURLsJSON))
+		id, err := menus.Create(&menu)
 		if err != nil {
-			log.Printf("创建菜品失败: %v", err)
+			if errors.Is(err, store.ErrInvalidCategory) {
+				badRequest(c, "分类不存在")
+				return
+			}
+			if errors.Is(err, store.ErrInvalidRestaurant) {
+				badRequest(c, "餐厅不存在")
+				return
+			}
+			logging.From(c).Error("创建菜品失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		id, _ := result.LastInsertId()
+		menu.ID = id
+		recordAudit(c, audit, "menu.create", "menu", id, nil, menu)
 		success(c, "菜品创建成功", gin.H{"menu_id": id})
 	}
 }
 
-func GetMenu(db *sql.DB) gin.HandlerFunc {
+func GetMenu(menus store.MenuStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idStr := c.Param("id")
 		if idStr == "undefined" || idStr == "" {
@@ -90,33 +116,21 @@ func GetMenu(db *sql.DB) gin.HandlerFunc {
 			badRequest(c, "无效的请求数据")
 			return
 		}
-		var menu models.Menu
-		var description, imageURLs sql.NullString
-		row := db.QueryRow("SELECT id, name, description, energy_cost, image_urls FROM menus WHERE id = ?", id)
-		if err := row.Scan(&menu.ID, &menu.Name, &description, &menu.EnergyCost, &imageURLs); err != nil {
-			if err == sql.ErrNoRows {
+		menu, err := menus.Get(id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
 				notFound(c, "菜品不存在")
 			} else {
-				log.Printf("查询菜品失败: %v", err)
+				logging.From(c).Error("查询菜品失败", "err", err)
 				serverError(c, "服务器错误")
 			}
 			return
 		}
-		menu.Description = description.String
-		if imageURLs.Valid {
-			if err := json.Unmarshal([]byte(imageURLs.String), &menu.ImageURLs); err != nil {
-				log.Printf("解析 image_urls 失败: %v", err)
-				serverError(c, "服务器错误")
-				return
-			}
-		} else {
-			menu.ImageURLs = []string{}
-		}
 		success(c, "获取菜品成功", gin.H{"menu": menu})
 	}
 }
 
-func UpdateMenu(db *sql.DB) gin.HandlerFunc {
+func UpdateMenu(menus store.MenuStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
@@ -128,112 +142,199 @@ func UpdateMenu(db *sql.DB) gin.HandlerFunc {
 			badRequest(c, "无效的请求数据")
 			return
 		}
-		if menu.Name == "" || menu.EnergyCost <= 0 {
-			badRequest(c, "菜品名称和精力消耗不能为空且精力消耗必须大于0")
+		if msg := validateMenu(&menu); msg != "" {
+			badRequest(c, msg)
 			return
 		}
-		imageURLsJSON, err := json.Marshal(menu.ImageURLs)
-		if err != nil {
-			log.Printf("序列化 image_urls 失败: %v", err)
-			serverError(c, "服务器错误")
+		menu.ID = id
+		before, _ := menus.Get(id)
+		if err := menus.Update(&menu); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "菜品不存在")
+			} else if errors.Is(err, store.ErrInvalidCategory) {
+				badRequest(c, "分类不存在")
+			} else if errors.Is(err, store.ErrInvalidRestaurant) {
+				badRequest(c, "餐厅不存在")
+			} else {
+				logging.From(c).Error("更新菜品失败", "err", err)
+				serverError(c, "服务器错误")
+			}
 			return
 		}
-		result, err := db.Exec("UPDATE menus SET name = ?, description = ?, energy_cost = ?, image_urls = ? WHERE id = ?", menu.Name, menu.Description, menu.EnergyCost, string(imageURLsJSON), id)
+		after, _ := menus.Get(id)
+		recordAudit(c, audit, "menu.update", "menu", id, before, after)
+		success(c, "菜品更新成功")
+	}
+}
+
+func DeleteMenu(menus store.MenuStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
-			log.Printf("更新菜品失败: %v", err)
-			serverError(c, "服务器错误")
+			badRequest(c, "无效的请求数据")
 			return
 		}
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			notFound(c, "菜品不存在")
+		before, _ := menus.Get(id)
+		if err := menus.Delete(id); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "菜品不存在")
+			} else {
+				logging.From(c).Error("删除菜品失败", "menu_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
 			return
 		}
-		success(c, "菜品更新成功")
+		logging.From(c).Info("菜品删除成功，已撤销草稿与开放 Party 中的相关订单", "menu_id", id)
+		recordAudit(c, audit, "menu.delete", "menu", id, before, nil)
+		success(c, "菜品删除成功")
+	}
+}
+
+func validateMenu(menu *models.Menu) string {
+	if menu.Name == "" || menu.EnergyCost <= 0 {
+		return "菜品名称和精力消耗不能为空且精力消耗必须大于0"
+	}
+	if len(menu.Modifiers) > MaxModifiers {
+		return fmt.Sprintf("菜品选项最多 %d 个", MaxModifiers)
+	}
+	seen := make(map[string]bool, len(menu.Modifiers))
+	for i := range menu.Modifiers {
+		menu.Modifiers[i].Name = strings.TrimSpace(menu.Modifiers[i].Name)
+		name := menu.Modifiers[i].Name
+		if name == "" || menu.Modifiers[i].Surcharge < 0 {
+			return "菜品选项名称不能为空且加价不能为负数"
+		}
+		if seen[name] {
+			return fmt.Sprintf("菜品选项 %s 重复", name)
+		}
+		seen[name] = true
+	}
+	if menu.Modifiers == nil {
+		menu.Modifiers = []models.MenuModifier{}
+	}
+	if len(menu.Tags) > MaxTags {
+		return fmt.Sprintf("菜品标签最多 %d 个", MaxTags)
+	}
+	tags := make([]string, 0, len(menu.Tags))
+	for _, tag := range menu.Tags {
+		if tag = normalizeTag(tag); tag == "" || slices.Contains(tags, tag) {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return fmt.Sprintf("菜品标签不能超过 %d 个字符", MaxTagLength)
+		}
+		tags = append(tags, tag)
+	}
+	menu.Tags = tags
+	allergens, ok := normalizeLabels(menu.Allergens, models.ValidAllergen)
+	if !ok {
+		return "无效的过敏原"
+	}
+	menu.Allergens = allergens
+	if (menu.AvailableFrom == "") != (menu.AvailableUntil == "") {
+		return "供应开始与结束时间需同时设置"
+	}
+	if menu.AvailableFrom != "" {
+		if !models.ValidClock(menu.AvailableFrom) || !models.ValidClock(menu.AvailableUntil) || menu.AvailableFrom == menu.AvailableUntil {
+			return "无效的供应时间，格式为 HH:MM 且开始与结束不能相同"
+		}
+	}
+	if (menu.DailyStock != nil && *menu.DailyStock < 0) || (menu.PartyStock != nil && *menu.PartyStock < 0) {
+		return "限量不能为负数"
 	}
+	return ""
 }
 
-func DeleteMenu(db *sql.DB) gin.HandlerFunc {
+func SetMenuAvailability(menus store.MenuStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
 			badRequest(c, "无效的请求数据")
 			return
 		}
-		tx, err := db.Begin()
-		if err != nil {
-			log.Printf("开启事务失败: %v", err)
-			serverError(c, "服务器错误")
+		var req struct {
+			Available *bool `json:"available"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Available == nil {
+			badRequest(c, "无效的请求数据")
 			return
 		}
-		defer tx.Rollback()
-
-		var energyCost int
-		row := tx.QueryRow("SELECT energy_cost FROM menus WHERE id = ?", id)
-		if err := row.Scan(&energyCost); err != nil {
-			if err == sql.ErrNoRows {
+		if err := menus.SetAvailable(id, *req.Available); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
 				notFound(c, "菜品不存在")
 			} else {
-				log.Printf("查询菜品 %v 失败: %v", id, err)
+				logging.From(c).Error("更新菜品上下架状态失败", "menu_id", id, "err", err)
 				serverError(c, "服务器错误")
 			}
 			return
 		}
-		rows, err := tx.Query("SELECT party_id FROM orders WHERE menu_id = ?", id)
-		if err != nil {
-			log.Printf("查询订单失败: %v", err)
-			serverError(c, "服务器错误")
-			return
-		}
-		partyEnergyUpdates := make(map[int]int)
-		for rows.Next() {
-			var partyID int
-			if err := rows.Scan(&partyID); err != nil {
-				log.Printf("扫描订单失败: %v", err)
-				rows.Close()
-				serverError(c, "服务器错误")
-				return
-			}
-			partyEnergyUpdates[partyID] += energyCost
+		recordAudit(c, audit, "menu.availability", "menu", id, nil, gin.H{"available": *req.Available})
+		if *req.Available {
+			success(c, "菜品已上架")
+		} else {
+			success(c, "菜品已下架")
 		}
-		rows.Close()
-		if err := rows.Err(); err != nil {
-			log.Printf("遍历订单行失败: %v", err)
+	}
+}
+
+// normalizeTag 去除首尾空白并统一为小写，使 "Spicy" 与 "spicy" 视为同一标签。
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+func GetTags(menus store.MenuStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tags, err := menus.Tags()
+		if err != nil {
+			logging.From(c).Error("查询标签失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
+		success(c, "获取标签列表成功", gin.H{"tags": tags})
+	}
+}
 
-		for partyID, energyToRestore := range partyEnergyUpdates {
-			_, err := tx.Exec("UPDATE parties SET energy_left = energy_left + ? WHERE id = ?", energyToRestore, partyID)
-			if err != nil {
-				log.Printf("恢复 Party %v 精力失败: %v", partyID, err)
-				serverError(c, "服务器错误")
-				return
-			}
+func RenameTag(menus store.MenuStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Name string `json:"name"`
 		}
-		_, err = tx.Exec("DELETE FROM orders WHERE menu_id = ?", id)
-		if err != nil {
-			log.Printf("删除订单引用失败: %v", err)
-			serverError(c, "服务器错误")
+		if err := c.ShouldBindJSON(&req); err != nil {
+			badRequest(c, "无效的请求数据")
 			return
 		}
-		result, err := tx.Exec("DELETE FROM menus WHERE id = ?", id)
-		if err != nil {
-			log.Printf("删除菜品 %v 失败: %v", id, err)
-			serverError(c, "服务器错误")
+		from, to := normalizeTag(c.Param("tag")), normalizeTag(req.Name)
+		if to == "" || utf8.RuneCountInString(to) > MaxTagLength {
+			badRequest(c, fmt.Sprintf("标签不能为空且不能超过 %d 个字符", MaxTagLength))
 			return
 		}
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			notFound(c, "菜品不存在")
+		if err := menus.RenameTag(from, to); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "标签不存在")
+			} else {
+				logging.From(c).Error("重命名标签失败", "tag", from, "err", err)
+				serverError(c, "服务器错误")
+			}
 			return
 		}
-		if err := tx.Commit(); err != nil {
-			log.Printf("提交事务失败: %v", err)
-			serverError(c, "服务器错误")
+		recordAudit(c, audit, "tag.rename", "tag", from, gin.H{"name": from}, gin.H{"name": to})
+		success(c, "标签更新成功")
+	}
+}
+
+func DeleteTag(menus store.MenuStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tag := normalizeTag(c.Param("tag"))
+		if err := menus.DeleteTag(tag); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "标签不存在")
+			} else {
+				logging.From(c).Error("删除标签失败", "tag", tag, "err", err)
+				serverError(c, "服务器错误")
+			}
 			return
 		}
-		log.Printf("菜品 %v 删除成功，已恢复相关 Party 精力", id)
-		success(c, "菜品删除成功")
+		recordAudit(c, audit, "tag.delete", "tag", tag, gin.H{"name": tag}, nil)
+		success(c, "标签删除成功")
 	}
 }
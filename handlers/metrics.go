@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"DineTogether/logging"
+	"DineTogether/metrics"
+	"DineTogether/models"
+	"DineTogether/store"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 以 Prometheus 文本格式输出指标，token 非空时要求 Authorization: Bearer <token>。
+// 活跃 Party 数在每次抓取时从数据库统计。
+func Metrics(parties store.PartyStore, token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token != "" {
+			got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				c.Header("WWW-Authenticate", "Bearer")
+				unauthorized(c, "无效的访问令牌")
+				return
+			}
+		}
+		counts, err := parties.CountByState()
+		if err != nil {
+			logging.From(c).Error("统计活跃 Party 失败", "err", err)
+		} else {
+			for _, state := range []string{models.PartyDraft, models.PartyOpen, models.PartyLocked, models.PartySubmitted} {
+				metrics.ActiveParties.Set(float64(counts[state]), state)
+			}
+		}
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", metrics.ContentType)
+		if err := metrics.Default.Write(c.Writer); err != nil {
+			logging.From(c).Error("输出指标失败", "err", err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"DineTogether/models"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	ts := newTestServer(t)
+	ts.router.GET("/metrics", Metrics(ts.st.Parties, "token"))
+	ts.addParty("a", 10, 0)
+	ts.addParty("b", 10, 0)
+	locked := ts.addParty("c", 10, 0)
+	if _, err := ts.st.Parties.Transition(locked, models.PartyLocked, 0); err != nil {
+		t.Fatal(err)
+	}
+	deleted := ts.addParty("d", 10, 0)
+	if err := ts.st.Parties.Delete(deleted, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(auth string) (int, string) {
+		req, _ := http.NewRequest("GET", ts.srv.URL+"/metrics", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	if status, _ := get("Bearer wrong"); status != http.StatusUnauthorized {
+		t.Fatalf("错误令牌: %d", status)
+	}
+	status, body := get("Bearer token")
+	if status != http.StatusOK {
+		t.Fatalf("抓取指标: %d", status)
+	}
+	for _, line := range []string{
+		`dinetogether_active_parties{state="draft"} 0`,
+		`dinetogether_active_parties{state="locked"} 1`,
+		`dinetogether_active_parties{state="open"} 2`,
+		`dinetogether_active_parties{state="submitted"} 0`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("缺少 %s", line)
+		}
+	}
+}
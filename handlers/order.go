@@ -1,98 +1,166 @@
 package handlers
 
 import (
-	"database/sql"
-	"log"
+	"DineTogether/events"
+	"DineTogether/logging"
+	"DineTogether/metrics"
+	"DineTogether/middleware"
+	"DineTogether/models"
+	"DineTogether/store"
+	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
-func PlaceOrder(db *sql.DB) gin.HandlerFunc {
+const (
+	MaxCartItems     = 50
+	MaxOrderQuantity = 99
+	MaxNoteLength    = 200
+)
+
+func PlaceOrder(orders store.OrderStore, menus store.MenuStore, users store.UserStore, parties store.PartyStore, hub *events.Hub, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		session := sessions.Default(c)
-		userID := session.Get("user_id")
-		partyID := session.Get("party_id")
-		if partyID == nil {
-			badRequest(c, "未加入任何 Party")
+		var item models.CartItem
+		if err := c.ShouldBindJSON(&item); err != nil {
+			badRequest(c, "无效的请求数据")
 			return
 		}
-		var order struct {
-			MenuID int `json:"menu_id"`
+		if item.Quantity == 0 {
+			item.Quantity = 1
 		}
-		if err := c.ShouldBindJSON(&order); err != nil {
+		placeItems(c, orders, menus, users, parties, hub, audit, []models.CartItem{item})
+	}
+}
+
+func PlaceCart(orders store.OrderStore, menus store.MenuStore, users store.UserStore, parties store.PartyStore, hub *events.Hub, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var items []models.CartItem
+		if err := c.ShouldBindJSON(&items); err != nil {
 			badRequest(c, "无效的请求数据")
 			return
 		}
-		if order.MenuID <= 0 {
-			badRequest(c, "无效的菜品 ID")
+		if len(items) == 0 {
+			badRequest(c, "购物车为空")
 			return
 		}
-		var energyCost int
-		row := db.QueryRow("SELECT energy_cost FROM menus WHERE id = ?", order.MenuID)
-		if err := row.Scan(&energyCost); err != nil {
-			log.Printf("菜品 %v 不存在: %v", order.MenuID, err)
-			notFound(c, "资源未找到")
+		if len(items) > MaxCartItems {
+			badRequest(c, fmt.Sprintf("购物车最多 %d 项", MaxCartItems))
 			return
 		}
-		var energyLeft int
-		row = db.QueryRow("SELECT energy_left FROM parties WHERE id = ?", partyID)
-		if err := row.Scan(&energyLeft); err != nil {
-			log.Printf("Party %v 不存在: %v", partyID, err)
-			notFound(c, "资源未找到")
-			return
-		}
-		if energyLeft < energyCost {
-			badRequest(c, "Party 精力不足")
+		placeItems(c, orders, menus, users, parties, hub, audit, items)
+	}
+}
+
+// placeItems 为 session 中的 Party 下单：先校验数量、备注与选项，再检查菜品与用户饮食档案的冲突，
+// 严格模式下有冲突即拒绝，否则在响应中返回 warnings。随后由 orders.Place 在同一事务中检查成员关系、
+// Party 状态与点餐时间、菜品是否在 Party 的合集中且在供应时段内、按选项计算价格、检查限量与个人额度，
+// 并扣除 Party 精力；成功后推送订单与精力变化并记录审计日志。
+func placeItems(c *gin.Context, orders store.OrderStore, menus store.MenuStore, users store.UserStore, parties store.PartyStore, hub *events.Hub, audit store.AuditStore, items []models.CartItem) {
+	session := sessions.Default(c)
+	userID, _ := currentUserID(c)
+	partyID, ok := sessionInt(session, "party_id")
+	if !ok {
+		badRequest(c, "未加入任何 Party")
+		return
+	}
+	for i := range items {
+		if items[i].MenuID <= 0 {
+			badRequest(c, "无效的菜品 ID")
 			return
 		}
-		var isMember bool
-		row = db.QueryRow("SELECT EXISTS(SELECT 1 FROM party_members WHERE party_id = ? AND user_id = ?)", partyID, userID)
-		if err := row.Scan(&isMember); err != nil || !isMember {
-			badRequest(c, "未加入此 Party")
+		if items[i].Quantity <= 0 || items[i].Quantity > MaxOrderQuantity {
+			badRequest(c, fmt.Sprintf("菜品数量必须在 1 到 %d 之间", MaxOrderQuantity))
 			return
 		}
-		tx, err := db.Begin()
-		if err != nil {
-			log.Printf("开启事务失败: %v", err)
-			serverError(c, "服务器错误")
+		items[i].Note = strings.TrimSpace(items[i].Note)
+		if utf8.RuneCountInString(items[i].Note) > MaxNoteLength {
+			badRequest(c, fmt.Sprintf("备注不能超过 %d 个字符", MaxNoteLength))
 			return
 		}
-		defer tx.Rollback()
-
-		_, err = tx.Exec("INSERT INTO orders (party_id, user_id, menu_id) VALUES (?, ?, ?)", partyID, userID, order.MenuID)
-		if err != nil {
-			log.Printf("点餐失败: %v", err)
-			serverError(c, "服务器错误")
+		if len(items[i].Modifiers) > MaxModifiers {
+			badRequest(c, "无效的菜品选项")
 			return
 		}
-		_, err = tx.Exec("UPDATE parties SET energy_left = energy_left - ? WHERE id = ?", energyCost, partyID)
-		if err != nil {
-			log.Printf("更新 Party %v 精力失败: %v", partyID, err)
-			serverError(c, "服务器错误")
-			return
+	}
+	profile, err := users.DietaryProfile(userID)
+	if err != nil {
+		logging.From(c).Error("获取用户饮食档案失败", "user_id", userID, "err", err)
+		serverError(c, "服务器错误")
+		return
+	}
+	warnings, err := dietaryConflicts(menus, profile, items)
+	if err != nil {
+		logging.From(c).Error("检查用户饮食冲突失败", "user_id", userID, "err", err)
+		serverError(c, "服务器错误")
+		return
+	}
+	if profile.Strict && len(warnings) > 0 {
+		names := make([]string, len(warnings))
+		for i, w := range warnings {
+			names[i] = w.MenuName
 		}
-		if err := tx.Commit(); err != nil {
-			log.Printf("提交事务失败: %v", err)
+		body := middleware.ErrorBody(c, fmt.Sprintf("%s 与饮食档案冲突", strings.Join(names, "、")))
+		body["conflicts"] = warnings
+		c.JSON(http.StatusConflict, body)
+		return
+	}
+	ids, energy, err := orders.Place(partyID, userID, items)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotMember):
+			badRequest(c, "未加入此 Party")
+		case errors.Is(err, store.ErrNotFound):
+			logging.From(c).Warn("Party 点餐时菜品或 Party 不存在", "party_id", partyID, "err", err)
+			notFound(c, "资源未找到")
+		case errors.Is(err, store.ErrInvalidModifier):
+			badRequest(c, "无效的菜品选项")
+		case errors.Is(err, store.ErrNotInCollection):
+			badRequest(c, err.Error())
+		case errors.Is(err, store.ErrMenuUnavailable), errors.Is(err, store.ErrNotServing), errors.Is(err, store.ErrSoldOut):
+			// 错误信息包含菜品名称，可直接展示
+			conflict(c, err.Error())
+		case errors.Is(err, store.ErrPartyNotOpen):
+			conflict(c, "Party 未开放点餐")
+		case errors.Is(err, store.ErrOutsideWindow):
+			conflict(c, "不在点餐时间内")
+		case errors.Is(err, store.ErrBudgetExceeded):
+			conflict(c, "个人精力额度不足")
+		case errors.Is(err, store.ErrInsufficientEnergy):
+			conflict(c, "Party 精力不足")
+		default:
+			logging.From(c).Error("点餐失败", "err", err)
 			serverError(c, "服务器错误")
-			return
 		}
-		log.Printf("用户 %v 在 Party %v 点餐 %v 成功", userID, partyID, order.MenuID)
-		success(c, "点餐成功")
+		return
+	}
+	logging.From(c).Info("点餐成功", "user_id", userID, "party_id", partyID, "order_ids", ids)
+	hub.Publish(partyID, events.OrderAdded, gin.H{"user_id": userID, "order_ids": ids, "items": items})
+	publishEnergy(c, hub, parties, partyID)
+	metrics.OrdersPlaced.Add(float64(len(ids)))
+	metrics.EnergyConsumed.Add(float64(energy))
+	for i, id := range ids {
+		recordAudit(c, audit, "order.place", "order", id, nil, gin.H{"party_id": partyID, "item": items[i]})
 	}
+	success(c, "点餐成功", gin.H{"order_ids": ids, "warnings": warnings})
 }
 
-func DeleteOrder(db *sql.DB) gin.HandlerFunc {
+func DeleteOrder(orders store.OrderStore, parties store.PartyStore, hub *events.Hub, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
-		partyID := session.Get("party_id")
-		if partyID == nil {
+		partyID, ok := sessionInt(session, "party_id")
+		if !ok {
 			badRequest(c, "未加入任何 Party")
 			return
 		}
-		userID := session.Get("user_id")
-		if userID == nil {
+		userID, ok := currentUserID(c)
+		if !ok {
 			unauthorized(c, "用户未登录")
 			return
 		}
@@ -101,79 +169,112 @@ func DeleteOrder(db *sql.DB) gin.HandlerFunc {
 			badRequest(c, "无效的订单 ID")
 			return
 		}
-		tx, err := db.Begin()
-		if err != nil {
-			log.Printf("开启事务失败: %v", err)
-			serverError(c, "服务器错误")
+		quantity, err := strconv.Atoi(c.DefaultQuery("quantity", "0"))
+		if err != nil || quantity < 0 {
+			badRequest(c, "无效的数量")
 			return
 		}
-		defer tx.Rollback()
-
-		var menuID, energyCost int
-		row := tx.QueryRow(`
-			SELECT o.menu_id, m.energy_cost
-			FROM orders o
-			JOIN menus m ON o.menu_id = m.id
-			WHERE o.id = ? AND o.user_id = ? AND o.party_id = ?`, orderID, userID, partyID)
-		if err := row.Scan(&menuID, &energyCost); err != nil {
-			if err == sql.ErrNoRows {
-				log.Printf("订单 %v 不存在或无权限: %v", orderID, err)
+		energyCost, err := orders.Delete(orderID, partyID, userID, quantity)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				logging.From(c).Warn("订单不存在或无权限", "order_id", orderID, "err", err)
 				notFound(c, "订单不存在")
 				return
 			}
-			log.Printf("查询订单失败: %v", err)
+			if errors.Is(err, store.ErrPartyNotOpen) {
+				conflict(c, "Party 已锁定，无法修改订单")
+				return
+			}
+			if errors.Is(err, store.ErrOutsideWindow) {
+				conflict(c, "不在点餐时间内，无法修改订单")
+				return
+			}
+			logging.From(c).Error("删除订单失败", "order_id", orderID, "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		result, err := tx.Exec("DELETE FROM orders WHERE id = ?", orderID)
+		logging.From(c).Info("删除订单成功，已退还 Party 精力", "order_id", orderID, "party_id", partyID, "refund", energyCost)
+		hub.Publish(partyID, events.OrderRemoved, gin.H{"user_id": userID, "order_id": orderID, "quantity": quantity, "refund": energyCost})
+		publishEnergy(c, hub, parties, partyID)
+		metrics.EnergyRefunded.Add(float64(energyCost))
+		recordAudit(c, audit, "order.delete", "order", orderID, nil, gin.H{"party_id": partyID, "quantity": quantity, "refund": energyCost})
+		success(c, "订单删除成功")
+	}
+}
+
+// RemovePartyOrder 供 Party 管理者删除成员的订单，退还精力规则与 DeleteOrder 相同。
+func RemovePartyOrder(orders store.OrderStore, parties store.PartyStore, hub *events.Hub, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		partyID, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
-			log.Printf("删除订单 %v 失败: %v", orderID, err)
-			serverError(c, "服务器错误")
+			badRequest(c, "无效的请求数据")
 			return
 		}
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			notFound(c, "订单不存在")
+		orderID, err := strconv.Atoi(c.Param("order_id"))
+		if err != nil {
+			badRequest(c, "无效的订单 ID")
 			return
 		}
-		_, err = tx.Exec("UPDATE parties SET energy_left = energy_left + ? WHERE id = ?", energyCost, partyID)
-		if err != nil {
-			log.Printf("更新 Party %v 精力值失败: %v", partyID, err)
-			serverError(c, "服务器错误")
+		quantity, err := strconv.Atoi(c.DefaultQuery("quantity", "0"))
+		if err != nil || quantity < 0 {
+			badRequest(c, "无效的数量")
 			return
 		}
-		if err := tx.Commit(); err != nil {
-			log.Printf("提交事务失败: %v", err)
-			serverError(c, "服务器错误")
+		energyCost, err := orders.Delete(orderID, partyID, 0, quantity)
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				logging.From(c).Warn("订单不存在", "order_id", orderID, "party_id", partyID, "err", err)
+				notFound(c, "订单不存在")
+			case errors.Is(err, store.ErrPartyNotOpen):
+				conflict(c, "Party 已锁定，无法修改订单")
+			case errors.Is(err, store.ErrOutsideWindow):
+				conflict(c, "不在点餐时间内，无法修改订单")
+			default:
+				logging.From(c).Error("删除订单失败", "order_id", orderID, "err", err)
+				serverError(c, "服务器错误")
+			}
 			return
 		}
-		log.Printf("删除订单 %v 成功，Party %v 精力值增加 %v", orderID, partyID, energyCost)
+		actorID, _ := currentUserID(c)
+		logging.From(c).Info("Party 管理者删除订单，已退还 Party 精力", "actor_id", actorID, "order_id", orderID, "party_id", partyID, "refund", energyCost)
+		hub.Publish(partyID, events.OrderRemoved, gin.H{"order_id": orderID, "quantity": quantity, "refund": energyCost, "removed_by": actorID})
+		publishEnergy(c, hub, parties, partyID)
+		metrics.EnergyRefunded.Add(float64(energyCost))
+		recordAudit(c, audit, "order.remove", "order", orderID, nil, gin.H{"party_id": partyID, "quantity": quantity, "refund": energyCost})
 		success(c, "订单删除成功")
 	}
 }
 
-func GetUserParty(db *sql.DB) gin.HandlerFunc {
+func GetUserParty(parties store.PartyStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
-		userID := session.Get("user_id")
-		var partyID int
-		var partyName string
-		row := db.QueryRow(`
-			SELECT p.id, p.name
-			FROM party_members pm
-			JOIN parties p ON pm.party_id = p.id
-			WHERE pm.user_id = ?`, userID)
-		if err := row.Scan(&partyID, &partyName); err != nil {
-			if err == sql.ErrNoRows {
+		userID, _ := currentUserID(c)
+		party, err := parties.FindByMember(userID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				// 被移除或已离开的成员不再保留旧的 party_id
+				if _, ok := sessionInt(session, "party_id"); ok {
+					session.Delete("party_id")
+					session.Save()
+				}
 				c.JSON(200, gin.H{"hasParty": false})
 				return
 			}
-			log.Printf("查询用户 Party 失败: %v", err)
+			logging.From(c).Error("查询用户 Party 失败", "err", err)
 			serverError(c, "查询 Party 失败")
 			return
 		}
-		session.Set("party_id", partyID)
+		session.Set("party_id", party.ID)
 		session.Save()
-		c.JSON(200, gin.H{"hasParty": true, "party_id": partyID, "party_name": partyName})
+		c.JSON(200, gin.H{
+			"hasParty":    true,
+			"party_id":    party.ID,
+			"party_name":  party.Name,
+			"party_state": party.State,
+			"opens_at":    party.OpensAt,
+			"closes_at":   party.ClosesAt,
+			"server_time": time.Now().UTC(),
+		})
 	}
 }
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"DineTogether/models"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestDeleteOrderRemovesGroupedQuantity(t *testing.T) {
+	ts := newTestServer(t)
+	ts.router.POST("/join-party", JoinParty(ts.st.Parties, ts.hub, ts.st.Audit))
+	ts.router.POST("/order", PlaceOrder(ts.st.Orders, ts.st.Menus, ts.st.Users, ts.st.Parties, ts.hub, ts.st.Audit))
+	ts.router.DELETE("/order/:id", DeleteOrder(ts.st.Orders, ts.st.Parties, ts.hub, ts.st.Audit))
+	ts.router.GET("/api/party-orders", GetPartyOrders(ts.st.Parties, ts.st.Orders, ts.st.Restaurants))
+
+	ts.addUser("alice", models.RoleGuest)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost)
+	if _, err := ts.st.Parties.Create(&models.Party{Name: "lunch", Password: string(hash), EnergyLeft: 20, State: models.PartyOpen, BudgetMode: models.BudgetShared}); err != nil {
+		t.Fatal(err)
+	}
+	menuID, err := ts.st.Menus.Create(&models.Menu{Name: "noodles", EnergyCost: 2, Available: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := ts.login("alice")
+	if status, body := alice.do("POST", "/join-party", gin.H{"party_name": "lunch", "password": "pw"}); status != http.StatusOK {
+		t.Fatalf("加入 Party: %d %v", status, body)
+	}
+	for i := 0; i < 3; i++ {
+		if status, body := alice.do("POST", "/order", gin.H{"menu_id": menuID, "quantity": 2}); status != http.StatusOK {
+			t.Fatalf("点餐: %d %v", status, body)
+		}
+	}
+	orders := func() []any {
+		t.Helper()
+		status, body := alice.do("GET", "/api/party-orders", nil)
+		if status != http.StatusOK {
+			t.Fatalf("获取订单: %d %v", status, body)
+		}
+		list, _ := body["orders"].([]any)
+		return list
+	}
+	list := orders()
+	if len(list) != 1 || list[0].(map[string]any)["quantity"] != float64(6) {
+		t.Fatalf("合并后的订单 = %v", list)
+	}
+	id := int(list[0].(map[string]any)["id"].(float64))
+
+	if status, body := alice.do("DELETE", fmt.Sprintf("/order/%d?quantity=5", id), nil); status != http.StatusOK {
+		t.Fatalf("删除 5 份: %d %v", status, body)
+	}
+	if list = orders(); len(list) != 1 || list[0].(map[string]any)["quantity"] != float64(1) {
+		t.Fatalf("删除 5 份后的订单 = %v", list)
+	}
+	if status, body := alice.do("DELETE", fmt.Sprintf("/order/%d", id), nil); status != http.StatusOK {
+		t.Fatalf("删除剩余订单: %d %v", status, body)
+	}
+	if list = orders(); len(list) != 0 {
+		t.Fatalf("删除后的订单 = %v", list)
+	}
+	if party, _ := ts.st.Parties.GetByName("lunch"); party.EnergyLeft != 20 {
+		t.Fatalf("剩余精力 = %d, 期望 20", party.EnergyLeft)
+	}
+}
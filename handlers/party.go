@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"DineTogether/events"
+	"DineTogether/logging"
 	"DineTogether/models"
-	"database/sql"
-	"log"
+	"DineTogether/store"
+	"errors"
+	"fmt"
 	"strconv"
 
 	"github.com/gin-contrib/sessions"
@@ -11,7 +14,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-func CreateParty(db *sql.DB) gin.HandlerFunc {
+func CreateParty(parties store.PartyStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var party models.Party
 		if err := c.ShouldBindJSON(&party); err != nil {
@@ -22,75 +25,132 @@ func CreateParty(db *sql.DB) gin.HandlerFunc {
 			badRequest(c, "Party 名称、密码和初始精力值不能为空或无效")
 			return
 		}
+		if !validWindow(&party) {
+			badRequest(c, "截止时间必须晚于开放时间")
+			return
+		}
+		if party.BudgetMode == "" {
+			party.BudgetMode = models.BudgetShared
+		}
+		if msg := validateBudget(&party); msg != "" {
+			badRequest(c, msg)
+			return
+		}
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(party.Password), bcrypt.DefaultCost)
 		if err != nil {
-			log.Printf("密码加密失败: %v", err)
+			logging.From(c).Error("密码加密失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		result, err := db.Exec("INSERT INTO parties (name, password, energy_left, is_active) VALUES (?, ?, ?, ?)", party.Name, hashedPassword, party.EnergyLeft, true)
+		party.Password = string(hashedPassword)
+		switch party.State {
+		case "":
+			party.State = models.PartyOpen
+		case models.PartyDraft, models.PartyOpen:
+		default:
+			badRequest(c, "新建 Party 的状态只能为草稿或开放")
+			return
+		}
+		userID, ok := currentUserID(c)
+		if !ok {
+			unauthorized(c, "用户未登录")
+			return
+		}
+		party.OwnerID = &userID
+		id, err := parties.Create(&party)
 		if err != nil {
-			if isUniqueConstraint(err) {
+			if errors.Is(err, store.ErrDuplicate) {
 				badRequest(c, "Party 名称已存在")
+			} else if errors.Is(err, store.ErrInvalidCollection) {
+				badRequest(c, "菜单集不存在")
+			} else if errors.Is(err, store.ErrInvalidRestaurant) {
+				badRequest(c, "餐厅不存在")
 			} else {
-				log.Printf("创建 Party 失败: %v", err)
+				logging.From(c).Error("创建 Party 失败", "err", err)
 				serverError(c, "服务器错误")
 			}
 			return
 		}
-		id, _ := result.LastInsertId()
+		party.ID = id
+		logging.From(c).Info("创建 Party 成功", "owner_id", userID, "party_id", id)
+		recordAudit(c, audit, "party.create", "party", id, nil, auditParty(&party))
 		success(c, "Party 创建成功", gin.H{"party_id": id})
 	}
 }
 
-func GetParties(db *sql.DB) gin.HandlerFunc {
+// auditParty 返回去掉密码哈希的 Party 副本，用于审计记录。
+func auditParty(party *models.Party) *models.Party {
+	if party == nil {
+		return nil
+	}
+	p := *party
+	p.Password = ""
+	return &p
+}
+
+func validWindow(party *models.Party) bool {
+	return party.OpensAt == nil || party.ClosesAt == nil || party.ClosesAt.After(*party.OpensAt)
+}
+
+func validateBudget(party *models.Party) string {
+	if !models.ValidBudgetMode(party.BudgetMode) {
+		return "无效的额度模式"
+	}
+	if party.MemberBudget < 0 || (party.BudgetMode == models.BudgetFixed && party.MemberBudget <= 0) {
+		return "固定额度模式下成员额度必须大于0"
+	}
+	return ""
+}
+
+func GetParties(parties store.PartyStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		rows, err := db.Query("SELECT id, name, energy_left, is_active FROM parties")
+		list, err := parties.List()
 		if err != nil {
-			log.Printf("获取 Party 列表失败: %v", err)
+			logging.From(c).Error("获取 Party 列表失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		defer rows.Close()
+		success(c, "获取 Party 列表成功", gin.H{"parties": list})
+	}
+}
 
-		parties := make([]models.Party, 0)
-		for rows.Next() {
-			var party models.Party
-			if err := rows.Scan(&party.ID, &party.Name, &party.EnergyLeft, &party.IsActive); err != nil {
-				log.Printf("扫描 Party 失败: %v", err)
-				serverError(c, "服务器错误")
-				return
-			}
-			parties = append(parties, party)
+// GetMyParties 返回当前用户创建的 Party。
+func GetMyParties(parties store.PartyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			unauthorized(c, "用户未登录")
+			return
 		}
-		if err := rows.Err(); err != nil {
-			log.Printf("遍历 Party 行失败: %v", err)
+		list, err := parties.ListByOwner(userID)
+		if err != nil {
+			logging.From(c).Error("获取用户创建的 Party 失败", "user_id", userID, "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		success(c, "获取 Party 列表成功", gin.H{"parties": parties})
+		success(c, "获取 Party 列表成功", gin.H{"parties": list})
 	}
 }
 
-func GetPartyByID(db *sql.DB) gin.HandlerFunc {
+func GetPartyByID(parties store.PartyStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
 			badRequest(c, "无效的请求数据")
 			return
 		}
-		var party models.Party
-		row := db.QueryRow("SELECT id, name, energy_left, is_active FROM parties WHERE id = ?", id)
-		if err := row.Scan(&party.ID, &party.Name, &party.EnergyLeft, &party.IsActive); err != nil {
-			log.Printf("Party %v 不存在: %v", id, err)
+		party, err := parties.Get(id)
+		if err != nil {
+			logging.From(c).Warn("Party 不存在", "party_id", id, "err", err)
 			notFound(c, "资源未找到")
 			return
 		}
+		party.Password = ""
 		success(c, "获取 Party 成功", gin.H{"party": party})
 	}
 }
 
-func UpdateParty(db *sql.DB) gin.HandlerFunc {
+func UpdateParty(parties store.PartyStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
@@ -106,65 +166,280 @@ func UpdateParty(db *sql.DB) gin.HandlerFunc {
 			badRequest(c, "Party 名称和精力值不能为空或无效")
 			return
 		}
-		var hashedPassword string
+		if !validWindow(&party) {
+			badRequest(c, "截止时间必须晚于开放时间")
+			return
+		}
+		existing, err := parties.Get(id)
+		if err != nil {
+			logging.From(c).Warn("Party 不存在", "party_id", id, "err", err)
+			notFound(c, "资源未找到")
+			return
+		}
+		if party.BudgetMode == "" {
+			party.BudgetMode = existing.BudgetMode
+			party.MemberBudget = existing.MemberBudget
+		}
+		if msg := validateBudget(&party); msg != "" {
+			badRequest(c, msg)
+			return
+		}
 		if party.Password != "" {
 			hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(party.Password), bcrypt.DefaultCost)
 			if err != nil {
-				log.Printf("密码加密失败: %v", err)
+				logging.From(c).Error("密码加密失败", "err", err)
 				serverError(c, "服务器错误")
 				return
 			}
-			hashedPassword = string(hashedPasswordBytes)
+			party.Password = string(hashedPasswordBytes)
 		} else {
-			row := db.QueryRow("SELECT password FROM parties WHERE id = ?", id)
-			if err := row.Scan(&hashedPassword); err != nil {
-				log.Printf("Party %v 不存在: %v", id, err)
+			party.Password = existing.Password
+		}
+		party.ID = id
+		if err := parties.Update(&party); err != nil {
+			switch {
+			case errors.Is(err, store.ErrDuplicate):
+				badRequest(c, "Party 名称已存在")
+			case errors.Is(err, store.ErrInvalidCollection):
+				badRequest(c, "菜单集不存在")
+			case errors.Is(err, store.ErrInvalidRestaurant):
+				badRequest(c, "餐厅不存在")
+			case errors.Is(err, store.ErrNotFound):
+				notFound(c, "资源未找到")
+			default:
+				logging.From(c).Error("更新 Party 失败", "party_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		after, _ := parties.Get(id)
+		recordAudit(c, audit, "party.update", "party", id, auditParty(existing), auditParty(after))
+		success(c, "Party 更新成功")
+	}
+}
+
+// UpdatePartyState 变更 Party 状态，未指定目标状态时推进到生命周期中的下一个状态。
+func UpdatePartyState(parties store.PartyStore, hub *events.Hub, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		var req struct {
+			State string `json:"state"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		if req.State == "" {
+			party, err := parties.Get(id)
+			if err != nil {
+				logging.From(c).Warn("Party 不存在", "party_id", id, "err", err)
 				notFound(c, "资源未找到")
 				return
 			}
+			req.State = models.NextPartyState(party.State)
+			if req.State == "" {
+				conflict(c, "Party 已归档，无法继续推进")
+				return
+			}
 		}
-		result, err := db.Exec("UPDATE parties SET name = ?, password = ?, energy_left = ?, is_active = ? WHERE id = ?", party.Name, hashedPassword, party.EnergyLeft, party.IsActive, id)
+		if !models.ValidPartyState(req.State) {
+			badRequest(c, "无效的 Party 状态")
+			return
+		}
+		actorID, _ := currentUserID(c)
+		from, err := parties.Transition(id, req.State, actorID)
 		if err != nil {
-			if isUniqueConstraint(err) {
-				badRequest(c, "Party 名称已存在")
-			} else {
-				log.Printf("更新 Party %v 失败: %v", id, err)
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				notFound(c, "资源未找到")
+			case errors.Is(err, store.ErrInvalidTransition):
+				conflict(c, fmt.Sprintf("Party 状态不能从 %s 变更为 %s", from, req.State))
+			default:
+				logging.From(c).Error("变更 Party 状态失败", "party_id", id, "err", err)
 				serverError(c, "服务器错误")
 			}
 			return
 		}
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
+		logging.From(c).Info("Party 状态已变更", "actor_id", actorID, "party_id", id, "from", from, "to", req.State)
+		hub.Publish(id, events.StateChanged, gin.H{"from": from, "state": req.State})
+		recordAudit(c, audit, "party.state", "party", id, gin.H{"state": from}, gin.H{"state": req.State})
+		success(c, "Party 状态更新成功", gin.H{"from": from, "state": req.State})
+	}
+}
+
+func GetPartyHistory(parties store.PartyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		if _, err := parties.Get(id); err != nil {
+			logging.From(c).Warn("Party 不存在", "party_id", id, "err", err)
 			notFound(c, "资源未找到")
 			return
 		}
-		success(c, "Party 更新成功")
+		history, err := parties.History(id)
+		if err != nil {
+			logging.From(c).Error("获取 Party 状态历史失败", "party_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		success(c, "获取 Party 状态历史成功", gin.H{"history": history})
 	}
 }
 
-func DeleteParty(db *sql.DB) gin.HandlerFunc {
+func GetPartyMembers(parties store.PartyStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
 			badRequest(c, "无效的请求数据")
 			return
 		}
-		result, err := db.Exec("DELETE FROM parties WHERE id = ?", id)
+		if _, err := parties.Get(id); err != nil {
+			logging.From(c).Warn("Party 不存在", "party_id", id, "err", err)
+			notFound(c, "资源未找到")
+			return
+		}
+		members, err := parties.MemberBudgets(id)
 		if err != nil {
-			log.Printf("删除 Party %v 失败: %v", id, err)
+			logging.From(c).Error("获取 Party 成员额度失败", "party_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		success(c, "获取成员额度成功", gin.H{"members": members})
+	}
+}
+
+// UpdateMemberBudget 覆盖单个成员的额度，budget 为 null 表示不限额。
+func UpdateMemberBudget(parties store.PartyStore, hub *events.Hub, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		var req struct {
+			Budget *int `json:"budget"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		if req.Budget != nil && *req.Budget < 0 {
+			badRequest(c, "成员额度不能为负数")
+			return
+		}
+		var before *int
+		if budgets, err := parties.MemberBudgets(id); err == nil {
+			for _, m := range budgets {
+				if m.UserID == userID {
+					before = m.Budget
+				}
+			}
+		}
+		if err := parties.SetMemberBudget(id, userID, req.Budget); err != nil {
+			if errors.Is(err, store.ErrNotMember) {
+				notFound(c, "该用户不是此 Party 成员")
+				return
+			}
+			logging.From(c).Error("设置 Party 成员额度失败", "party_id", id, "member_id", userID, "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
+		hub.Publish(id, events.BudgetChanged, gin.H{"user_id": userID, "budget": req.Budget})
+		recordAudit(c, audit, "party.member_budget", "party", id, gin.H{"user_id": userID, "budget": before}, gin.H{"user_id": userID, "budget": req.Budget})
+		success(c, "成员额度更新成功")
+	}
+}
+
+// RemovePartyMember 将成员移出 Party，其订单一并删除并退还精力。
+func RemovePartyMember(parties store.PartyStore, hub *events.Hub, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		member, err := parties.IsMember(id, userID)
+		if err != nil {
+			logging.From(c).Error("查询 Party 成员失败", "party_id", id, "member_id", userID, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		if !member {
+			notFound(c, "该用户不是此 Party 成员")
+			return
+		}
+		if err := parties.RemoveMember(id, userID); err != nil {
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+				notFound(c, "资源未找到")
+			case errors.Is(err, store.ErrPartyNotOpen):
+				conflict(c, "Party 已锁定，无法移除成员")
+			default:
+				logging.From(c).Error("移除 Party 成员失败", "party_id", id, "member_id", userID, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		actorID, _ := currentUserID(c)
+		logging.From(c).Info("移除 Party 成员", "actor_id", actorID, "party_id", id, "member_id", userID)
+		hub.Publish(id, events.MemberLeft, gin.H{"user_id": userID, "removed_by": actorID})
+		publishEnergy(c, hub, parties, id)
+		recordAudit(c, audit, "party.member_remove", "party", id, gin.H{"user_id": userID}, nil)
+		success(c, "成员移除成功")
+	}
+}
+
+// DeleteParty 软删除 Party，成员与订单保留在历史记录中。
+func DeleteParty(parties store.PartyStore, hub *events.Hub, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		party, err := parties.Get(id)
+		if err != nil || party.DeletedAt != nil {
+			logging.From(c).Warn("Party 不存在", "party_id", id, "err", err)
 			notFound(c, "资源未找到")
 			return
 		}
+		actorID, _ := currentUserID(c)
+		if err := parties.Delete(id, actorID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "资源未找到")
+			} else {
+				logging.From(c).Error("删除 Party 失败", "party_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		logging.From(c).Info("删除 Party", "actor_id", actorID, "party_id", id)
+		if party.State != models.PartyArchived {
+			hub.Publish(id, events.StateChanged, gin.H{"from": party.State, "state": models.PartyArchived})
+		}
+		recordAudit(c, audit, "party.delete", "party", id, auditParty(party), nil)
 		success(c, "Party 删除成功")
 	}
 }
 
-func JoinParty(db *sql.DB) gin.HandlerFunc {
+func JoinParty(parties store.PartyStore, hub *events.Hub, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var joinRequest struct {
 			PartyName string `json:"party_name"`
@@ -179,101 +454,107 @@ func JoinParty(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 		session := sessions.Default(c)
-		userID, ok := session.Get("user_id").(int)
-		if !ok || userID <= 0 {
+		userID, ok := currentUserID(c)
+		if !ok {
 			unauthorized(c, "用户未登录")
 			return
 		}
-		var party models.Party
-		row := db.QueryRow("SELECT id, name, password, energy_left, is_active FROM parties WHERE name = ? AND is_active = ?", joinRequest.PartyName, true)
-		if err := row.Scan(&party.ID, &party.Name, &party.Password, &party.EnergyLeft, &party.IsActive); err != nil {
-			log.Printf("Party %s 不存在或已关闭: %v", joinRequest.PartyName, err)
+		party, err := parties.GetByName(joinRequest.PartyName)
+		if err != nil || party.State != models.PartyOpen {
+			logging.From(c).Warn("Party 不存在或未开放", "party_name", joinRequest.PartyName, "err", err)
 			unauthorized(c, "Party 不存在或已关闭")
 			return
 		}
 		if err := bcrypt.CompareHashAndPassword([]byte(party.Password), []byte(joinRequest.Password)); err != nil {
-			log.Printf("Party %s 密码错误", joinRequest.PartyName)
+			logging.From(c).Warn("Party 密码错误", "party_name", joinRequest.PartyName)
 			unauthorized(c, "Party 密码错误")
 			return
 		}
 		session.Set("party_id", party.ID)
 		if err := session.Save(); err != nil {
-			log.Printf("保存 session 失败: %v", err)
+			logging.From(c).Error("保存 session 失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		_, err := db.Exec("INSERT OR IGNORE INTO party_members (party_id, user_id) VALUES (?, ?)", party.ID, userID)
-		if err != nil {
-			log.Printf("记录用户 %v 加入 Party %v 失败: %v", userID, party.ID, err)
-			serverError(c, "服务器错误")
-			return
-		}
-		_, err = db.Exec("INSERT OR IGNORE INTO orders (party_id, user_id, menu_id) VALUES (?, ?, 0)", party.ID, userID)
-		if err != nil {
-			log.Printf("记录用户 %v 加入 Party %v 的订单失败: %v", userID, party.ID, err)
+		if err := parties.AddMember(party.ID, userID); err != nil {
+			logging.From(c).Error("记录用户加入 Party 失败", "user_id", userID, "party_id", party.ID, "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		log.Printf("用户 %v 加入 Party %v (%s) 成功", userID, party.ID, party.Name)
+		logging.From(c).Info("用户加入 Party 成功", "user_id", userID, "party_id", party.ID, "party_name", party.Name)
+		hub.Publish(party.ID, events.MemberJoined, gin.H{"user_id": userID})
+		recordAudit(c, audit, "party.join", "party", party.ID, nil, nil)
 		success(c, "加入 Party 成功", gin.H{
-			"party_id":   party.ID,
-			"party_name": party.Name,
+			"party_id":    party.ID,
+			"party_name":  party.Name,
+			"party_state": party.State,
 		})
 	}
 }
 
-func LeaveParty(db *sql.DB) gin.HandlerFunc {
+func LeaveParty(parties store.PartyStore, hub *events.Hub, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
-		userID := session.Get("user_id")
-		partyID := session.Get("party_id")
-		if partyID == nil {
+		userID, _ := currentUserID(c)
+		partyID, ok := sessionInt(session, "party_id")
+		if !ok {
 			badRequest(c, "未加入任何 Party")
 			return
 		}
-		_, err := db.Exec("DELETE FROM party_members WHERE user_id = ? AND party_id = ?", userID, partyID)
-		if err != nil {
-			log.Printf("用户 %v 离开 Party %v 失败: %v", userID, partyID, err)
-			serverError(c, "服务器错误")
-			return
-		}
-		_, err = db.Exec("DELETE FROM orders WHERE user_id = ? AND party_id = ?", userID, partyID)
-		if err != nil {
-			log.Printf("用户 %v 删除 Party %v 订单失败: %v", userID, partyID, err)
+		party, err := parties.Get(partyID)
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+		case err != nil:
+			logging.From(c).Error("获取 Party 失败", "party_id", partyID, "err", err)
 			serverError(c, "服务器错误")
 			return
+		case party.State == models.PartyArchived:
+			// 已归档的 Party 保留成员与订单记录，只清除会话
+		default:
+			if err := parties.RemoveMember(partyID, userID); err != nil {
+				if errors.Is(err, store.ErrPartyNotOpen) {
+					conflict(c, "Party 已锁定，无法离开")
+					return
+				}
+				logging.From(c).Error("用户离开 Party 失败", "user_id", userID, "party_id", partyID, "err", err)
+				serverError(c, "服务器错误")
+				return
+			}
+			hub.Publish(partyID, events.MemberLeft, gin.H{"user_id": userID})
+			publishEnergy(c, hub, parties, partyID)
 		}
 		session.Delete("party_id")
 		if err := session.Save(); err != nil {
-			log.Printf("保存 session 失败: %v", err)
+			logging.From(c).Error("保存 session 失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		log.Printf("用户 %v 离开 Party %v 成功", userID, partyID)
+		logging.From(c).Info("用户离开 Party 成功", "user_id", userID, "party_id", partyID)
+		recordAudit(c, audit, "party.leave", "party", partyID, nil, nil)
 		success(c, "离开 Party 成功")
 	}
 }
 
-func GetCurrentParty(db *sql.DB) gin.HandlerFunc {
+func GetCurrentParty(parties store.PartyStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
-		partyID := session.Get("party_id")
-		if partyID == nil {
+		partyID, ok := sessionInt(session, "party_id")
+		if !ok {
 			c.JSON(200, gin.H{"message": "未加入 Party", "hasParty": false})
 			return
 		}
-		var party models.Party
-		row := db.QueryRow("SELECT id, name, energy_left, is_active FROM parties WHERE id = ?", partyID)
-		if err := row.Scan(&party.ID, &party.Name, &party.EnergyLeft, &party.IsActive); err != nil {
-			log.Printf("获取 Party %v 信息失败: %v", partyID, err)
+		party, err := parties.Get(partyID)
+		if err != nil {
+			logging.From(c).Error("获取 Party 信息失败", "party_id", partyID, "err", err)
 			c.JSON(200, gin.H{"message": "未加入 Party", "hasParty": false})
 			return
 		}
 		c.JSON(200, gin.H{
-			"message":    "获取 Party 成功",
-			"hasParty":   true,
-			"party_id":   party.ID,
-			"party_name": party.Name,
+			"message":     "获取 Party 成功",
+			"hasParty":    true,
+			"party_id":    party.ID,
+			"party_name":  party.Name,
+			"party_state": party.State,
 		})
 	}
 }
@@ -1,82 +1,157 @@
 package handlers
 
 import (
-	"database/sql"
-	"encoding/json"
-	"log"
+	"DineTogether/logging"
+	"DineTogether/middleware"
+	"DineTogether/models"
+	"DineTogether/store"
+	"strconv"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
-type OrderItem struct {
-	ID         int      `json:"id"`
-	Username   string   `json:"username"`
-	MenuName   string   `json:"menu_name"`
-	MenuID     int      `json:"menu_id"`
-	ImageURLs  []string `json:"image_urls"`
-	EnergyCost int      `json:"energy_cost"`
-	Quantity   int      `json:"quantity"`
+// sessionPartyMember 检查当前用户是否仍是 session 中 party_id 对应 Party 的成员，
+// 被移除或已离开时清除 session 中的 party_id，避免继续读取该 Party 的数据。
+func sessionPartyMember(c *gin.Context, parties store.PartyStore, partyID int) (bool, error) {
+	userID, _ := currentUserID(c)
+	isMember, err := parties.IsMember(partyID, userID)
+	if err != nil || isMember {
+		return isMember, err
+	}
+	session := sessions.Default(c)
+	session.Delete("party_id")
+	if err := session.Save(); err != nil {
+		logging.From(c).Error("保存 session 失败", "err", err)
+	}
+	return false, nil
 }
 
-func GetPartyOrders(db *sql.DB) gin.HandlerFunc {
+func GetPartyOrders(parties store.PartyStore, orders store.OrderStore, restaurants store.RestaurantStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
-		partyID := session.Get("party_id")
-		if partyID == nil {
-			c.JSON(200, gin.H{"error": "未加入任何 Party", "success": false})
+		partyID, ok := sessionInt(session, "party_id")
+		if !ok {
+			c.JSON(200, middleware.ErrorBody(c, "未加入任何 Party"))
 			return
 		}
-		var energyLeft int
-		row := db.QueryRow("SELECT energy_left FROM parties WHERE id = ?", partyID)
-		if err := row.Scan(&energyLeft); err != nil {
-			log.Printf("获取 Party %v 剩余精力失败: %v", partyID, err)
+		isMember, err := sessionPartyMember(c, parties, partyID)
+		if err != nil {
+			logging.From(c).Error("检查用户是否为 Party 成员失败", "party_id", partyID, "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		rows, err := db.Query(`
-			SELECT MIN(o.id) as id, u.username, m.name, m.id, m.image_urls, m.energy_cost, COUNT(*) as quantity
-			FROM orders o
-			JOIN users u ON o.user_id = u.id
-			JOIN menus m ON o.menu_id = m.id
-			WHERE o.party_id = ?
-			GROUP BY u.id, m.id`, partyID)
+		if !isMember {
+			forbidden(c, "未加入此 Party")
+			return
+		}
+		party, err := parties.Get(partyID)
 		if err != nil {
-			log.Printf("获取 Party %v 订单失败: %v", partyID, err)
+			logging.From(c).Error("获取 Party 剩余精力失败", "party_id", partyID, "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		defer rows.Close()
-
-		orders := make([]OrderItem, 0)
-		for rows.Next() {
-			var order OrderItem
-			var imageURLs sql.NullString
-			if err := rows.Scan(&order.ID, &order.Username, &order.MenuName, &order.MenuID, &imageURLs, &order.EnergyCost, &order.Quantity); err != nil {
-				log.Printf("扫描订单失败: %v", err)
-				serverError(c, "服务器错误")
-				return
-			}
-			if imageURLs.Valid {
-				if err := json.Unmarshal([]byte(imageURLs.String), &order.ImageURLs); err != nil {
-					log.Printf("解析 image_urls 失败: %v", err)
-					serverError(c, "服务器错误")
-					return
-				}
-			} else {
-				order.ImageURLs = []string{}
-			}
-			orders = append(orders, order)
+		list, err := orders.ListByParty(partyID)
+		if err != nil {
+			logging.From(c).Error("获取 Party 订单失败", "party_id", partyID, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		summary, err := orders.KitchenSummary(partyID)
+		if err != nil {
+			logging.From(c).Error("汇总 Party 订单失败", "party_id", partyID, "err", err)
+			serverError(c, "服务器错误")
+			return
 		}
-		if err := rows.Err(); err != nil {
-			log.Printf("遍历订单行失败: %v", err)
+		restaurantList, err := restaurants.List()
+		if err != nil {
+			logging.From(c).Error("查询餐厅失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		log.Printf("获取 Party %v 的订单成功，数量: %d", partyID, len(orders))
+		members, err := parties.MemberBudgets(partyID)
+		if err != nil {
+			logging.From(c).Error("获取 Party 成员额度失败", "party_id", partyID, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		logging.From(c).Info("获取 Party 订单成功", "party_id", partyID, "count", len(list))
 		success(c, "获取订单成功", gin.H{
-			"orders":      orders,
-			"energy_left": energyLeft,
+			"orders":      list,
+			"summary":     summary,
+			"restaurants": splitByRestaurant(summary, party, restaurantList),
+			"energy_left": party.EnergyLeft,
+			"state":       party.State,
+			"budget_mode": party.BudgetMode,
+			"members":     members,
 		})
 	}
 }
+
+// ListPartyOrders 按 Party ID 返回订单，供 Party 管理者查看和删除成员订单。
+func ListPartyOrders(parties store.PartyStore, orders store.OrderStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		if _, err := parties.Get(id); err != nil {
+			logging.From(c).Warn("Party 不存在", "party_id", id, "err", err)
+			notFound(c, "资源未找到")
+			return
+		}
+		list, err := orders.ListByParty(id)
+		if err != nil {
+			logging.From(c).Error("获取 Party 订单失败", "party_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		success(c, "获取订单成功", gin.H{"orders": list})
+	}
+}
+
+// splitByRestaurant 将出餐汇总按餐厅拆分，便于分别下单。Party 合作的餐厅即使暂无订单也会列出，
+// 未关联餐厅的菜品归入 Restaurant 为空的一组并排在最后。
+func splitByRestaurant(summary []models.KitchenItem, party *models.Party, restaurants []models.Restaurant) []models.RestaurantOrder {
+	byID := make(map[int]*models.Restaurant, len(restaurants))
+	for i := range restaurants {
+		byID[restaurants[i].ID] = &restaurants[i]
+	}
+	groups := make([]models.RestaurantOrder, 0)
+	index := make(map[int]int)
+	group := func(id int) *models.RestaurantOrder {
+		if i, ok := index[id]; ok {
+			return &groups[i]
+		}
+		index[id] = len(groups)
+		groups = append(groups, models.RestaurantOrder{Restaurant: byID[id], Items: []models.KitchenItem{}})
+		return &groups[len(groups)-1]
+	}
+	for _, id := range party.RestaurantIDs {
+		if byID[id] != nil {
+			group(id)
+		}
+	}
+	var unassigned []models.KitchenItem
+	for _, item := range summary {
+		if item.RestaurantID == nil || byID[*item.RestaurantID] == nil {
+			unassigned = append(unassigned, item)
+			continue
+		}
+		g := group(*item.RestaurantID)
+		g.Items = append(g.Items, item)
+		g.Subtotal += item.Energy
+	}
+	for i := range groups {
+		groups[i].BelowMinimum = groups[i].Subtotal > 0 && groups[i].Subtotal < groups[i].Restaurant.MinOrder
+	}
+	if len(unassigned) > 0 {
+		g := models.RestaurantOrder{Items: unassigned}
+		for _, item := range unassigned {
+			g.Subtotal += item.Energy
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
@@ -1,8 +1,8 @@
 package handlers
 
 import (
+	"DineTogether/middleware"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,28 +18,25 @@ func success(c *gin.Context, message string, data ...gin.H) {
 }
 
 func badRequest(c *gin.Context, message string) {
-	c.JSON(http.StatusBadRequest, gin.H{"error": message, "success": false})
+	c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, message))
 }
 
 func notFound(c *gin.Context, message string) {
-	c.JSON(http.StatusNotFound, gin.H{"error": message, "success": false})
+	c.JSON(http.StatusNotFound, middleware.ErrorBody(c, message))
 }
 
 func serverError(c *gin.Context, message string) {
-	c.JSON(http.StatusInternalServerError, gin.H{"error": message, "success": false})
+	c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, message))
 }
 
-func unauthorized(c *gin.Context, message string) {
-	c.JSON(http.StatusUnauthorized, gin.H{"error": message, "success": false})
+func conflict(c *gin.Context, message string) {
+	c.JSON(http.StatusConflict, middleware.ErrorBody(c, message))
 }
 
-func forbidden(c *gin.Context, message string) {
-	c.JSON(http.StatusForbidden, gin.H{"error": message, "success": false})
+func unauthorized(c *gin.Context, message string) {
+	c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, message))
 }
 
-func isUniqueConstraint(err error) bool {
-	if err == nil {
-		return false
-	}
-	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+func forbidden(c *gin.Context, message string) {
+	c.JSON(http.StatusForbidden, middleware.ErrorBody(c, message))
 }
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"DineTogether/logging"
+	"DineTogether/models"
+	"DineTogether/store"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetRestaurants(restaurants store.RestaurantStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := restaurants.List()
+		if err != nil {
+			logging.From(c).Error("查询餐厅失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		success(c, "获取餐厅列表成功", gin.H{"restaurants": list})
+	}
+}
+
+func CreateRestaurant(restaurants store.RestaurantStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var restaurant models.Restaurant
+		if err := c.ShouldBindJSON(&restaurant); err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		if msg := validateRestaurant(&restaurant); msg != "" {
+			badRequest(c, msg)
+			return
+		}
+		id, err := restaurants.Create(&restaurant)
+		if err != nil {
+			if errors.Is(err, store.ErrDuplicate) {
+				badRequest(c, "餐厅名称已存在")
+			} else {
+				logging.From(c).Error("创建餐厅失败", "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		restaurant.ID = id
+		recordAudit(c, audit, "restaurant.create", "restaurant", id, nil, restaurant)
+		success(c, "餐厅创建成功", gin.H{"restaurant_id": id})
+	}
+}
+
+func UpdateRestaurant(restaurants store.RestaurantStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		var restaurant models.Restaurant
+		if err := c.ShouldBindJSON(&restaurant); err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		if msg := validateRestaurant(&restaurant); msg != "" {
+			badRequest(c, msg)
+			return
+		}
+		restaurant.ID = id
+		before, _ := restaurants.Get(id)
+		if err := restaurants.Update(&restaurant); err != nil {
+			switch {
+			case errors.Is(err, store.ErrDuplicate):
+				badRequest(c, "餐厅名称已存在")
+			case errors.Is(err, store.ErrNotFound):
+				notFound(c, "餐厅不存在")
+			default:
+				logging.From(c).Error("更新餐厅失败", "restaurant_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		after, _ := restaurants.Get(id)
+		recordAudit(c, audit, "restaurant.update", "restaurant", id, before, after)
+		success(c, "餐厅更新成功")
+	}
+}
+
+func DeleteRestaurant(restaurants store.RestaurantStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		before, _ := restaurants.Get(id)
+		if err := restaurants.Delete(id); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "餐厅不存在")
+			} else {
+				logging.From(c).Error("删除餐厅失败", "restaurant_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		recordAudit(c, audit, "restaurant.delete", "restaurant", id, before, nil)
+		success(c, "餐厅删除成功")
+	}
+}
+
+func validateRestaurant(restaurant *models.Restaurant) string {
+	restaurant.Name = strings.TrimSpace(restaurant.Name)
+	restaurant.Contact = strings.TrimSpace(restaurant.Contact)
+	restaurant.Address = strings.TrimSpace(restaurant.Address)
+	restaurant.OpeningHours = strings.TrimSpace(restaurant.OpeningHours)
+	if restaurant.Name == "" {
+		return "餐厅名称不能为空"
+	}
+	if restaurant.MinOrder < 0 || restaurant.DeliveryFee < 0 {
+		return "起送精力和配送费不能为负数"
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"DineTogether/logging"
+	"DineTogether/sessionstore"
+	"DineTogether/store"
+	"errors"
+	"strconv"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// currentSessionKey 返回当前请求会话在存储中的 ID，尚未保存的会话返回空字符串。
+func currentSessionKey(session sessions.Session) string {
+	if id := session.ID(); id != "" {
+		return sessionstore.Key(id)
+	}
+	return ""
+}
+
+// revokeUserSessions 使用户除 exceptID 外的会话全部失效，用于角色或密码变更后。
+// 返回错误时旧会话可能仍然有效，调用方应返回失败而不是提示操作成功。
+func revokeUserSessions(c *gin.Context, sessionStore store.SessionStore, userID int, exceptID string) error {
+	n, err := sessionStore.DeleteByUser(userID, exceptID)
+	if err != nil {
+		logging.From(c).Error("吊销用户会话失败", "user_id", userID, "err", err)
+		return err
+	}
+	if n > 0 {
+		logging.From(c).Info("已吊销用户会话", "user_id", userID, "count", n)
+	}
+	return nil
+}
+
+// GetMySessions 返回当前用户的所有登录会话，current 标记本次请求所用的会话。
+func GetMySessions(sessionStore store.SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := sessions.Default(c)
+		userID, ok := currentUserID(c)
+		if !ok {
+			unauthorized(c, "用户未登录")
+			return
+		}
+		list, err := sessionStore.ListByUser(userID)
+		if err != nil {
+			logging.From(c).Error("获取会话列表失败", "user_id", userID, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		current := currentSessionKey(session)
+		for i := range list {
+			list[i].Current = list[i].ID == current
+		}
+		success(c, "获取会话列表成功", gin.H{"sessions": list})
+	}
+}
+
+// RevokeMySession 注销当前用户的指定会话。
+func RevokeMySession(sessionStore store.SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
+			unauthorized(c, "用户未登录")
+			return
+		}
+		id := c.Param("id")
+		target, err := sessionStore.Get(id)
+		if err != nil || target.UserID == nil || *target.UserID != userID {
+			if err != nil && !errors.Is(err, store.ErrNotFound) {
+				logging.From(c).Error("获取会话失败", "err", err)
+				serverError(c, "服务器错误")
+				return
+			}
+			notFound(c, "会话不存在")
+			return
+		}
+		if err := sessionStore.Delete(id); err != nil && !errors.Is(err, store.ErrNotFound) {
+			logging.From(c).Error("注销会话失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		success(c, "会话已注销")
+	}
+}
+
+// LogoutAll 注销当前用户的全部会话，包括本次请求所用的会话。
+func LogoutAll(sessionStore store.SessionStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := sessions.Default(c)
+		userID, ok := currentUserID(c)
+		if !ok {
+			unauthorized(c, "用户未登录")
+			return
+		}
+		if _, err := sessionStore.DeleteByUser(userID, ""); err != nil {
+			logging.From(c).Error("注销全部会话失败", "user_id", userID, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		session.Clear()
+		session.Save()
+		logging.From(c).Info("用户已退出全部设备", "user_id", userID)
+		success(c, "已退出全部设备")
+	}
+}
+
+// RevokeUserSessions 由管理员强制注销指定用户的全部会话。
+func RevokeUserSessions(sessionStore store.SessionStore, audit store.AuditStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		n, err := sessionStore.DeleteByUser(id, "")
+		if err != nil {
+			logging.From(c).Error("吊销用户会话失败", "user_id", id, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		recordAudit(c, audit, "user.sessions.revoke", "user", id, nil, gin.H{"count": n})
+		success(c, "已注销该用户的全部会话", gin.H{"count": n})
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"DineTogether/logging"
+	"DineTogether/models"
+	"DineTogether/store"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const DefaultStatsLimit = 10
+
+// GetStats 返回时间范围内的消费概况、按 group 分组的汇总与未参加任何 Party 的用户。
+// group 为 day/week/month 时按下单时间分组，为 user/dish 时返回份数最多的 limit 个用户或菜品。
+func GetStats(stats store.StatsStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var filter store.StatsFilter
+		var ok bool
+		if filter.From, filter.To, ok = parseTimeRange(c); !ok {
+			badRequest(c, "无效的时间范围")
+			return
+		}
+		filter.GroupBy = c.DefaultQuery("group", models.StatsByDay)
+		if !models.ValidStatsGroup(filter.GroupBy) {
+			badRequest(c, "无效的统计分组")
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(DefaultStatsLimit)))
+		if err != nil || limit < 0 || limit > MaxPageSize {
+			badRequest(c, "无效的数量限制")
+			return
+		}
+		filter.Limit = limit
+		overview, err := stats.Overview(filter)
+		if err != nil {
+			logging.From(c).Error("统计消费概况失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		series, err := stats.Series(filter)
+		if err != nil {
+			logging.From(c).Error("按分组统计失败", "group", filter.GroupBy, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		inactive, err := stats.InactiveUsers(filter)
+		if err != nil {
+			logging.From(c).Error("统计未参加 Party 的用户失败", "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		success(c, "获取统计数据成功", gin.H{
+			"group":          filter.GroupBy,
+			"overview":       overview,
+			"series":         series,
+			"inactive_users": inactive,
+		})
+	}
+}
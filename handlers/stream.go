@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"DineTogether/events"
+	"DineTogether/logging"
+	"DineTogether/store"
+	"io"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const streamHeartbeat = 25 * time.Second
+
+// PartyStream 以 Server-Sent Events 向 Party 成员推送订单、成员、精力与状态变化。
+func PartyStream(parties store.PartyStore, hub *events.Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := sessions.Default(c)
+		userID, ok := currentUserID(c)
+		if !ok {
+			unauthorized(c, "用户未登录")
+			return
+		}
+		partyID, ok := sessionInt(session, "party_id")
+		if !ok {
+			badRequest(c, "未加入任何 Party")
+			return
+		}
+		isMember, err := sessionPartyMember(c, parties, partyID)
+		if err != nil {
+			logging.From(c).Error("检查用户是否为 Party 成员失败", "user_id", userID, "party_id", partyID, "err", err)
+			serverError(c, "服务器错误")
+			return
+		}
+		if !isMember {
+			forbidden(c, "未加入此 Party")
+			return
+		}
+
+		ch, cancel := hub.Subscribe(partyID)
+		defer cancel()
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.SSEvent("ready", gin.H{"party_id": partyID})
+		c.Writer.Flush()
+
+		// 成员被移除或退出后应立即断开，订阅者处理过慢时事件可能被丢弃，心跳时也重新检查
+		stillMember := func() bool {
+			isMember, err := parties.IsMember(partyID, userID)
+			if err != nil {
+				logging.From(c).Error("检查用户是否为 Party 成员失败", "user_id", userID, "party_id", partyID, "err", err)
+				return false
+			}
+			return isMember
+		}
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case e, ok := <-ch:
+				if !ok {
+					return false
+				}
+				c.SSEvent(e.Type, e)
+				return e.Type != events.MemberLeft || stillMember()
+			case <-heartbeat.C:
+				if !stillMember() {
+					return false
+				}
+				io.WriteString(w, ": ping\n\n")
+				return true
+			}
+		})
+	}
+}
+
+// publishEnergy 推送 Party 最新的剩余精力。
+func publishEnergy(c *gin.Context, hub *events.Hub, parties store.PartyStore, partyID int) {
+	party, err := parties.Get(partyID)
+	if err != nil {
+		logging.From(c).Error("获取 Party 剩余精力失败", "party_id", partyID, "err", err)
+		return
+	}
+	hub.Publish(partyID, events.EnergyChanged, gin.H{"energy_left": party.EnergyLeft})
+}
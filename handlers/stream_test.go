@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"DineTogether/models"
+	"bufio"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// openStream 连接 Party 事件流，返回逐行读取的事件通道，连接断开时通道关闭。
+func (c *testClient) openStream(t *testing.T) <-chan string {
+	t.Helper()
+	resp, err := c.http.Get(c.ts.srv.URL + "/api/party/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		t.Fatalf("订阅事件流: %d", resp.StatusCode)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	lines := make(chan string, 64)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
+
+// waitEvent 等待指定类型的事件，返回 false 表示连接已断开。
+func waitEvent(t *testing.T, lines <-chan string, event string) bool {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return false
+			}
+			if strings.TrimSpace(strings.TrimPrefix(line, "event:")) == event {
+				return true
+			}
+		case <-timeout:
+			t.Fatalf("等待事件 %s 超时", event)
+		}
+	}
+}
+
+func TestPartyStreamClosesForRemovedMember(t *testing.T) {
+	ts := newTestServer(t)
+	ts.router.POST("/join-party", JoinParty(ts.st.Parties, ts.hub, ts.st.Audit))
+	ts.router.POST("/leave-party", LeaveParty(ts.st.Parties, ts.hub, ts.st.Audit))
+	ts.router.GET("/api/party/stream", PartyStream(ts.st.Parties, ts.hub))
+	ts.router.GET("/api/party", GetUserParty(ts.st.Parties))
+	ts.router.GET("/api/party-orders", GetPartyOrders(ts.st.Parties, ts.st.Orders, ts.st.Restaurants))
+	ts.router.DELETE("/party/:id/members/:user_id", RequirePartyAccess(ts.st.Parties, models.PermPartyWrite), RemovePartyMember(ts.st.Parties, ts.hub, ts.st.Audit))
+
+	ownerID := ts.addUser("owner", models.RoleOrganizer)
+	otherOwnerID := ts.addUser("other", models.RoleOrganizer)
+	ts.addParty("dinner", 10, otherOwnerID)
+	bobID := ts.addUser("bob", models.RoleGuest)
+	ts.addUser("carol", models.RoleGuest)
+	ts.addUser("dave", models.RoleGuest)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("pw"), bcrypt.MinCost)
+	partyID, err := ts.st.Parties.Create(&models.Party{Name: "lunch", Password: string(hash), EnergyLeft: 10, State: models.PartyOpen, BudgetMode: models.BudgetShared, OwnerID: &ownerID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	streams := map[string]<-chan string{}
+	clients := map[string]*testClient{}
+	for _, name := range []string{"bob", "carol", "dave"} {
+		c := ts.login(name)
+		if status, body := c.do("POST", "/join-party", gin.H{"party_name": "lunch", "password": "pw"}); status != http.StatusOK {
+			t.Fatalf("%s 加入 Party: %d %v", name, status, body)
+		}
+		clients[name] = c
+		streams[name] = c.openStream(t)
+		if !waitEvent(t, streams[name], "ready") {
+			t.Fatalf("%s 的事件流提前断开", name)
+		}
+	}
+
+	// 其他 Party 的所有者不能移除本 Party 的成员
+	if status, body := ts.login("other").do("DELETE", fmt.Sprintf("/party/%d/members/%d", partyID, bobID), nil); status != http.StatusForbidden {
+		t.Fatalf("其他所有者移除成员: %d %v", status, body)
+	}
+	if status, body := ts.login("owner").do("DELETE", fmt.Sprintf("/party/%d/members/%d", partyID, bobID), nil); status != http.StatusOK {
+		t.Fatalf("移除成员: %d %v", status, body)
+	}
+	if !waitEvent(t, streams["bob"], "member-left") || waitEvent(t, streams["bob"], "never") {
+		t.Fatal("被移除成员的事件流未断开")
+	}
+	// 被移除的成员不能再读取订单，session 中的 party_id 随之清除
+	if status, body := clients["bob"].do("GET", "/api/party-orders", nil); status != http.StatusForbidden {
+		t.Fatalf("被移除成员读取订单: %d %v", status, body)
+	}
+	if status, body := clients["bob"].do("GET", "/api/party-orders", nil); status != http.StatusOK || body["error"] != "未加入任何 Party" {
+		t.Fatalf("清除 party_id 后读取订单: %d %v", status, body)
+	}
+	if status, body := clients["bob"].do("GET", "/api/party", nil); status != http.StatusOK || body["hasParty"] != false {
+		t.Fatalf("被移除成员查询 Party: %d %v", status, body)
+	}
+	if status, body := clients["carol"].do("GET", "/api/party-orders", nil); status != http.StatusOK {
+		t.Fatalf("成员读取订单: %d %v", status, body)
+	}
+	if status, body := clients["dave"].do("POST", "/leave-party", nil); status != http.StatusOK {
+		t.Fatalf("退出 Party: %d %v", status, body)
+	}
+	if !waitEvent(t, streams["dave"], "member-left") || waitEvent(t, streams["dave"], "never") {
+		t.Fatal("退出成员的事件流未断开")
+	}
+	// 其他成员收到两次离开事件后仍保持连接
+	if !waitEvent(t, streams["carol"], "member-left") || !waitEvent(t, streams["carol"], "member-left") {
+		t.Fatal("其他成员的事件流被断开")
+	}
+	ts.hub.Publish(partyID, "order-added", nil)
+	if !waitEvent(t, streams["carol"], "order-added") {
+		t.Fatal("其他成员的事件流被断开")
+	}
+}
@@ -1,28 +1,51 @@
 package handlers
 
 import (
+	"DineTogether/logging"
 	"DineTogether/models"
-	"database/sql"
+	"DineTogether/store"
+	"errors"
 	"fmt"
-	"log"
+	"strconv"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func GetUserInfo(db *sql.DB) gin.HandlerFunc {
+var roleLabels = map[string]string{
+	models.RoleAdmin:      "管理员",
+	models.RoleOrganizer:  "组织者",
+	models.RoleMenuEditor: "菜单编辑",
+	models.RoleViewer:     "查看者",
+	models.RoleGuest:      "普通用户",
+}
+
+// GetRoles 返回可分配的角色及其权限。
+func GetRoles() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		session := sessions.Default(c)
-		userID := session.Get("user_id")
-		if userID == nil {
+		roles := make([]gin.H, 0, len(models.Roles))
+		for _, role := range models.Roles {
+			roles = append(roles, gin.H{
+				"role":        role,
+				"label":       roleLabels[role],
+				"permissions": models.RolePermissions(role),
+			})
+		}
+		success(c, "获取角色列表成功", gin.H{"roles": roles})
+	}
+}
+
+func GetUserInfo(users store.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := currentUserID(c)
+		if !ok {
 			unauthorized(c, "未授权")
 			return
 		}
-		var user models.User
-		row := db.QueryRow("SELECT id, username, role FROM users WHERE id = ?", userID)
-		if err := row.Scan(&user.ID, &user.Username, &user.Role); err != nil {
-			log.Printf("获取用户 %v 信息失败: %v", userID, err)
+		user, err := users.Get(userID)
+		if err != nil {
+			logging.From(c).Error("获取用户信息失败", "user_id", userID, "err", err)
 			notFound(c, "资源未找到")
 			return
 		}
@@ -34,7 +57,7 @@ func GetUserInfo(db *sql.DB) gin.HandlerFunc {
 	}
 }
 
-func CreateUser(db *sql.DB) gin.HandlerFunc {
+func CreateUser(users store.UserStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var user models.User
 		if err := c.ShouldBindJSON(&user); err != nil {
@@ -45,67 +68,67 @@ func CreateUser(db *sql.DB) gin.HandlerFunc {
 			badRequest(c, "用户名、密码和角色不能为空")
 			return
 		}
+		if !models.ValidRole(user.Role) {
+			badRequest(c, "无效的角色")
+			return
+		}
 		if err := ValidatePassword(user.Password); err != nil {
 			badRequest(c, err.Error())
 			return
 		}
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 		if err != nil {
-			log.Printf("密码加密失败: %v", err)
+			logging.From(c).Error("密码加密失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		result, err := db.Exec("INSERT INTO users (username, password, role) VALUES (?, ?, ?)", user.Username, hashedPassword, user.Role)
+		user.Password = string(hashedPassword)
+		id, err := users.Create(&user)
 		if err != nil {
-			if isUniqueConstraint(err) {
+			if errors.Is(err, store.ErrDuplicate) {
 				badRequest(c, "用户名已存在")
 			} else {
-				log.Printf("创建用户失败: %v", err)
+				logging.From(c).Error("创建用户失败", "err", err)
 				serverError(c, "服务器错误")
 			}
 			return
 		}
-		id, _ := result.LastInsertId()
+		user.ID = id
+		recordAudit(c, audit, "user.create", "user", id, nil, auditUser(&user))
 		success(c, "用户创建成功", gin.H{"user_id": id})
 	}
 }
 
-func GetUsers(db *sql.DB) gin.HandlerFunc {
+// auditUser 返回去掉密码哈希的用户信息，用于审计记录。
+func auditUser(user *models.User) any {
+	if user == nil {
+		return nil
+	}
+	return gin.H{"id": user.ID, "username": user.Username, "role": user.Role}
+}
+
+func GetUsers(users store.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		rows, err := db.Query("SELECT id, username, role FROM users")
+		list, err := users.List()
 		if err != nil {
-			log.Printf("获取用户列表失败: %v", err)
+			logging.From(c).Error("获取用户列表失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		defer rows.Close()
-
-		users := make([]models.User, 0)
-		for rows.Next() {
-			var user models.User
-			if err := rows.Scan(&user.ID, &user.Username, &user.Role); err != nil {
-				log.Printf("扫描用户失败: %v", err)
-				serverError(c, "服务器错误")
-				return
-			}
-			users = append(users, user)
-		}
-		if err := rows.Err(); err != nil {
-			log.Printf("遍历用户行失败: %v", err)
-			serverError(c, "服务器错误")
-			return
-		}
-		success(c, "获取用户列表成功", gin.H{"users": users})
+		success(c, "获取用户列表成功", gin.H{"users": list})
 	}
 }
 
-func GetUserByID(db *sql.DB) gin.HandlerFunc {
+func GetUserByID(users store.UserStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
-		var user models.User
-		row := db.QueryRow("SELECT id, username, role FROM users WHERE id = ?", id)
-		if err := row.Scan(&user.ID, &user.Username, &user.Role); err != nil {
-			log.Printf("用户 %s 不存在: %v", id, err)
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
+		user, err := users.Get(id)
+		if err != nil {
+			logging.From(c).Warn("用户不存在", "user_id", id, "err", err)
 			notFound(c, "资源未找到")
 			return
 		}
@@ -117,9 +140,13 @@ func GetUserByID(db *sql.DB) gin.HandlerFunc {
 	}
 }
 
-func UpdateUser(db *sql.DB) gin.HandlerFunc {
+func UpdateUser(users store.UserStore, cache *UserCache, sessionStore store.SessionStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
 		var user models.User
 		if err := c.ShouldBindJSON(&user); err != nil {
 			badRequest(c, "无效的请求数据")
@@ -129,7 +156,10 @@ func UpdateUser(db *sql.DB) gin.HandlerFunc {
 			badRequest(c, "用户名和角色不能为空")
 			return
 		}
-		var hashedPassword string
+		if !models.ValidRole(user.Role) {
+			badRequest(c, "无效的角色")
+			return
+		}
 		if user.Password != "" {
 			if err := ValidatePassword(user.Password); err != nil {
 				badRequest(c, err.Error())
@@ -137,99 +167,121 @@ func UpdateUser(db *sql.DB) gin.HandlerFunc {
 			}
 			hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 			if err != nil {
-				log.Printf("密码加密失败: %v", err)
+				logging.From(c).Error("密码加密失败", "err", err)
 				serverError(c, "服务器错误")
 				return
 			}
-			hashedPassword = string(hashedPasswordBytes)
+			user.Password = string(hashedPasswordBytes)
 		} else {
-			row := db.QueryRow("SELECT password FROM users WHERE id = ?", id)
-			if err := row.Scan(&hashedPassword); err != nil {
-				log.Printf("用户 %s 不存在: %v", id, err)
+			existing, err := users.Get(id)
+			if err != nil {
+				logging.From(c).Warn("用户不存在", "user_id", id, "err", err)
 				notFound(c, "资源未找到")
 				return
 			}
+			user.Password = existing.Password
 		}
-		result, err := db.Exec("UPDATE users SET username = ?, password = ?, role = ? WHERE id = ?", user.Username, hashedPassword, user.Role, id)
-		if err != nil {
-			if isUniqueConstraint(err) {
+		user.ID = id
+		before, _ := users.Get(id)
+		if err := users.Update(&user); err != nil {
+			switch {
+			case errors.Is(err, store.ErrDuplicate):
 				badRequest(c, "用户名已存在")
-			} else {
-				log.Printf("更新用户 %s 失败: %v", id, err)
+			case errors.Is(err, store.ErrNotFound):
+				notFound(c, "资源未找到")
+			default:
+				logging.From(c).Error("更新用户失败", "user_id", id, "err", err)
 				serverError(c, "服务器错误")
 			}
 			return
 		}
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			notFound(c, "资源未找到")
-			return
+		cache.Invalidate(id)
+		recordAudit(c, audit, "user.update", "user", id, auditUser(before), auditUser(&user))
+		// 密码或角色变更后旧会话全部失效
+		if before == nil || before.Password != user.Password || before.Role != user.Role {
+			if err := revokeUserSessions(c, sessionStore, id, ""); err != nil {
+				serverError(c, "服务器错误")
+				return
+			}
 		}
 		success(c, "用户更新成功")
 	}
 }
 
-func UpdateUserRole(db *sql.DB) gin.HandlerFunc {
+func UpdateUserRole(users store.UserStore, cache *UserCache, sessionStore store.SessionStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			badRequest(c, "无效的请求数据")
+			return
+		}
 		var req struct {
 			Role string `json:"role"`
 		}
-		if err := c.ShouldBindJSON(&req); err != nil || (req.Role != "admin" && req.Role != "guest") {
+		if err := c.ShouldBindJSON(&req); err != nil || !models.ValidRole(req.Role) {
 			badRequest(c, "无效的角色")
 			return
 		}
-		session := sessions.Default(c)
-		currentUserID := session.Get("user_id")
-		if currentUserID != nil {
-			currStr := fmt.Sprintf("%v", currentUserID)
-			if currStr == id {
-				badRequest(c, "不能修改自己的角色")
-				return
-			}
-		}
-		result, err := db.Exec("UPDATE users SET role = ? WHERE id = ?", req.Role, id)
-		if err != nil {
-			log.Printf("更新用户 %s 角色失败: %v", id, err)
-			serverError(c, "服务器错误")
+		if currentUserID, ok := currentUserID(c); ok && currentUserID == id {
+			badRequest(c, "不能修改自己的角色")
 			return
 		}
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			notFound(c, "资源未找到")
+		before, _ := users.Get(id)
+		if err := users.UpdateRole(id, req.Role); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "资源未找到")
+			} else {
+				logging.From(c).Error("更新用户角色失败", "user_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
 			return
 		}
-		label := "管理员"
-		if req.Role == "guest" {
-			label = "普通用户"
+		cache.Invalidate(id)
+		var beforeRole any
+		if before != nil {
+			beforeRole = gin.H{"role": before.Role}
+		}
+		recordAudit(c, audit, "user.role", "user", id, beforeRole, gin.H{"role": req.Role})
+		if err := revokeUserSessions(c, sessionStore, id, ""); err != nil {
+			serverError(c, "服务器错误")
+			return
 		}
-		success(c, fmt.Sprintf("已设为%s", label))
+		success(c, fmt.Sprintf("已设为%s", roleLabels[req.Role]))
 	}
 }
 
-func DeleteUser(db *sql.DB) gin.HandlerFunc {
+func DeleteUser(users store.UserStore, cache *UserCache, sessionStore store.SessionStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
-		result, err := db.Exec("DELETE FROM users WHERE id = ?", id)
+		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
-			log.Printf("删除用户 %s 失败: %v", id, err)
-			serverError(c, "服务器错误")
+			badRequest(c, "无效的请求数据")
 			return
 		}
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			notFound(c, "资源未找到")
+		before, _ := users.Get(id)
+		if err := users.Delete(id); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "资源未找到")
+			} else {
+				logging.From(c).Error("删除用户失败", "user_id", id, "err", err)
+				serverError(c, "服务器错误")
+			}
+			return
+		}
+		cache.Invalidate(id)
+		recordAudit(c, audit, "user.delete", "user", id, auditUser(before), nil)
+		if err := revokeUserSessions(c, sessionStore, id, ""); err != nil {
+			serverError(c, "服务器错误")
 			return
 		}
 		success(c, "用户删除成功")
 	}
 }
 
-func ChangePassword(db *sql.DB) gin.HandlerFunc {
+func ChangePassword(users store.UserStore, sessionStore store.SessionStore, audit store.AuditStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
-		userID := session.Get("user_id")
-		if userID == nil {
+		userID, ok := currentUserID(c)
+		if !ok {
 			unauthorized(c, "用户未登录")
 			return
 		}
@@ -249,36 +301,39 @@ func ChangePassword(db *sql.DB) gin.HandlerFunc {
 			badRequest(c, err.Error())
 			return
 		}
-		var currentPassword string
-		row := db.QueryRow("SELECT password FROM users WHERE id = ?", userID)
-		if err := row.Scan(&currentPassword); err != nil {
-			log.Printf("用户 %v 不存在: %v", userID, err)
+		user, err := users.Get(userID)
+		if err != nil {
+			logging.From(c).Warn("用户不存在", "user_id", userID, "err", err)
 			notFound(c, "用户不存在")
 			return
 		}
-		if err := bcrypt.CompareHashAndPassword([]byte(currentPassword), []byte(request.OldPassword)); err != nil {
-			log.Printf("用户 %v 旧密码错误", userID)
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.OldPassword)); err != nil {
+			logging.From(c).Warn("用户旧密码错误", "user_id", userID)
 			unauthorized(c, "旧密码错误")
 			return
 		}
 		hashedNewPassword, err := bcrypt.GenerateFromPassword([]byte(request.NewPassword), bcrypt.DefaultCost)
 		if err != nil {
-			log.Printf("密码加密失败: %v", err)
+			logging.From(c).Error("密码加密失败", "err", err)
 			serverError(c, "服务器错误")
 			return
 		}
-		result, err := db.Exec("UPDATE users SET password = ? WHERE id = ?", hashedNewPassword, userID)
-		if err != nil {
-			log.Printf("更新用户 %v 密码失败: %v", userID, err)
-			serverError(c, "服务器错误")
+		if err := users.UpdatePassword(userID, string(hashedNewPassword)); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				notFound(c, "用户不存在")
+			} else {
+				logging.From(c).Error("更新用户密码失败", "user_id", userID, "err", err)
+				serverError(c, "服务器错误")
+			}
 			return
 		}
-		rowsAffected, _ := result.RowsAffected()
-		if rowsAffected == 0 {
-			notFound(c, "用户不存在")
+		logging.From(c).Info("用户修改密码成功", "user_id", userID)
+		recordAudit(c, audit, "user.password", "user", userID, nil, nil)
+		// 保留当前会话，其他设备需重新登录
+		if err := revokeUserSessions(c, sessionStore, userID, currentSessionKey(session)); err != nil {
+			serverError(c, "服务器错误")
 			return
 		}
-		log.Printf("用户 %v 修改密码成功", userID)
 		success(c, "密码修改成功")
 	}
 }
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// failingSessions 模拟会话存储写入失败。
+type failingSessions struct {
+	store.SessionStore
+}
+
+func (failingSessions) DeleteByUser(int, string) (int, error) {
+	return 0, errors.New("磁盘已满")
+}
+
+func TestRevokeSessionsFailureIsReported(t *testing.T) {
+	cases := []struct {
+		name   string
+		route  func(ts *testServer, sessions store.SessionStore)
+		method string
+		path   string
+		body   any
+		as     string
+	}{
+		{"UpdateUser", func(ts *testServer, s store.SessionStore) {
+			ts.router.PUT("/user/:id", UpdateUser(ts.st.Users, ts.cache, s, ts.st.Audit))
+		}, "PUT", "/user/2", gin.H{"username": "bob", "role": models.RoleGuest, "password": "newpass123"}, "admin"},
+		{"UpdateUserRole", func(ts *testServer, s store.SessionStore) {
+			ts.router.PUT("/user/:id/role", UpdateUserRole(ts.st.Users, ts.cache, s, ts.st.Audit))
+		}, "PUT", "/user/2/role", gin.H{"role": models.RoleGuest}, "admin"},
+		{"DeleteUser", func(ts *testServer, s store.SessionStore) {
+			ts.router.DELETE("/user/:id", DeleteUser(ts.st.Users, ts.cache, s, ts.st.Audit))
+		}, "DELETE", "/user/2", nil, "admin"},
+		{"ChangePassword", func(ts *testServer, s store.SessionStore) {
+			ts.router.POST("/change-password", ChangePassword(ts.st.Users, s, ts.st.Audit))
+		}, "POST", "/change-password", gin.H{"old_password": testPassword, "new_password": "newpass123"}, "bob"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, fail := range []bool{false, true} {
+				ts := newTestServer(t)
+				ts.addUser("admin", models.RoleAdmin)
+				ts.addUser("bob", models.RoleOrganizer)
+				var sessions store.SessionStore = ts.st.Sessions
+				if fail {
+					sessions = failingSessions{ts.st.Sessions}
+				}
+				tc.route(ts, sessions)
+				status, body := ts.login(tc.as).do(tc.method, tc.path, tc.body)
+				want := http.StatusOK
+				if fail {
+					want = http.StatusInternalServerError
+				}
+				if status != want {
+					t.Fatalf("会话吊销失败=%v: %d %v", fail, status, body)
+				}
+			}
+		})
+	}
+}
+
+func TestUserCacheInvalidatedOnChange(t *testing.T) {
+	ts := newTestServer(t)
+	ts.cache.ttl = time.Hour
+	ts.router.GET("/users", RequirePermission(models.PermUserRead), GetUsers(ts.st.Users))
+	ts.router.PUT("/user/:id/role", UpdateUserRole(ts.st.Users, ts.cache, ts.st.Sessions, ts.st.Audit))
+	ts.router.DELETE("/user/:id", DeleteUser(ts.st.Users, ts.cache, ts.st.Sessions, ts.st.Audit))
+	ts.addUser("admin", models.RoleAdmin)
+	bobID := ts.addUser("bob", models.RoleAdmin)
+	admin, bob := ts.login("admin"), ts.login("bob")
+
+	// 测试的 Cookie 会话不受会话吊销影响，只验证缓存
+	if status, _ := bob.do("GET", "/users", nil); status != http.StatusOK {
+		t.Fatalf("降级前: %d", status)
+	}
+	if status, body := admin.do("PUT", fmt.Sprintf("/user/%d/role", bobID), gin.H{"role": models.RoleGuest}); status != http.StatusOK {
+		t.Fatalf("降级: %d %v", status, body)
+	}
+	if status, _ := bob.do("GET", "/users", nil); status != http.StatusForbidden {
+		t.Fatalf("降级后: %d", status)
+	}
+	if status, body := admin.do("DELETE", fmt.Sprintf("/user/%d", bobID), nil); status != http.StatusOK {
+		t.Fatalf("删除: %d %v", status, body)
+	}
+	if status, _ := bob.do("GET", "/users", nil); status != http.StatusUnauthorized {
+		t.Fatalf("删除后: %d", status)
+	}
+}
@@ -0,0 +1,89 @@
+package invite
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenRoundTrip(t *testing.T) {
+	s := NewSigner([]byte("secret"))
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, id := range []int{1, 42, 1 << 40} {
+		token := s.Token(id, expires)
+		if token != s.Token(id, expires) {
+			t.Fatalf("相同参数的令牌不一致")
+		}
+		gotID, gotExpires, err := s.Parse(token)
+		if err != nil {
+			t.Fatalf("id %d: %v", id, err)
+		}
+		if gotID != id || !gotExpires.Equal(expires) {
+			t.Fatalf("Parse = %d, %v, 期望 %d, %v", gotID, gotExpires, id, expires)
+		}
+	}
+	// 过期时间只保留到秒，且已过期的令牌同样能解析
+	past := time.Date(2020, 1, 1, 0, 0, 0, 999, time.Local)
+	if _, gotExpires, err := s.Parse(s.Token(1, past)); err != nil || !gotExpires.Equal(past.Truncate(time.Second)) || gotExpires.Location() != time.UTC {
+		t.Fatalf("Parse = %v, %v", gotExpires, err)
+	}
+}
+
+func TestTokenRejectsTampering(t *testing.T) {
+	s := NewSigner([]byte("secret"))
+	token := s.Token(7, time.Now().Add(time.Hour))
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range buf {
+		tampered := append([]byte{}, buf...)
+		tampered[i] ^= 1
+		if _, _, err := s.Parse(base64.RawURLEncoding.EncodeToString(tampered)); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("修改第 %d 字节: err = %v", i, err)
+		}
+	}
+
+	cases := map[string]string{
+		"空令牌":    "",
+		"非法字符":   token[:len(token)-1] + "!",
+		"标准编码":   base64.StdEncoding.EncodeToString(buf),
+		"截断":     token[:len(token)-2],
+		"追加数据":   base64.RawURLEncoding.EncodeToString(append(buf, 0)),
+		"其他密钥签名": NewSigner([]byte("other")).Token(7, time.Now().Add(time.Hour)),
+	}
+	for name, tok := range cases {
+		if _, _, err := s.Parse(tok); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
+
+func TestTokenRejectsInvalidID(t *testing.T) {
+	s := NewSigner([]byte("secret"))
+	expires := time.Now().Add(time.Hour)
+	if _, _, err := s.Parse(s.Token(0, expires)); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("id 0: err = %v", err)
+	}
+	// 正确签名但超出 int 范围的 id
+	payload := make([]byte, payloadSize)
+	binary.BigEndian.PutUint64(payload[:8], 1<<63)
+	binary.BigEndian.PutUint64(payload[8:], uint64(expires.Unix()))
+	token := base64.RawURLEncoding.EncodeToString(append(payload, s.sign(payload)...))
+	if _, _, err := s.Parse(token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("id 溢出: err = %v", err)
+	}
+}
+
+func TestSignerKeyIsDerived(t *testing.T) {
+	// 派生密钥不应等于原始 secret，令牌也不应能用原始 secret 直接签出
+	secret := []byte("secret")
+	s := NewSigner(secret)
+	raw := &Signer{key: secret}
+	expires := time.Now().Add(time.Hour)
+	if s.Token(1, expires) == raw.Token(1, expires) {
+		t.Fatal("令牌直接使用了原始 secret 签名")
+	}
+}
@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+const contextKey = "logger"
+
+// New 按 format 创建输出到 w 的 logger，format 为空时使用文本格式。
+func New(w io.Writer, format string) (*slog.Logger, error) {
+	switch format {
+	case "", FormatText:
+		return slog.New(slog.NewTextHandler(w, nil)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, nil)), nil
+	default:
+		return nil, fmt.Errorf("无效的日志格式 %q", format)
+	}
+}
+
+// From 返回请求携带的 logger，未设置时返回默认 logger。
+func From(c *gin.Context) *slog.Logger {
+	if l, ok := c.Get(contextKey); ok {
+		return l.(*slog.Logger)
+	}
+	return slog.Default()
+}
+
+// Set 设置请求携带的 logger。
+func Set(c *gin.Context, l *slog.Logger) {
+	c.Set(contextKey, l)
+}
+
+// With 为请求之后的日志追加字段。
+func With(c *gin.Context, args ...any) {
+	Set(c, From(c).With(args...))
+}
@@ -1,64 +1,99 @@
 package main
 
 import (
+	"DineTogether/events"
 	"DineTogether/handlers"
+	"DineTogether/invite"
+	"DineTogether/logging"
+	"DineTogether/metrics"
 	"DineTogether/middleware"
+	"DineTogether/migrations"
+	"DineTogether/models"
+	"DineTogether/scheduler"
+	"DineTogether/sessionstore"
+	"DineTogether/store"
+	"DineTogether/store/memory"
+	"DineTogether/store/sqlite"
+	"context"
 	"database/sql"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
 	_ "modernc.org/sqlite"
 	"github.com/spf13/viper"
 )
 
+//go:generate go run . migrate schema -o schema.sql
+
 func main() {
-	viper.SetConfigFile("config.yaml")
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatalf("读取配置文件失败: %v", err)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCommand(os.Args[2:])
+		return
 	}
-	dbPath := viper.GetString("database.path")
+
+	loadConfig()
+	logger, err := logging.New(os.Stdout, viper.GetString("log.format"))
+	if err != nil {
+		log.Fatalf("初始化日志失败: %v", err)
+	}
+	// 其余使用标准库 log 的输出也按配置格式写出
+	slog.SetDefault(logger)
 	uploadDir := viper.GetString("upload.dir")
 	secret := viper.GetString("session.secret")
-
-	dir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		log.Fatalf("创建数据库目录失败: %v", err)
+	// 邀请链接与二维码中的站点地址，为空时按请求的 Host 生成
+	publicURL := viper.GetString("server.public_url")
+	if publicURL == "" {
+		slog.Warn("未配置 server.public_url，邀请链接将按请求的 Host 头生成，生产环境应配置固定地址")
 	}
+
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
 		log.Fatalf("创建上传目录失败: %v", err)
 	}
 
-	dsn := "file:" + dbPath + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
-	db, err := sql.Open("sqlite", dsn)
+	db := openDatabase(viper.GetString("database.path"))
+	defer db.Close()
+	migrator, err := migrations.New(db)
 	if err != nil {
-		log.Fatalf("无法连接到数据库: %v", err)
+		log.Fatalf("初始化数据库迁移失败: %v", err)
 	}
-	defer db.Close()
-	if err := db.Ping(); err != nil {
-		log.Fatalf("数据库连接测试失败: %v", err)
+	if _, err := migrator.Up(); err != nil {
+		log.Fatalf("数据库迁移失败: %v", err)
 	}
-	runMigrations(db)
+	st := sqlite.New(db)
+	hub := events.NewHub()
+	inviteSigner := invite.NewSigner([]byte(secret))
+	go scheduler.Run(context.Background(), st.Parties, hub, viper.GetDuration("scheduler.interval"))
 
-	r := gin.Default()
-	r.Use(middleware.ErrorHandler())
+	r := gin.New()
+	r.Use(middleware.RequestID(logger), middleware.Metrics(), gin.Recovery(), middleware.ErrorHandler())
 
 	r.GET("/api/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// /metrics 只在单独的监听地址上提供，或在主端口上要求访问令牌
+	metricsHandler := handlers.Metrics(st.Parties, viper.GetString("metrics.token"))
+	switch {
+	case viper.GetString("metrics.listen") != "":
+		go serveMetrics(viper.GetString("metrics.listen"), metricsHandler)
+	case viper.GetString("metrics.token") != "":
+		r.GET("/metrics", metricsHandler)
+	default:
+		slog.Warn("未配置 metrics.listen 或 metrics.token，不提供 /metrics")
+	}
+
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:8080", "http://127.0.0.1:8080", "http://localhost:8081", "http://127.0.0.1:8081"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-CSRF-Token"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-CSRF-Token", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * 60 * 60,
 	}))
@@ -67,134 +102,228 @@ func main() {
 	r.Static("/static", "./static")
 	r.Static("/uploads", uploadDir)
 
-	store := cookie.NewStore([]byte(secret))
-	store.Options(sessions.Options{
+	sessionBackend := newSessionBackend(viper.GetString("session.store"), st)
+	sessionStore := sessionstore.New(sessionBackend,
+		viper.GetDuration("session.idle_timeout"), viper.GetDuration("session.absolute_timeout"), []byte(secret))
+	sessionStore.Options(sessions.Options{
 		MaxAge:   0,
 		Path:     "/",
 		HttpOnly: true,
 		SameSite: http.SameSiteLaxMode,
 	})
-	r.Use(sessions.Sessions("session", store))
+	go sessionStore.Cleanup(context.Background(), 10*time.Minute)
+	userCache := handlers.NewUserCache(st.Users, viper.GetDuration("session.user_cache_ttl"))
+	r.Use(sessions.Sessions("session", sessionStore), handlers.LoadUser(userCache), middleware.SessionLogger())
 
 	rl := middleware.NewRateLimiter(10, time.Minute)
 
 	r.GET("/", func(c *gin.Context) {
-		var adminCount int
-		db.QueryRow("SELECT COUNT(*) FROM users WHERE role = 'admin'").Scan(&adminCount)
+		adminCount, _ := st.Users.CountAdmins()
 		c.HTML(http.StatusOK, "index.html", gin.H{"needsSetup": adminCount == 0})
 	})
 	r.GET("/setup", func(c *gin.Context) {
-		var adminCount int
-		db.QueryRow("SELECT COUNT(*) FROM users WHERE role = 'admin'").Scan(&adminCount)
+		adminCount, _ := st.Users.CountAdmins()
 		if adminCount > 0 {
 			c.Redirect(http.StatusFound, "/")
 			return
 		}
 		c.HTML(http.StatusOK, "setup.html", nil)
 	})
-	r.POST("/setup", middleware.RateLimitMiddleware(rl), handlers.SetupAdmin(db))
+	r.POST("/setup", middleware.RateLimitMiddleware(rl), handlers.SetupAdmin(st.Users, st.Audit))
 	r.GET("/login", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "login.html", nil)
 	})
 	r.GET("/register", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "register.html", nil)
 	})
-	r.POST("/register", middleware.RateLimitMiddleware(rl), handlers.Register(db))
-	r.POST("/login", middleware.RateLimitMiddleware(rl), handlers.Login(db))
-	r.POST("/logout", middleware.CSRFMiddleware(), handlers.Logout(db))
+	r.POST("/register", middleware.RateLimitMiddleware(rl), handlers.Register(st.Users))
+	r.POST("/login", middleware.RateLimitMiddleware(rl), handlers.Login(st.Users))
+	r.POST("/logout", middleware.CSRFMiddleware(), handlers.Logout())
+	r.GET("/sessions", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "sessions.html", nil)
+	})
+	r.GET("/api/sessions", handlers.GetMySessions(sessionBackend))
+	r.DELETE("/api/sessions/:id", middleware.CSRFMiddleware(), handlers.RevokeMySession(sessionBackend))
+	r.POST("/api/sessions/logout-all", middleware.CSRFMiddleware(), handlers.LogoutAll(sessionBackend))
 	r.GET("/dashboard", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "dashboard.html", nil)
 	})
 	r.GET("/change-password", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "change_password.html", nil)
 	})
-	r.POST("/change-password", middleware.CSRFMiddleware(), handlers.ChangePassword(db))
+	r.POST("/change-password", middleware.CSRFMiddleware(), handlers.ChangePassword(st.Users, sessionBackend, st.Audit))
+	r.GET("/dietary-profile", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "dietary_profile.html", nil)
+	})
+	r.GET("/api/dietary-profile", handlers.GetDietaryProfile(st.Users))
+	r.PUT("/api/dietary-profile", middleware.CSRFMiddleware(), handlers.UpdateDietaryProfile(st.Users))
 	r.GET("/join-party", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "join_party.html", nil)
 	})
-	r.POST("/join-party", middleware.CSRFMiddleware(), handlers.JoinParty(db))
-	r.POST("/leave-party", middleware.CSRFMiddleware(), handlers.LeaveParty(db))
+	r.POST("/join-party", middleware.CSRFMiddleware(), handlers.JoinParty(st.Parties, hub, st.Audit))
+	r.POST("/leave-party", middleware.CSRFMiddleware(), handlers.LeaveParty(st.Parties, hub, st.Audit))
+	r.GET("/join/:token", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "join_invite.html", nil)
+	})
+	r.GET("/join/:token/qr.png", handlers.InviteQRCode(inviteSigner, publicURL))
+	r.GET("/api/join/:token", handlers.GetInviteInfo(st.Invites, st.Parties, inviteSigner))
+	r.POST("/api/join/:token", middleware.CSRFMiddleware(), handlers.JoinByInvite(st.Invites, st.Parties, inviteSigner, hub, st.Audit))
 	r.GET("/order", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "order.html", nil)
 	})
-	r.POST("/order", middleware.CSRFMiddleware(), handlers.PlaceOrder(db))
-	r.GET("/api/party", handlers.GetUserParty(db))
-	r.GET("/api/party-orders", handlers.GetPartyOrders(db))
-	r.DELETE("/order/:id", middleware.CSRFMiddleware(), handlers.DeleteOrder(db))
+	r.POST("/order", middleware.CSRFMiddleware(), handlers.PlaceOrder(st.Orders, st.Menus, st.Users, st.Parties, hub, st.Audit))
+	r.POST("/cart", middleware.CSRFMiddleware(), handlers.PlaceCart(st.Orders, st.Menus, st.Users, st.Parties, hub, st.Audit))
+	r.GET("/my-orders", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "my_orders.html", nil)
+	})
+	r.GET("/api/me/orders", handlers.GetMyOrders(st.Orders))
+	r.GET("/api/party", handlers.GetUserParty(st.Parties))
+	r.GET("/api/party-orders", handlers.GetPartyOrders(st.Parties, st.Orders, st.Restaurants))
+	r.GET("/api/party/stream", handlers.PartyStream(st.Parties, hub))
+	r.DELETE("/order/:id", middleware.CSRFMiddleware(), handlers.DeleteOrder(st.Orders, st.Parties, hub, st.Audit))
 	r.GET("/menu-detail", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "menu_detail.html", nil)
 	})
 	r.GET("/api/csrf-token", handlers.GetCSRFToken())
 	r.GET("/api/check-auth", func(c *gin.Context) {
-		session := sessions.Default(c)
-		userID := session.Get("user_id")
-		role := session.Get("role")
-		if userID == nil {
+		user, ok := handlers.CurrentUser(c)
+		if !ok {
 			c.JSON(401, gin.H{"authenticated": false})
 			return
 		}
-		var exists bool
-		db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", userID).Scan(&exists)
-		if !exists {
-			session.Clear()
-			session.Save()
-			c.JSON(401, gin.H{"authenticated": false})
-			return
-		}
-		c.JSON(200, gin.H{"authenticated": true, "user_id": userID, "role": role})
+		c.JSON(200, gin.H{"authenticated": true, "user_id": user.ID, "role": user.Role, "permissions": models.RolePermissions(user.Role)})
 	})
 
-	adminRoutes := r.Group("")
-	adminRoutes.Use(handlers.AuthMiddleware(db))
+	menuRoutes := r.Group("", handlers.RequirePermission(models.PermMenuWrite))
 	{
-		adminRoutes.GET("/menu-manage", func(c *gin.Context) {
+		menuRoutes.GET("/menu-manage", func(c *gin.Context) {
 			c.HTML(http.StatusOK, "menu_manage.html", nil)
 		})
-		adminRoutes.GET("/create-menu", func(c *gin.Context) {
+		menuRoutes.GET("/create-menu", func(c *gin.Context) {
 			c.HTML(http.StatusOK, "create_menu.html", nil)
 		})
-		adminRoutes.GET("/edit-menu", func(c *gin.Context) {
+		menuRoutes.GET("/edit-menu", func(c *gin.Context) {
 			c.HTML(http.StatusOK, "edit_menu.html", nil)
 		})
-		adminRoutes.GET("/party-manage", func(c *gin.Context) {
-			c.HTML(http.StatusOK, "party_manage.html", nil)
+		menuRoutes.GET("/category-manage", func(c *gin.Context) {
+			c.HTML(http.StatusOK, "category_manage.html", nil)
+		})
+		menuRoutes.GET("/collection-manage", func(c *gin.Context) {
+			c.HTML(http.StatusOK, "collection_manage.html", nil)
 		})
-		adminRoutes.GET("/create-party", func(c *gin.Context) {
-			c.HTML(http.StatusOK, "create_party.html", nil)
+		menuRoutes.POST("/menus", middleware.CSRFMiddleware(), handlers.CreateMenu(st.Menus, st.Audit))
+		menuRoutes.POST("/upload-image", handlers.UploadImage(uploadDir, st.Audit))
+		menuRoutes.POST("/delete-image", handlers.DeleteImage(uploadDir, st.Audit))
+		menuRoutes.PUT("/menu/:id", middleware.CSRFMiddleware(), handlers.UpdateMenu(st.Menus, st.Audit))
+		menuRoutes.DELETE("/menu/:id", middleware.CSRFMiddleware(), handlers.DeleteMenu(st.Menus, st.Audit))
+		menuRoutes.PUT("/menu/:id/availability", middleware.CSRFMiddleware(), handlers.SetMenuAvailability(st.Menus, st.Audit))
+		menuRoutes.POST("/categories", middleware.CSRFMiddleware(), handlers.CreateCategory(st.Categories, st.Audit))
+		menuRoutes.PUT("/category/:id", middleware.CSRFMiddleware(), handlers.UpdateCategory(st.Categories, st.Audit))
+		menuRoutes.DELETE("/category/:id", middleware.CSRFMiddleware(), handlers.DeleteCategory(st.Categories, st.Audit))
+		menuRoutes.POST("/collections", middleware.CSRFMiddleware(), handlers.CreateCollection(st.Collections, st.Audit))
+		menuRoutes.PUT("/collection/:id", middleware.CSRFMiddleware(), handlers.UpdateCollection(st.Collections, st.Audit))
+		menuRoutes.DELETE("/collection/:id", middleware.CSRFMiddleware(), handlers.DeleteCollection(st.Collections, st.Audit))
+		menuRoutes.POST("/collection/:id/clone", middleware.CSRFMiddleware(), handlers.CloneCollection(st.Collections, st.Audit))
+		menuRoutes.PUT("/tag/:tag", middleware.CSRFMiddleware(), handlers.RenameTag(st.Menus, st.Audit))
+		menuRoutes.DELETE("/tag/:tag", middleware.CSRFMiddleware(), handlers.DeleteTag(st.Menus, st.Audit))
+	}
+
+	restaurantRoutes := r.Group("", handlers.RequirePermission(models.PermRestaurantWrite))
+	{
+		restaurantRoutes.GET("/restaurant-manage", func(c *gin.Context) {
+			c.HTML(http.StatusOK, "restaurant_manage.html", nil)
 		})
-		adminRoutes.GET("/edit-party", func(c *gin.Context) {
-			c.HTML(http.StatusOK, "edit_party.html", nil)
+		restaurantRoutes.POST("/restaurants", middleware.CSRFMiddleware(), handlers.CreateRestaurant(st.Restaurants, st.Audit))
+		restaurantRoutes.PUT("/restaurant/:id", middleware.CSRFMiddleware(), handlers.UpdateRestaurant(st.Restaurants, st.Audit))
+		restaurantRoutes.DELETE("/restaurant/:id", middleware.CSRFMiddleware(), handlers.DeleteRestaurant(st.Restaurants, st.Audit))
+	}
+
+	r.GET("/party-manage", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "party_manage.html", nil)
+	})
+	r.GET("/create-party", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "create_party.html", nil)
+	})
+	r.GET("/edit-party", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "edit_party.html", nil)
+	})
+	r.POST("/parties", middleware.CSRFMiddleware(), handlers.CreateParty(st.Parties, st.Audit))
+	r.GET("/api/my-parties", handlers.GetMyParties(st.Parties))
+
+	partyReadRoutes := r.Group("", handlers.RequirePermission(models.PermPartyRead))
+	{
+		partyReadRoutes.GET("/party-history", func(c *gin.Context) {
+			c.HTML(http.StatusOK, "party_history.html", nil)
 		})
-		adminRoutes.GET("/user-manage", func(c *gin.Context) {
+		partyReadRoutes.GET("/parties", handlers.GetParties(st.Parties))
+		partyReadRoutes.GET("/history/parties", handlers.GetArchivedParties(st.Parties))
+		partyReadRoutes.GET("/history/party/:id", handlers.GetArchivedParty(st.Parties, st.Orders, st.Restaurants))
+	}
+
+	// Party 所有者无需 party:read / party:write 即可查看和管理自己创建的 Party
+	ownedPartyReadRoutes := r.Group("", handlers.RequirePartyAccess(st.Parties, models.PermPartyRead))
+	{
+		ownedPartyReadRoutes.GET("/party/:id", handlers.GetPartyByID(st.Parties))
+		ownedPartyReadRoutes.GET("/party/:id/history", handlers.GetPartyHistory(st.Parties))
+		ownedPartyReadRoutes.GET("/party/:id/export", handlers.ExportParty(st.Parties, st.Orders, st.Restaurants))
+		ownedPartyReadRoutes.GET("/party/:id/members", handlers.GetPartyMembers(st.Parties))
+		ownedPartyReadRoutes.GET("/party/:id/orders", handlers.ListPartyOrders(st.Parties, st.Orders))
+	}
+
+	ownedPartyWriteRoutes := r.Group("", handlers.RequirePartyAccess(st.Parties, models.PermPartyWrite))
+	{
+		ownedPartyWriteRoutes.PUT("/party/:id", middleware.CSRFMiddleware(), handlers.UpdateParty(st.Parties, st.Audit))
+		ownedPartyWriteRoutes.DELETE("/party/:id", middleware.CSRFMiddleware(), handlers.DeleteParty(st.Parties, hub, st.Audit))
+		ownedPartyWriteRoutes.POST("/party/:id/state", middleware.CSRFMiddleware(), handlers.UpdatePartyState(st.Parties, hub, st.Audit))
+		ownedPartyWriteRoutes.PUT("/party/:id/members/:user_id/budget", middleware.CSRFMiddleware(), handlers.UpdateMemberBudget(st.Parties, hub, st.Audit))
+		ownedPartyWriteRoutes.DELETE("/party/:id/members/:user_id", middleware.CSRFMiddleware(), handlers.RemovePartyMember(st.Parties, hub, st.Audit))
+		ownedPartyWriteRoutes.DELETE("/party/:id/orders/:order_id", middleware.CSRFMiddleware(), handlers.RemovePartyOrder(st.Orders, st.Parties, hub, st.Audit))
+		ownedPartyWriteRoutes.GET("/party/:id/invites", handlers.GetInvites(st.Invites, inviteSigner, publicURL))
+		ownedPartyWriteRoutes.POST("/party/:id/invites", middleware.CSRFMiddleware(), handlers.CreateInvite(st.Invites, st.Parties, inviteSigner, publicURL, st.Audit))
+		ownedPartyWriteRoutes.DELETE("/party/:id/invites/:invite_id", middleware.CSRFMiddleware(), handlers.RevokeInvite(st.Invites, st.Audit))
+	}
+
+	r.GET("/stats", handlers.RequirePermission(models.PermStatsRead), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "stats.html", nil)
+	})
+	r.GET("/admin/stats", handlers.RequirePermission(models.PermStatsRead), handlers.GetStats(st.Stats))
+	r.GET("/audit-log", handlers.RequirePermission(models.PermAuditRead), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "audit_log.html", nil)
+	})
+	r.GET("/admin/audit", handlers.RequirePermission(models.PermAuditRead), handlers.GetAuditEvents(st.Audit))
+
+	userReadRoutes := r.Group("", handlers.RequirePermission(models.PermUserRead))
+	{
+		userReadRoutes.GET("/user-manage", func(c *gin.Context) {
 			c.HTML(http.StatusOK, "user_manage.html", nil)
 		})
-		adminRoutes.GET("/create-user", func(c *gin.Context) {
+		userReadRoutes.GET("/users", handlers.GetUsers(st.Users))
+		userReadRoutes.GET("/user/:id", handlers.GetUserByID(st.Users))
+		userReadRoutes.GET("/roles", handlers.GetRoles())
+	}
+
+	userWriteRoutes := r.Group("", handlers.RequirePermission(models.PermUserWrite))
+	{
+		userWriteRoutes.GET("/create-user", func(c *gin.Context) {
 			c.HTML(http.StatusOK, "create_user.html", nil)
 		})
-		adminRoutes.GET("/edit-user", func(c *gin.Context) {
+		userWriteRoutes.GET("/edit-user", func(c *gin.Context) {
 			c.HTML(http.StatusOK, "edit_user.html", nil)
 		})
-		adminRoutes.POST("/menus", middleware.CSRFMiddleware(), handlers.CreateMenu(db))
-
-		adminRoutes.POST("/upload-image", handlers.UploadImage(uploadDir))
-		adminRoutes.POST("/delete-image", handlers.DeleteImage(uploadDir))
-		adminRoutes.PUT("/menu/:id", middleware.CSRFMiddleware(), handlers.UpdateMenu(db))
-		adminRoutes.DELETE("/menu/:id", middleware.CSRFMiddleware(), handlers.DeleteMenu(db))
-		adminRoutes.GET("/parties", handlers.GetParties(db))
-		adminRoutes.POST("/parties", middleware.CSRFMiddleware(), handlers.CreateParty(db))
-		adminRoutes.GET("/party/:id", handlers.GetPartyByID(db))
-		adminRoutes.PUT("/party/:id", middleware.CSRFMiddleware(), handlers.UpdateParty(db))
-		adminRoutes.DELETE("/party/:id", middleware.CSRFMiddleware(), handlers.DeleteParty(db))
-		adminRoutes.GET("/users", handlers.GetUsers(db))
-		adminRoutes.POST("/users", middleware.CSRFMiddleware(), handlers.CreateUser(db))
-		adminRoutes.GET("/user/:id", handlers.GetUserByID(db))
-		adminRoutes.PUT("/user/:id", middleware.CSRFMiddleware(), handlers.UpdateUser(db))
-		adminRoutes.DELETE("/user/:id", middleware.CSRFMiddleware(), handlers.DeleteUser(db))
-		adminRoutes.PUT("/user/:id/role", middleware.CSRFMiddleware(), handlers.UpdateUserRole(db))
+		userWriteRoutes.POST("/users", middleware.CSRFMiddleware(), handlers.CreateUser(st.Users, st.Audit))
+		userWriteRoutes.PUT("/user/:id", middleware.CSRFMiddleware(), handlers.UpdateUser(st.Users, userCache, sessionBackend, st.Audit))
+		userWriteRoutes.DELETE("/user/:id", middleware.CSRFMiddleware(), handlers.DeleteUser(st.Users, userCache, sessionBackend, st.Audit))
+		userWriteRoutes.DELETE("/user/:id/sessions", middleware.CSRFMiddleware(), handlers.RevokeUserSessions(sessionBackend, st.Audit))
+		userWriteRoutes.PUT("/user/:id/role", middleware.CSRFMiddleware(), handlers.UpdateUserRole(st.Users, userCache, sessionBackend, st.Audit))
 	}
 
-	r.GET("/menus", handlers.GetMenus(db))
-	r.GET("/menu/:id", handlers.GetMenu(db))
+	r.GET("/menus", handlers.GetMenus(st.Menus, st.Parties))
+	r.GET("/menu/:id", handlers.GetMenu(st.Menus))
+	r.GET("/categories", handlers.GetCategories(st.Categories))
+	r.GET("/restaurants", handlers.GetRestaurants(st.Restaurants))
+	r.GET("/collections", handlers.GetCollections(st.Collections))
+	r.GET("/collection/:id", handlers.GetCollection(st.Collections))
+	r.GET("/tags", handlers.GetTags(st.Menus))
 
 	port := viper.GetString("server.port")
 	if port == "" {
@@ -205,53 +334,46 @@ func main() {
 	}
 }
 
-func runMigrations(db *sql.DB) {
-	schema := `
-	CREATE TABLE IF NOT EXISTS users (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		username TEXT NOT NULL UNIQUE,
-		password TEXT NOT NULL,
-		role TEXT NOT NULL DEFAULT 'guest'
-	);
-	CREATE TABLE IF NOT EXISTS menus (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL,
-		description TEXT DEFAULT '',
-		energy_cost INTEGER NOT NULL CHECK(energy_cost > 0),
-		image_urls TEXT DEFAULT '[]'
-	);
-	CREATE TABLE IF NOT EXISTS parties (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL UNIQUE,
-		password TEXT NOT NULL,
-		energy_left INTEGER NOT NULL CHECK(energy_left >= 0),
-		is_active INTEGER NOT NULL DEFAULT 1
-	);
-	CREATE TABLE IF NOT EXISTS party_members (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		party_id INTEGER NOT NULL,
-		user_id INTEGER NOT NULL,
-		joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		UNIQUE(party_id, user_id),
-		FOREIGN KEY (party_id) REFERENCES parties(id) ON DELETE CASCADE,
-		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
-	);
-	CREATE TABLE IF NOT EXISTS orders (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		party_id INTEGER NOT NULL,
-		user_id INTEGER NOT NULL,
-		menu_id INTEGER NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (party_id) REFERENCES parties(id) ON DELETE CASCADE,
-		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-		FOREIGN KEY (menu_id) REFERENCES menus(id) ON DELETE CASCADE
-	);`
-	for _, stmt := range strings.Split(schema, ";") {
-		stmt = strings.TrimSpace(stmt)
-		if stmt != "" {
-			if _, err := db.Exec(stmt); err != nil {
-				log.Printf("执行迁移失败: %v", err)
-			}
-		}
+func serveMetrics(addr string, handler gin.HandlerFunc) {
+	mr := gin.New()
+	mr.Use(gin.Recovery())
+	mr.GET("/metrics", handler)
+	if err := mr.Run(addr); err != nil {
+		log.Fatalf("metrics 服务启动失败: %v", err)
+	}
+}
+
+// newSessionBackend 按 session.store 选择会话存储，默认与业务数据共用 SQLite；memory 在重启后丢失全部会话。
+func newSessionBackend(kind string, st store.Stores) store.SessionStore {
+	switch kind {
+	case "", "sqlite":
+		return st.Sessions
+	case "memory":
+		return memory.New().Sessions
+	default:
+		log.Fatalf("未知的 session.store: %s", kind)
+		return nil
+	}
+}
+
+func loadConfig() {
+	viper.SetConfigFile("config.yaml")
+	if err := viper.ReadInConfig(); err != nil {
+		log.Fatalf("读取配置文件失败: %v", err)
+	}
+}
+
+func openDatabase(dbPath string) *sql.DB {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		log.Fatalf("创建数据库目录失败: %v", err)
+	}
+	dsn := "file:" + dbPath + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	db, err := metrics.OpenDB("sqlite", dsn)
+	if err != nil {
+		log.Fatalf("无法连接到数据库: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		log.Fatalf("数据库连接测试失败: %v", err)
 	}
+	return db
 }
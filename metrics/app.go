@@ -0,0 +1,31 @@
+package metrics
+
+// Default 为应用的指标注册表，/metrics 输出其中的全部指标。
+var Default = NewRegistry()
+
+var (
+	httpBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	dbBuckets   = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+)
+
+var (
+	HTTPRequests = Default.NewCounter("http_requests_total",
+		"HTTP 请求数", "method", "route", "status")
+	HTTPDuration = Default.NewHistogram("http_request_duration_seconds",
+		"HTTP 请求耗时（秒）", httpBuckets, "method", "route", "status")
+	DBQueryDuration = Default.NewHistogram("db_query_duration_seconds",
+		"SQL 执行耗时（秒），按语句类型分组", dbBuckets, "operation")
+	RateLimitRejections = Default.NewCounter("rate_limit_rejections_total",
+		"被速率限制拒绝的请求数", "route")
+
+	OrdersPlaced = Default.NewCounter("dinetogether_orders_placed_total",
+		"提交的订单数")
+	EnergyConsumed = Default.NewCounter("dinetogether_energy_consumed_total",
+		"点餐扣除的精力值")
+	EnergyRefunded = Default.NewCounter("dinetogether_energy_refunded_total",
+		"删除订单退还的精力值")
+	ActiveParties = Default.NewGauge("dinetogether_active_parties",
+		"未归档的 Party 数量，按状态分组", "state")
+	ImagesUploaded = Default.NewCounter("dinetogether_images_uploaded_total",
+		"上传的图片数")
+)
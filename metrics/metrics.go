@@ -0,0 +1,268 @@
+// Package metrics 以 Prometheus 文本格式（0.0.4）输出指标，仅依赖标准库。
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType 为 Prometheus 文本格式的响应类型。
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry 保存已注册的指标，按注册顺序输出。
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Write 按 Prometheus 文本格式写出全部指标。
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *desc) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.typ)
+}
+
+// key 校验标签值数量并返回用于索引序列的键。
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("指标 %s 需要 %d 个标签值，实际为 %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// series 为一组标签值对应的样本。
+type series struct {
+	values []string
+	value  float64
+	// 直方图使用
+	buckets []uint64
+	count   uint64
+}
+
+type vec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// init 设置指标描述，无标签的指标预先创建唯一序列，使其在首次更新前即输出 0。
+func (v *vec) init(name, help, typ string, labels []string) {
+	v.desc = desc{name: name, help: help, typ: typ, labels: labels}
+	v.series = make(map[string]*series)
+	if len(labels) == 0 {
+		v.get(nil)
+	}
+}
+
+func (v *vec) get(values []string) *series {
+	k := v.key(values)
+	s, ok := v.series[k]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		v.series[k] = s
+	}
+	return s
+}
+
+// sorted 返回按标签值排序的序列，调用方需持有锁。
+func (v *vec) sorted() []*series {
+	list := make([]*series, 0, len(v.series))
+	for _, s := range v.series {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.Join(list[i].values, "\xff") < strings.Join(list[j].values, "\xff")
+	})
+	return list
+}
+
+// Counter 为只增不减的计数器。
+type Counter struct {
+	vec
+}
+
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{}
+	c.init(name, help, "counter", labels)
+	r.register(c)
+	return c
+}
+
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add 增加计数，v 为负数时忽略。
+func (c *Counter) Add(v float64, labels ...string) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(labels).value += v
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w)
+	for _, s := range c.sorted() {
+		writeSample(w, c.name, c.labels, s.values, "", "", s.value)
+	}
+}
+
+// Gauge 为可任意设置的当前值。
+type Gauge struct {
+	vec
+}
+
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{}
+	g.init(name, help, "gauge", labels)
+	r.register(g)
+	return g
+}
+
+func (g *Gauge) Set(v float64, labels ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.get(labels).value = v
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w)
+	for _, s := range g.sorted() {
+		writeSample(w, g.name, g.labels, s.values, "", "", s.value)
+	}
+}
+
+// Histogram 按上界累计观测值，buckets 需升序排列，+Inf 自动追加。
+type Histogram struct {
+	vec
+	bounds []float64
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{bounds: buckets}
+	h.init(name, help, "histogram", labels)
+	r.register(h)
+	return h
+}
+
+func (h *Histogram) Observe(v float64, labels ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(labels)
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(h.bounds))
+	}
+	for i, b := range h.bounds {
+		if v <= b {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.value += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w)
+	for _, s := range h.sorted() {
+		for i, b := range h.bounds {
+			var n uint64
+			if s.buckets != nil {
+				n = s.buckets[i]
+			}
+			writeSample(w, h.name+"_bucket", h.labels, s.values, "le", formatFloat(b), float64(n))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.values, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.values, "", "", s.value)
+		writeSample(w, h.name+"_count", h.labels, s.values, "", "", float64(s.count))
+	}
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", l, escapeLabel(values[i]))
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
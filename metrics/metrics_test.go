@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("http_requests_total", "请求数\n按路由 \\ 状态码", "route", "status")
+	ready := r.NewGauge("ready", "是否就绪")
+	latency := r.NewHistogram("latency_seconds", "耗时", []float64{0.1, 0.5}, "route")
+	r.NewCounter("unused_total", "无标签计数器")
+	r.NewHistogram("unused_seconds", "未观测的直方图", []float64{1}, "route")
+
+	requests.Inc("/b", "200")
+	requests.Add(2, "/a", "500")
+	requests.Add(-1, "/a", "500")
+	requests.Inc(`/q"x\y`+"\n", "404")
+	ready.Set(1)
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.3, "/a")
+	latency.Observe(2, "/a")
+
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP http_requests_total 请求数\n按路由 \\ 状态码
+# TYPE http_requests_total counter
+http_requests_total{route="/a",status="500"} 2
+http_requests_total{route="/b",status="200"} 1
+http_requests_total{route="/q\"x\\y\n",status="404"} 1
+# HELP ready 是否就绪
+# TYPE ready gauge
+ready 1
+# HELP latency_seconds 耗时
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 1
+latency_seconds_bucket{route="/a",le="0.5"} 2
+latency_seconds_bucket{route="/a",le="+Inf"} 3
+latency_seconds_sum{route="/a"} 2.35
+latency_seconds_count{route="/a"} 3
+# HELP unused_total 无标签计数器
+# TYPE unused_total counter
+unused_total 0
+# HELP unused_seconds 未观测的直方图
+# TYPE unused_seconds histogram
+`
+	if got := b.String(); got != want {
+		t.Fatalf("输出不符\n得到:\n%s\n期望:\n%s", got, want)
+	}
+}
+
+func TestUnlabeledHistogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("h", "直方图", []float64{1, 2})
+	h.Observe(1.5)
+
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP h 直方图
+# TYPE h histogram
+h_bucket{le="1"} 0
+h_bucket{le="2"} 1
+h_bucket{le="+Inf"} 1
+h_sum 1.5
+h_count 1
+`
+	if got := b.String(); got != want {
+		t.Fatalf("输出不符\n得到:\n%s\n期望:\n%s", got, want)
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	c := NewRegistry().NewCounter("c", "计数器", "route")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("标签值数量不符时应 panic")
+		}
+	}()
+	c.Inc()
+}
+
+func TestFormatFloat(t *testing.T) {
+	for v, want := range map[float64]string{0: "0", 1: "1", 0.25: "0.25", 1e21: "1e+21"} {
+		if got := formatFloat(v); got != want {
+			t.Errorf("formatFloat(%v) = %s, 期望 %s", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"time"
+)
+
+// OpenDB 与 sql.Open 相同，但通过 driverName 对应的驱动建立的连接会把每条 SQL 的耗时记录到 DBQueryDuration。
+func OpenDB(driverName, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	// sql.Open 不会建立连接，这里只为取得已注册的驱动
+	d := db.Driver()
+	db.Close()
+	return sql.OpenDB(&connector{driver: d, dsn: dsn}), nil
+}
+
+type connector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: dc}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// conn 只在直接执行的 Exec/Query 上计时；底层驱动不支持时返回 driver.ErrSkip，由 database/sql 回退到预编译语句。
+type conn struct {
+	driver.Conn
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer observeQuery(query, time.Now())
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer observeQuery(query, time.Now())
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// observeQuery 按 SQL 的首个关键字分组记录耗时。
+func observeQuery(query string, start time.Time) {
+	op := "other"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		switch word := strings.ToLower(fields[0]); word {
+		case "select", "insert", "update", "delete", "with", "create", "drop", "alter", "pragma":
+			op = word
+		}
+	}
+	DBQueryDuration.Observe(time.Since(start).Seconds(), op)
+}
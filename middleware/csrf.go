@@ -23,20 +23,20 @@ func CSRFMiddleware() gin.HandlerFunc {
 		}
 		ct := c.GetHeader("Content-Type")
 		if ct != "application/json" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "无效的请求 Content-Type", "success": false})
+			c.JSON(http.StatusForbidden, ErrorBody(c, "无效的请求 Content-Type"))
 			c.Abort()
 			return
 		}
 		session := sessions.Default(c)
 		token := session.Get("csrf_token")
 		if token == nil {
-			c.JSON(http.StatusForbidden, gin.H{"error": "CSRF token 缺失", "success": false})
+			c.JSON(http.StatusForbidden, ErrorBody(c, "CSRF token 缺失"))
 			c.Abort()
 			return
 		}
 		headerToken := c.GetHeader("X-CSRF-Token")
 		if headerToken == "" || headerToken != token.(string) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "CSRF token 无效", "success": false})
+			c.JSON(http.StatusForbidden, ErrorBody(c, "CSRF token 无效"))
 			c.Abort()
 			return
 		}
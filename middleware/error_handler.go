@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"log"
+	"DineTogether/logging"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -13,11 +13,8 @@ func ErrorHandler() gin.HandlerFunc {
 		if len(c.Errors) > 0 {
 			c.Header("Content-Type", "application/json")
 			err := c.Errors.Last()
-			log.Printf("未处理的错误: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "服务器错误",
-				"success": false,
-			})
+			logging.From(c).Error("未处理的错误", "err", err)
+			c.JSON(http.StatusInternalServerError, ErrorBody(c, "服务器错误"))
 			c.Errors = nil
 			c.Abort()
 		}
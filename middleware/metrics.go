@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"DineTogether/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 按路由模板与状态码记录请求数与耗时，未匹配路由的请求统一记为 unmatched，避免标签数量随路径增长。
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route, method, status := routeLabel(c), c.Request.Method, strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequests.Inc(method, route, status)
+		metrics.HTTPDuration.Observe(time.Since(start).Seconds(), method, route, status)
+	}
+}
+
+func routeLabel(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return "unmatched"
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"DineTogether/metrics"
 	"net/http"
 	"sync"
 	"time"
@@ -72,7 +73,8 @@ func RateLimitMiddleware(rl *rateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ip := c.ClientIP()
 		if !rl.Allow(ip) {
-			c.JSON(http.StatusTooManyRequests, gin.H{"error": "请求过于频繁，请稍后重试", "success": false})
+			metrics.RateLimitRejections.Inc(routeLabel(c))
+			c.JSON(http.StatusTooManyRequests, ErrorBody(c, "请求过于频繁，请稍后重试"))
 			c.Abort()
 			return
 		}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"DineTogether/logging"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RequestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+	requestIDKey       = "request_id"
+)
+
+// RequestID 沿用请求头中的 X-Request-ID（缺失或不合法时重新生成）并写回响应头，
+// 在 gin context 中放入带 request_id、method、route 字段的 logger，请求结束后记录访问日志。
+func RequestID(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		logging.Set(c, base.With("request_id", id, "method", c.Request.Method, "route", c.FullPath()))
+
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		logging.From(c).Log(c.Request.Context(), level, "请求完成",
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"ip", c.ClientIP(),
+		)
+	}
+}
+
+// SessionLogger 把会话中的 user_id、party_id 加入请求 logger，需注册在 sessions 中间件之后。
+func SessionLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := sessions.Default(c)
+		var attrs []any
+		for _, key := range []string{"user_id", "party_id"} {
+			if v := session.Get(key); v != nil {
+				attrs = append(attrs, key, v)
+			}
+		}
+		if len(attrs) > 0 {
+			logging.With(c, attrs...)
+		}
+		c.Next()
+	}
+}
+
+// GetRequestID 返回当前请求的 ID。
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// ErrorBody 返回统一的错误响应，附带请求 ID 便于对照日志。
+func ErrorBody(c *gin.Context, message string) gin.H {
+	body := gin.H{"error": message, "success": false}
+	if id := GetRequestID(c); id != "" {
+		body["request_id"] = id
+	}
+	return body
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' || r == ':') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
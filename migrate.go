@@ -0,0 +1,108 @@
+package main
+
+import (
+	"DineTogether/migrations"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/viper"
+)
+
+const migrateUsage = `用法: dinetogether migrate <命令>
+
+命令:
+  status            查看迁移状态
+  up                应用所有未执行的迁移
+  down [-n 步数]    回滚最近的迁移（默认 1 步）
+  schema [-o 文件]  输出全部迁移后的表结构（用于生成 schema.sql）
+`
+
+func runMigrateCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "schema":
+		fs := flag.NewFlagSet("schema", flag.ExitOnError)
+		output := fs.String("o", "", "输出文件，默认输出到标准输出")
+		fs.Parse(args[1:])
+		db, err := sql.Open("sqlite", ":memory:")
+		if err != nil {
+			log.Fatalf("无法创建内存数据库: %v", err)
+		}
+		defer db.Close()
+		db.SetMaxOpenConns(1)
+		schema, err := migrations.Schema(db)
+		if err != nil {
+			log.Fatalf("生成表结构失败: %v", err)
+		}
+		if *output == "" {
+			fmt.Print(schema)
+			return
+		}
+		if err := os.WriteFile(*output, []byte(schema), 0644); err != nil {
+			log.Fatalf("写入 %s 失败: %v", *output, err)
+		}
+		return
+	case "status", "up", "down":
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	loadConfig()
+	db := openDatabase(viper.GetString("database.path"))
+	defer db.Close()
+	migrator, err := migrations.New(db)
+	if err != nil {
+		log.Fatalf("初始化数据库迁移失败: %v", err)
+	}
+
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("查询迁移状态失败: %v", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "版本\t名称\t状态\t应用时间")
+		for _, s := range statuses {
+			state, appliedAt := "待执行", ""
+			if s.Applied {
+				state = "已应用"
+				appliedAt = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+				if s.ChecksumMismatch {
+					state = "校验和不一致"
+				}
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		w.Flush()
+		if err := migrator.Verify(); err != nil {
+			log.Fatalf("迁移校验失败: %v", err)
+		}
+	case "up":
+		count, err := migrator.Up()
+		if err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+		log.Printf("共应用 %d 个迁移", count)
+	case "down":
+		fs := flag.NewFlagSet("down", flag.ExitOnError)
+		steps := fs.Int("n", 1, "回滚的迁移数量")
+		fs.Parse(args[1:])
+		if *steps <= 0 {
+			log.Fatalf("回滚步数必须大于0")
+		}
+		count, err := migrator.Down(*steps)
+		if err != nil {
+			log.Fatalf("回滚迁移失败: %v", err)
+		}
+		log.Printf("共回滚 %d 个迁移", count)
+	}
+}
@@ -0,0 +1,192 @@
+package migrations
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var ErrChecksumMismatch = errors.New("迁移校验和不一致")
+
+type Status struct {
+	Version          int
+	Name             string
+	Applied          bool
+	AppliedAt        time.Time
+	ChecksumMismatch bool
+}
+
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return newMigrator(db, migrations)
+}
+
+// newMigrator 使用指定的迁移列表，migrations 须按版本升序排列。
+func newMigrator(db *sql.DB, migrations []Migration) (*Migrator, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return nil, fmt.Errorf("创建 schema_migrations 表失败: %w", err)
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func (m *Migrator) applied() (map[int]appliedMigration, error) {
+	rows, err := m.db.Query("SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("查询已应用迁移失败: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var am appliedMigration
+		if err := rows.Scan(&version, &am.name, &am.checksum, &am.appliedAt); err != nil {
+			return nil, fmt.Errorf("扫描迁移记录失败: %w", err)
+		}
+		applied[version] = am
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if am, ok := applied[mig.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = am.appliedAt
+			s.ChecksumMismatch = am.checksum != mig.Checksum
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Verify 确认数据库中已应用的迁移与当前程序内嵌的迁移完全一致。
+func (m *Migrator) Verify() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	known := make(map[int]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+	for version, am := range applied {
+		mig, ok := known[version]
+		if !ok {
+			return fmt.Errorf("数据库包含未知的迁移版本 %d (%s)，请升级程序", version, am.name)
+		}
+		if am.checksum != mig.Checksum {
+			return fmt.Errorf("%w: 版本 %d (%s)", ErrChecksumMismatch, version, mig.Name)
+		}
+	}
+	return nil
+}
+
+// Up 按顺序应用所有未执行的迁移，返回本次应用的数量。
+func (m *Migrator) Up() (int, error) {
+	if err := m.Verify(); err != nil {
+		return 0, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := m.apply(mig); err != nil {
+			return count, err
+		}
+		log.Printf("已应用迁移 %04d_%s", mig.Version, mig.Name)
+		count++
+	}
+	return count, nil
+}
+
+// Down 回滚最近应用的 steps 个迁移，返回实际回滚的数量。
+func (m *Migrator) Down(steps int) (int, error) {
+	if err := m.Verify(); err != nil {
+		return 0, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if err := m.revert(mig); err != nil {
+			return count, err
+		}
+		log.Printf("已回滚迁移 %04d_%s", mig.Version, mig.Name)
+		count++
+	}
+	return count, nil
+}
+
+func (m *Migrator) apply(mig Migration) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(mig.Up); err != nil {
+		return fmt.Errorf("执行迁移 %04d_%s 失败: %w", mig.Version, mig.Name, err)
+	}
+	_, err = tx.Exec("INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)", mig.Version, mig.Name, mig.Checksum)
+	if err != nil {
+		return fmt.Errorf("记录迁移 %04d_%s 失败: %w", mig.Version, mig.Name, err)
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) revert(mig Migration) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(mig.Down); err != nil {
+		return fmt.Errorf("回滚迁移 %04d_%s 失败: %w", mig.Version, mig.Name, err)
+	}
+	if _, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", mig.Version); err != nil {
+		return fmt.Errorf("删除迁移记录 %04d_%s 失败: %w", mig.Version, mig.Name, err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,244 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.sqlite")+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func migration(version int, name, up, down string) Migration {
+	sum := sha256.Sum256([]byte(up))
+	return Migration{Version: version, Name: name, Up: up, Down: down, Checksum: hex.EncodeToString(sum[:])}
+}
+
+// testMigrations 每个版本创建一张表，并在 steps 中记录执行顺序。
+func testMigrations() []Migration {
+	return []Migration{
+		migration(1, "steps", "CREATE TABLE steps (id INTEGER PRIMARY KEY AUTOINCREMENT, step TEXT NOT NULL); INSERT INTO steps (step) VALUES ('up 1');", "DROP TABLE steps;"),
+		migration(2, "a", "CREATE TABLE a (id INTEGER); INSERT INTO steps (step) VALUES ('up 2');", "DROP TABLE a; INSERT INTO steps (step) VALUES ('down 2');"),
+		migration(3, "b", "CREATE TABLE b (id INTEGER); INSERT INTO steps (step) VALUES ('up 3');", "DROP TABLE b; INSERT INTO steps (step) VALUES ('down 3');"),
+	}
+}
+
+func newTestMigrator(t *testing.T, db *sql.DB, migrations []Migration) *Migrator {
+	t.Helper()
+	m, err := newMigrator(db, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func steps(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query("SELECT step FROM steps ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var list []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+func appliedVersions(t *testing.T, m *Migrator) []int {
+	t.Helper()
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []int
+	for _, s := range statuses {
+		if s.Applied {
+			versions = append(versions, s.Version)
+		}
+	}
+	return versions
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n > 0
+}
+
+func TestUpDownOrder(t *testing.T) {
+	db := openDB(t)
+	m := newTestMigrator(t, db, testMigrations())
+
+	if n, err := m.Up(); err != nil || n != 3 {
+		t.Fatalf("Up = %d, %v", n, err)
+	}
+	if n, err := m.Up(); err != nil || n != 0 {
+		t.Fatalf("重复 Up = %d, %v", n, err)
+	}
+	if n, err := m.Down(2); err != nil || n != 2 {
+		t.Fatalf("Down(2) = %d, %v", n, err)
+	}
+	want := []string{"up 1", "up 2", "up 3", "down 3", "down 2"}
+	if got := steps(t, db); !reflect.DeepEqual(got, want) {
+		t.Fatalf("执行顺序 = %v, 期望 %v", got, want)
+	}
+	if got := appliedVersions(t, m); !reflect.DeepEqual(got, []int{1}) {
+		t.Fatalf("已应用版本 = %v", got)
+	}
+	if tableExists(t, db, "a") || tableExists(t, db, "b") {
+		t.Fatal("回滚后表仍然存在")
+	}
+	// 回滚数量超过已应用数量时只回滚已应用的部分
+	if n, err := m.Down(10); err != nil || n != 1 {
+		t.Fatalf("Down(10) = %d, %v", n, err)
+	}
+	if tableExists(t, db, "steps") {
+		t.Fatal("全部回滚后 steps 表仍然存在")
+	}
+}
+
+func TestRefuseChecksumMismatch(t *testing.T) {
+	db := openDB(t)
+	if _, err := newTestMigrator(t, db, testMigrations()[:2]).Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := testMigrations()
+	changed[1] = migration(2, "a", "CREATE TABLE a (id INTEGER, name TEXT); INSERT INTO steps (step) VALUES ('up 2');", changed[1].Down)
+	m := newTestMigrator(t, db, changed)
+	if err := m.Verify(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Verify: %v", err)
+	}
+	if n, err := m.Up(); !errors.Is(err, ErrChecksumMismatch) || n != 0 {
+		t.Fatalf("Up = %d, %v", n, err)
+	}
+	if n, err := m.Down(1); !errors.Is(err, ErrChecksumMismatch) || n != 0 {
+		t.Fatalf("Down = %d, %v", n, err)
+	}
+	// 校验失败时不执行任何迁移
+	if tableExists(t, db, "b") || !tableExists(t, db, "a") {
+		t.Fatal("校验失败后数据库仍被修改")
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statuses[1].ChecksumMismatch || statuses[0].ChecksumMismatch || statuses[2].Applied {
+		t.Fatalf("Status = %+v", statuses)
+	}
+
+	// 数据库中存在程序不认识的版本时同样拒绝执行
+	if err := newTestMigrator(t, db, testMigrations()[:1]).Verify(); err == nil {
+		t.Fatal("未知版本: Verify 未返回错误")
+	}
+}
+
+func TestPartiallyApplied(t *testing.T) {
+	db := openDB(t)
+	failing := testMigrations()
+	failing[2] = migration(3, "b", "CREATE TABLE b (id INTEGER); INSERT INTO missing (id) VALUES (1);", failing[2].Down)
+
+	// 第 3 个迁移失败：前两个已提交，失败的迁移整体回滚且不被记录
+	n, err := newTestMigrator(t, db, failing).Up()
+	if err == nil || n != 2 {
+		t.Fatalf("Up = %d, %v", n, err)
+	}
+	m := newTestMigrator(t, db, testMigrations())
+	if got := appliedVersions(t, m); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Fatalf("已应用版本 = %v", got)
+	}
+	if tableExists(t, db, "b") {
+		t.Fatal("失败的迁移未回滚")
+	}
+
+	// 修复后只执行剩余的迁移
+	if n, err := m.Up(); err != nil || n != 1 {
+		t.Fatalf("修复后 Up = %d, %v", n, err)
+	}
+	if got := steps(t, db); !reflect.DeepEqual(got, []string{"up 1", "up 2", "up 3"}) {
+		t.Fatalf("执行顺序 = %v", got)
+	}
+}
+
+func TestUpFillsGap(t *testing.T) {
+	db := openDB(t)
+	all := testMigrations()
+	// 模拟较早版本号的迁移后合入：1、3 已应用，2 未应用
+	if _, err := newTestMigrator(t, db, []Migration{all[0], all[2]}).Up(); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestMigrator(t, db, all)
+	if n, err := m.Up(); err != nil || n != 1 {
+		t.Fatalf("Up = %d, %v", n, err)
+	}
+	if got := steps(t, db); !reflect.DeepEqual(got, []string{"up 1", "up 3", "up 2"}) {
+		t.Fatalf("执行顺序 = %v", got)
+	}
+	// 回滚按版本号从高到低进行
+	if n, err := m.Down(2); err != nil || n != 2 {
+		t.Fatalf("Down = %d, %v", n, err)
+	}
+	if got := appliedVersions(t, m); !reflect.DeepEqual(got, []int{1}) {
+		t.Fatalf("已应用版本 = %v", got)
+	}
+}
+
+func TestEmbeddedMigrationsRoundTrip(t *testing.T) {
+	db := openDB(t)
+	m, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := len(m.migrations)
+	for i, mig := range m.migrations {
+		if mig.Version != i+1 {
+			t.Fatalf("迁移版本不连续: 第 %d 个为 %d", i+1, mig.Version)
+		}
+	}
+	if n, err := m.Up(); err != nil || n != total {
+		t.Fatalf("Up = %d, %v", n, err)
+	}
+	if n, err := m.Down(total); err != nil || n != total {
+		t.Fatalf("Down = %d, %v", n, err)
+	}
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'").Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Fatalf("全部回滚后仍有 %d 张表", tables)
+	}
+	if n, err := m.Up(); err != nil || n != total {
+		t.Fatalf("再次 Up = %d, %v", n, err)
+	}
+}
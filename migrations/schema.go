@@ -0,0 +1,87 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+const schemaHeader = "-- 此文件由 `go generate` 根据 migrations/sql 生成，请勿手动修改。\n\n"
+
+var createPrefixes = []string{"CREATE TABLE ", "CREATE INDEX ", "CREATE UNIQUE INDEX ", "CREATE TRIGGER ", "CREATE VIEW "}
+
+// Schema 在空数据库 db 上应用全部迁移，并导出最终的表结构。
+func Schema(db *sql.DB) (string, error) {
+	m, err := New(db)
+	if err != nil {
+		return "", err
+	}
+	if _, err := m.Up(); err != nil {
+		return "", err
+	}
+	rows, err := db.Query(`
+		SELECT sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'
+		ORDER BY rowid`)
+	if err != nil {
+		return "", fmt.Errorf("读取表结构失败: %w", err)
+	}
+	defer rows.Close()
+
+	var b strings.Builder
+	b.WriteString(schemaHeader)
+	first := true
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return "", fmt.Errorf("扫描表结构失败: %w", err)
+		}
+		if strings.HasPrefix(stmt, "CREATE TABLE ") {
+			stmt = formatCreateTable(stmt)
+		}
+		for _, prefix := range createPrefixes {
+			if strings.HasPrefix(stmt, prefix) && !strings.HasPrefix(stmt, prefix+"IF NOT EXISTS ") {
+				stmt = prefix + "IF NOT EXISTS " + strings.TrimPrefix(stmt, prefix)
+				break
+			}
+		}
+		if !first {
+			b.WriteString("\n")
+		}
+		first = false
+		b.WriteString(stmt)
+		b.WriteString(";\n")
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// formatCreateTable 将每个列定义与约束拆分到单独一行，
+// 因为 ALTER TABLE ADD COLUMN 追加的列在 sqlite_master 中会与上一列挤在同一行。
+func formatCreateTable(stmt string) string {
+	lparen := strings.Index(stmt, "(")
+	rparen := strings.LastIndex(stmt, ")")
+	if lparen < 0 || rparen < lparen {
+		return stmt
+	}
+	var parts []string
+	depth, start, inQuote := 0, lparen+1, false
+	for i := lparen + 1; i < rparen; i++ {
+		switch ch := stmt[i]; {
+		case ch == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case ch == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(stmt[start:i]))
+			start = i + 1
+		}
+	}
+	parts = append(parts, strings.TrimSpace(stmt[start:rparen]))
+	return strings.TrimSpace(stmt[:lparen]) + " (\n    " + strings.Join(parts, ",\n    ") + "\n" + stmt[rparen:]
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS party_members;
+DROP TABLE IF EXISTS parties;
+DROP TABLE IF EXISTS menus;
+DROP TABLE IF EXISTS users;
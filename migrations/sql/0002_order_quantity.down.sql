@@ -0,0 +1,12 @@
+WITH RECURSIVE seq(n) AS (
+    SELECT 1
+    UNION ALL
+    SELECT n + 1 FROM seq WHERE n < (SELECT MAX(quantity) FROM orders)
+)
+INSERT INTO orders (party_id, user_id, menu_id, created_at)
+SELECT o.party_id, o.user_id, o.menu_id, o.created_at
+FROM orders o
+JOIN seq ON seq.n < o.quantity;
+
+ALTER TABLE orders DROP COLUMN note;
+ALTER TABLE orders DROP COLUMN quantity;
@@ -0,0 +1,6 @@
+ALTER TABLE orders ADD COLUMN quantity INTEGER NOT NULL DEFAULT 1 CHECK(quantity > 0);
+ALTER TABLE orders ADD COLUMN note TEXT NOT NULL DEFAULT '';
+
+-- 旧版本加入 Party 时会插入 menu_id = 0 的占位订单来记录成员关系（成员关系现保存在 party_members），
+-- 这些行不对应任何菜品或精力消耗，引入份数后会被当作 1 份真实订单统计，因此一并清除。
+DELETE FROM orders WHERE menu_id = 0;
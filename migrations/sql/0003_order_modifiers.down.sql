@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP COLUMN unit_cost;
+ALTER TABLE orders DROP COLUMN modifiers;
+ALTER TABLE menus DROP COLUMN modifiers;
@@ -0,0 +1,5 @@
+ALTER TABLE menus ADD COLUMN modifiers TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE orders ADD COLUMN modifiers TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE orders ADD COLUMN unit_cost INTEGER NOT NULL DEFAULT 0;
+
+UPDATE orders SET unit_cost = COALESCE((SELECT energy_cost FROM menus WHERE menus.id = orders.menu_id), 0);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS party_state_history;
+
+ALTER TABLE parties ADD COLUMN is_active INTEGER NOT NULL DEFAULT 1;
+UPDATE parties SET is_active = CASE WHEN state = 'open' THEN 1 ELSE 0 END;
+ALTER TABLE parties DROP COLUMN state;
@@ -0,0 +1,17 @@
+ALTER TABLE parties ADD COLUMN state TEXT NOT NULL DEFAULT 'open' CHECK(state IN ('draft', 'open', 'locked', 'submitted', 'archived'));
+UPDATE parties SET state = 'locked' WHERE is_active = 0;
+ALTER TABLE parties DROP COLUMN is_active;
+
+CREATE TABLE IF NOT EXISTS party_state_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    party_id INTEGER NOT NULL,
+    from_state TEXT NOT NULL DEFAULT '',
+    to_state TEXT NOT NULL,
+    changed_by INTEGER,
+    changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (party_id) REFERENCES parties(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_party_state_history_party ON party_state_history(party_id, id);
+
+INSERT INTO party_state_history (party_id, to_state) SELECT id, state FROM parties;
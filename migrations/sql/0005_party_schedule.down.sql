@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_parties_state_closes_at;
+
+ALTER TABLE parties DROP COLUMN closes_at;
+ALTER TABLE parties DROP COLUMN opens_at;
@@ -0,0 +1,4 @@
+ALTER TABLE parties ADD COLUMN opens_at DATETIME;
+ALTER TABLE parties ADD COLUMN closes_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_parties_state_closes_at ON parties(state, closes_at);
@@ -0,0 +1,4 @@
+ALTER TABLE party_members DROP COLUMN budget;
+
+ALTER TABLE parties DROP COLUMN member_budget;
+ALTER TABLE parties DROP COLUMN budget_mode;
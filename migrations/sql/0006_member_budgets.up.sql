@@ -0,0 +1,5 @@
+ALTER TABLE parties ADD COLUMN budget_mode TEXT NOT NULL DEFAULT 'shared' CHECK(budget_mode IN ('shared', 'fixed', 'split'));
+ALTER TABLE parties ADD COLUMN member_budget INTEGER NOT NULL DEFAULT 0 CHECK(member_budget >= 0);
+
+-- budget 为 NULL 表示该成员不限额，只受 Party 总精力约束
+ALTER TABLE party_members ADD COLUMN budget INTEGER CHECK(budget >= 0);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_menu_tags_tag;
+DROP INDEX IF EXISTS idx_menus_category;
+DROP TABLE IF EXISTS menu_tags;
+
+ALTER TABLE menus DROP COLUMN category_id;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    sort_order INTEGER NOT NULL DEFAULT 0
+);
+
+ALTER TABLE menus ADD COLUMN category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS menu_tags (
+    menu_id INTEGER NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (menu_id, tag),
+    FOREIGN KEY (menu_id) REFERENCES menus(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_menus_category ON menus(category_id);
+CREATE INDEX IF NOT EXISTS idx_menu_tags_tag ON menu_tags(tag);
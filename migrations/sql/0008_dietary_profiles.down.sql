@@ -0,0 +1,4 @@
+ALTER TABLE menus DROP COLUMN allergens;
+ALTER TABLE users DROP COLUMN diet_strict;
+ALTER TABLE users DROP COLUMN diets;
+ALTER TABLE users DROP COLUMN allergies;
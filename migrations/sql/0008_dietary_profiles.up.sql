@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN allergies TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE users ADD COLUMN diets TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE users ADD COLUMN diet_strict INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE menus ADD COLUMN allergens TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_orders_menu_created;
+
+ALTER TABLE menus DROP COLUMN party_stock;
+ALTER TABLE menus DROP COLUMN daily_stock;
+ALTER TABLE menus DROP COLUMN available_until;
+ALTER TABLE menus DROP COLUMN available_from;
+ALTER TABLE menus DROP COLUMN available;
@@ -0,0 +1,7 @@
+ALTER TABLE menus ADD COLUMN available INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE menus ADD COLUMN available_from TEXT NOT NULL DEFAULT '';
+ALTER TABLE menus ADD COLUMN available_until TEXT NOT NULL DEFAULT '';
+ALTER TABLE menus ADD COLUMN daily_stock INTEGER CHECK(daily_stock >= 0);
+ALTER TABLE menus ADD COLUMN party_stock INTEGER CHECK(party_stock >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_orders_menu_created ON orders(menu_id, created_at);
@@ -0,0 +1,5 @@
+ALTER TABLE parties DROP COLUMN collection_id;
+
+DROP INDEX IF EXISTS idx_collection_menus_menu;
+DROP TABLE IF EXISTS collection_menus;
+DROP TABLE IF EXISTS collections;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS collections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS collection_menus (
+    collection_id INTEGER NOT NULL,
+    menu_id INTEGER NOT NULL,
+    PRIMARY KEY (collection_id, menu_id),
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (menu_id) REFERENCES menus(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_menus_menu ON collection_menus(menu_id);
+
+ALTER TABLE parties ADD COLUMN collection_id INTEGER REFERENCES collections(id) ON DELETE SET NULL;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS party_restaurants;
+
+DROP INDEX IF EXISTS idx_menus_restaurant;
+ALTER TABLE menus DROP COLUMN restaurant_id;
+
+DROP TABLE IF EXISTS restaurants;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS restaurants (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    contact TEXT NOT NULL DEFAULT '',
+    address TEXT NOT NULL DEFAULT '',
+    opening_hours TEXT NOT NULL DEFAULT '',
+    min_order INTEGER NOT NULL DEFAULT 0,
+    delivery_fee INTEGER NOT NULL DEFAULT 0
+);
+
+ALTER TABLE menus ADD COLUMN restaurant_id INTEGER REFERENCES restaurants(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_menus_restaurant ON menus(restaurant_id);
+
+CREATE TABLE IF NOT EXISTS party_restaurants (
+    party_id INTEGER NOT NULL,
+    restaurant_id INTEGER NOT NULL,
+    PRIMARY KEY (party_id, restaurant_id),
+    FOREIGN KEY (party_id) REFERENCES parties(id) ON DELETE CASCADE,
+    FOREIGN KEY (restaurant_id) REFERENCES restaurants(id) ON DELETE CASCADE
+);
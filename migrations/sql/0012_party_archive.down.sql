@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_user_created;
+
+ALTER TABLE parties DROP COLUMN deleted_at;
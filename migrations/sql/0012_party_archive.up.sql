@@ -0,0 +1,3 @@
+ALTER TABLE parties ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_created ON orders(user_id, created_at);
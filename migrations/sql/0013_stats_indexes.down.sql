@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_party_members_user_joined;
+
+DROP INDEX IF EXISTS idx_orders_created;
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_orders_created ON orders(created_at);
+
+CREATE INDEX IF NOT EXISTS idx_party_members_user_joined ON party_members(user_id, joined_at);
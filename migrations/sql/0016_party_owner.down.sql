@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_parties_owner;
+
+ALTER TABLE parties DROP COLUMN owner_id;
@@ -0,0 +1,3 @@
+ALTER TABLE parties ADD COLUMN owner_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_parties_owner ON parties(owner_id);
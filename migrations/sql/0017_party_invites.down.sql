@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_party_invites_party;
+DROP TABLE IF EXISTS party_invites;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS party_invites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    party_id INTEGER NOT NULL,
+    created_by INTEGER,
+    expires_at DATETIME NOT NULL,
+    max_uses INTEGER CHECK(max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0,
+    revoked_at DATETIME,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (party_id) REFERENCES parties(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_party_invites_party ON party_invites(party_id);
@@ -0,0 +1,2 @@
+DELETE FROM menus WHERE deleted_at IS NOT NULL;
+ALTER TABLE menus DROP COLUMN deleted_at;
@@ -0,0 +1,2 @@
+-- 删除菜品改为软删除，已锁定、已提交与已归档 Party 中的订单保留该菜品
+ALTER TABLE menus ADD COLUMN deleted_at DATETIME;
@@ -0,0 +1,24 @@
+-- 恢复名称的 UNIQUE 约束前，为与其他 Party 重名的已删除 Party 加上后缀
+UPDATE parties SET name = name || ' (已删除 #' || id || ')'
+WHERE deleted_at IS NOT NULL AND name IN (SELECT name FROM parties GROUP BY name HAVING COUNT(*) > 1);
+
+CREATE TABLE parties_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL,
+    energy_left INTEGER NOT NULL CHECK(energy_left >= 0),
+    state TEXT NOT NULL DEFAULT 'open' CHECK(state IN ('draft', 'open', 'locked', 'submitted', 'archived')),
+    opens_at DATETIME,
+    closes_at DATETIME,
+    budget_mode TEXT NOT NULL DEFAULT 'shared' CHECK(budget_mode IN ('shared', 'fixed', 'split')),
+    member_budget INTEGER NOT NULL DEFAULT 0 CHECK(member_budget >= 0),
+    collection_id INTEGER REFERENCES collections(id) ON DELETE SET NULL,
+    deleted_at DATETIME,
+    owner_id INTEGER REFERENCES users(id) ON DELETE SET NULL
+);
+INSERT INTO parties_old SELECT id, name, password, energy_left, state, opens_at, closes_at, budget_mode, member_budget, collection_id, deleted_at, owner_id FROM parties;
+DROP TABLE parties;
+ALTER TABLE parties_old RENAME TO parties;
+
+CREATE INDEX IF NOT EXISTS idx_parties_state_closes_at ON parties(state, closes_at);
+CREATE INDEX IF NOT EXISTS idx_parties_owner ON parties(owner_id);
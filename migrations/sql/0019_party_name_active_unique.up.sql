@@ -0,0 +1,23 @@
+-- Party 名称只在未删除的 Party 之间唯一，已删除 Party 的名称可以重新使用。
+-- SQLite 无法删除列上的 UNIQUE 约束，需要重建表；程序未开启 foreign_keys，删除旧表不会级联删除成员与订单。
+CREATE TABLE parties_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    password TEXT NOT NULL,
+    energy_left INTEGER NOT NULL CHECK(energy_left >= 0),
+    state TEXT NOT NULL DEFAULT 'open' CHECK(state IN ('draft', 'open', 'locked', 'submitted', 'archived')),
+    opens_at DATETIME,
+    closes_at DATETIME,
+    budget_mode TEXT NOT NULL DEFAULT 'shared' CHECK(budget_mode IN ('shared', 'fixed', 'split')),
+    member_budget INTEGER NOT NULL DEFAULT 0 CHECK(member_budget >= 0),
+    collection_id INTEGER REFERENCES collections(id) ON DELETE SET NULL,
+    deleted_at DATETIME,
+    owner_id INTEGER REFERENCES users(id) ON DELETE SET NULL
+);
+INSERT INTO parties_new SELECT id, name, password, energy_left, state, opens_at, closes_at, budget_mode, member_budget, collection_id, deleted_at, owner_id FROM parties;
+DROP TABLE parties;
+ALTER TABLE parties_new RENAME TO parties;
+
+CREATE INDEX IF NOT EXISTS idx_parties_state_closes_at ON parties(state, closes_at);
+CREATE INDEX IF NOT EXISTS idx_parties_owner ON parties(owner_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_parties_name_active ON parties(name) WHERE deleted_at IS NULL;
@@ -1,6 +1,9 @@
 package models
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 type User struct {
 	ID       int    `json:"id"`
@@ -9,12 +12,219 @@ type User struct {
 	Role     string `json:"role"`
 }
 
+// 用户角色。guest 为普通用户，只能参加 Party 点餐。
+const (
+	RoleAdmin      = "admin"
+	RoleOrganizer  = "organizer"
+	RoleMenuEditor = "menu_editor"
+	RoleViewer     = "viewer"
+	RoleGuest      = "guest"
+)
+
+// 权限，格式为 资源:操作。
+const (
+	// 菜品、分类、标签、菜单集与图片
+	PermMenuWrite       = "menu:write"
+	PermRestaurantWrite = "restaurant:write"
+	// 查看全部 Party、成员额度、状态历史、历史 Party 与导出出餐单
+	PermPartyRead = "party:read"
+	// 管理全部 Party：编辑、删除，变更状态、成员额度，移除成员与订单。
+	// 任何登录用户都可以新建 Party 并管理自己创建的 Party，无需此权限。
+	PermPartyWrite = "party:write"
+	PermStatsRead  = "stats:read"
+	PermAuditRead  = "audit:read"
+	PermUserRead   = "user:read"
+	// 新建、编辑、删除用户，分配角色与强制下线
+	PermUserWrite = "user:write"
+)
+
+// Roles 按权限从高到低排列。
+var Roles = []string{RoleAdmin, RoleOrganizer, RoleMenuEditor, RoleViewer, RoleGuest}
+
+var rolePermissions = map[string][]string{
+	RoleAdmin: {PermMenuWrite, PermRestaurantWrite, PermPartyRead, PermPartyWrite,
+		PermStatsRead, PermAuditRead, PermUserRead, PermUserWrite},
+	// 组织者只管理自己创建的 Party，由 RequirePartyAccess 按所有权放行，不授予全局 Party 权限
+	RoleOrganizer:  {},
+	RoleMenuEditor: {PermMenuWrite},
+	RoleViewer:     {PermPartyRead, PermStatsRead},
+	RoleGuest:      {},
+}
+
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolePermissions 返回角色拥有的权限，未知角色返回空列表。
+func RolePermissions(role string) []string {
+	return append([]string{}, rolePermissions[role]...)
+}
+
+func HasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
 type Menu struct {
-	ID          int      `json:"id"`
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	EnergyCost  int      `json:"energy_cost"`
-	ImageURLs   []string `json:"image_urls"`
+	ID          int            `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	EnergyCost  int            `json:"energy_cost"`
+	ImageURLs   []string       `json:"image_urls"`
+	Modifiers   []MenuModifier `json:"modifiers"`
+	// CategoryID 为空表示未分类，CategoryName 仅用于展示。
+	CategoryID   *int     `json:"category_id"`
+	CategoryName string   `json:"category_name"`
+	Tags         []string `json:"tags"`
+	// RestaurantID 为菜品所属餐厅，为空表示未关联，RestaurantName 仅用于展示。
+	RestaurantID   *int   `json:"restaurant_id"`
+	RestaurantName string `json:"restaurant_name"`
+	// Allergens 为菜品含有的过敏原与饮食成分，取值见 Allergens 列表。
+	Allergens []string `json:"allergens"`
+	// Available 为假表示已下架；AvailableFrom/AvailableUntil 为本地时间 "HH:MM" 的供应时段，为空表示全天供应。
+	Available      bool   `json:"available"`
+	AvailableFrom  string `json:"available_from"`
+	AvailableUntil string `json:"available_until"`
+	// DailyStock/PartyStock 为每日及每个 Party 的限量，为空表示不限量；SoldToday 仅用于展示。
+	DailyStock *int `json:"daily_stock"`
+	PartyStock *int `json:"party_stock"`
+	SoldToday  int  `json:"sold_today"`
+	// DeletedAt 非空表示菜品已删除，仅保留在订单历史中。
+	DeletedAt *time.Time `json:"-"`
+}
+
+const clockLayout = "15:04"
+
+func ValidClock(s string) bool {
+	_, err := time.Parse(clockLayout, s)
+	return err == nil
+}
+
+// InServingHours 判断 now 是否处于菜品的供应时段内，时段可以跨越午夜（如 22:00-02:00）。
+func (m *Menu) InServingHours(now time.Time) bool {
+	if m.AvailableFrom == "" || m.AvailableUntil == "" {
+		return true
+	}
+	clock := now.Format(clockLayout)
+	if m.AvailableFrom <= m.AvailableUntil {
+		return clock >= m.AvailableFrom && clock < m.AvailableUntil
+	}
+	return clock >= m.AvailableFrom || clock < m.AvailableUntil
+}
+
+// StartOfDay 返回 now 所在日期的本地零点，每日限量按该时间起统计。
+func StartOfDay(now time.Time) time.Time {
+	y, mo, d := now.Date()
+	return time.Date(y, mo, d, 0, 0, 0, 0, now.Location())
+}
+
+// 过敏原与饮食成分：前者对应用户的过敏项，meat/pork/alcohol 仅用于判断饮食限制。
+var Allergens = []string{"peanut", "tree_nut", "gluten", "dairy", "egg", "soy", "fish", "shellfish", "sesame", "meat", "pork", "alcohol"}
+
+// dietExcludes 为每种饮食限制不能食用的成分。
+var dietExcludes = map[string][]string{
+	"vegetarian": {"meat", "pork", "fish", "shellfish"},
+	"vegan":      {"meat", "pork", "fish", "shellfish", "dairy", "egg"},
+	"halal":      {"pork", "alcohol"},
+}
+
+var Diets = []string{"vegetarian", "vegan", "halal"}
+
+func ValidAllergen(a string) bool {
+	for _, v := range Allergens {
+		if v == a {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidDiet(d string) bool {
+	_, ok := dietExcludes[d]
+	return ok
+}
+
+// DietaryProfile 为用户的过敏与饮食限制，Strict 为真时点餐遇到冲突直接拒绝，否则仅提示。
+type DietaryProfile struct {
+	Allergies []string `json:"allergies"`
+	Diets     []string `json:"diets"`
+	Strict    bool     `json:"strict"`
+}
+
+// Conflicts 返回 allergens 中与档案冲突的成分，按 allergens 中的顺序排列。
+func (p *DietaryProfile) Conflicts(allergens []string) []string {
+	conflicts := []string{}
+	for _, a := range allergens {
+		if p.excludes(a) {
+			conflicts = append(conflicts, a)
+		}
+	}
+	return conflicts
+}
+
+func (p *DietaryProfile) excludes(allergen string) bool {
+	for _, a := range p.Allergies {
+		if a == allergen {
+			return true
+		}
+	}
+	for _, d := range p.Diets {
+		for _, a := range dietExcludes[d] {
+			if a == allergen {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DietaryConflict 描述一道菜品与用户饮食档案的冲突。
+type DietaryConflict struct {
+	MenuID    int      `json:"menu_id"`
+	MenuName  string   `json:"menu_name"`
+	Conflicts []string `json:"conflicts"`
+}
+
+// Category 为菜品分类，按 SortOrder 升序展示。
+type Category struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	SortOrder int    `json:"sort_order"`
+}
+
+// Collection 为可关联到 Party 的菜单集，MenuIDs 为其包含的菜品。
+type Collection struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	MenuIDs     []int  `json:"menu_ids"`
+}
+
+// Restaurant 为菜品的供应餐厅，MinOrder 为起送精力，DeliveryFee 为以精力计的配送费。
+type Restaurant struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Contact      string `json:"contact"`
+	Address      string `json:"address"`
+	OpeningHours string `json:"opening_hours"`
+	MinOrder     int    `json:"min_order"`
+	DeliveryFee  int    `json:"delivery_fee"`
+}
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// MenuModifier 是菜品的可选项，如"加辣"、"少盐"，Surcharge 为额外消耗的精力。
+type MenuModifier struct {
+	Name      string `json:"name"`
+	Surcharge int    `json:"surcharge"`
 }
 
 func (m *Menu) UnmarshalJSON(data []byte) error {
@@ -25,6 +235,8 @@ func (m *Menu) UnmarshalJSON(data []byte) error {
 	}{
 		Alias: (*Alias)(m),
 	}
+	// 未指定 available 时默认上架
+	m.Available = true
 	if err := json.Unmarshal(data, aux); err != nil {
 		return err
 	}
@@ -54,7 +266,112 @@ type Party struct {
 	Name       string `json:"name"`
 	Password   string `json:"password"`
 	EnergyLeft int    `json:"energy_left"`
-	IsActive   bool   `json:"is_active"`
+	State      string `json:"state"`
+	// OpensAt/ClosesAt 为可选的点餐时间窗口，为空表示不限制。
+	OpensAt  *time.Time `json:"opens_at"`
+	ClosesAt *time.Time `json:"closes_at"`
+	// BudgetMode 决定成员个人额度的分配方式，MemberBudget 仅在 fixed 模式下使用。
+	BudgetMode   string `json:"budget_mode"`
+	MemberBudget int    `json:"member_budget"`
+	// CollectionID 为 Party 使用的菜单集，为空表示可点全部菜品。
+	CollectionID *int `json:"collection_id"`
+	// RestaurantIDs 为 Party 合作的餐厅，出餐汇总按餐厅拆分。
+	RestaurantIDs []int `json:"restaurant_ids"`
+	// DeletedAt 非空表示 Party 已被删除，删除后仅保留在历史记录中。
+	DeletedAt *time.Time `json:"deleted_at"`
+	// OwnerID 为创建 Party 的用户，可管理自己的 Party；为空表示无所有者（旧数据或所有者已被删除）。
+	OwnerID *int `json:"owner_id"`
+}
+
+func (p *Party) OwnedBy(userID int) bool {
+	return p.OwnerID != nil && *p.OwnerID == userID
+}
+
+// 成员额度模式：shared 共用 Party 精力；fixed 每人固定额度；split 按成员数平分 Party 精力。
+const (
+	BudgetShared = "shared"
+	BudgetFixed  = "fixed"
+	BudgetSplit  = "split"
+)
+
+func ValidBudgetMode(mode string) bool {
+	return mode == BudgetShared || mode == BudgetFixed || mode == BudgetSplit
+}
+
+// InOrderingWindow 判断 now 是否处于 Party 的点餐时间窗口内。
+func (p *Party) InOrderingWindow(now time.Time) bool {
+	if p.OpensAt != nil && now.Before(*p.OpensAt) {
+		return false
+	}
+	if p.ClosesAt != nil && !now.Before(*p.ClosesAt) {
+		return false
+	}
+	return true
+}
+
+// Party 生命周期：草稿 → 开放点餐 → 锁定 → 已提交 → 归档。
+const (
+	PartyDraft     = "draft"
+	PartyOpen      = "open"
+	PartyLocked    = "locked"
+	PartySubmitted = "submitted"
+	PartyArchived  = "archived"
+)
+
+var partyTransitions = map[string][]string{
+	PartyDraft:     {PartyOpen, PartyArchived},
+	PartyOpen:      {PartyLocked, PartyArchived},
+	PartyLocked:    {PartyOpen, PartySubmitted, PartyArchived},
+	PartySubmitted: {PartyArchived},
+	PartyArchived:  {},
+}
+
+var partyStateOrder = []string{PartyDraft, PartyOpen, PartyLocked, PartySubmitted, PartyArchived}
+
+func ValidPartyState(state string) bool {
+	_, ok := partyTransitions[state]
+	return ok
+}
+
+func CanTransitionParty(from, to string) bool {
+	for _, s := range partyTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextPartyState 返回生命周期中的下一个状态，已归档时返回空字符串。
+func NextPartyState(state string) string {
+	for i, s := range partyStateOrder {
+		if s == state && i+1 < len(partyStateOrder) {
+			return partyStateOrder[i+1]
+		}
+	}
+	return ""
+}
+
+type PartyStateChange struct {
+	ID        int       `json:"id"`
+	PartyID   int       `json:"party_id"`
+	FromState string    `json:"from_state"`
+	ToState   string    `json:"to_state"`
+	ChangedBy int       `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// PartyInvite 为 Party 邀请链接，链接中的令牌由邀请 ID 与过期时间签名生成，不保存在数据库中。
+// MaxUses 为空表示不限次数，Uses 只统计通过邀请新加入的成员。
+type PartyInvite struct {
+	ID        int        `json:"id"`
+	PartyID   int        `json:"party_id"`
+	CreatedBy *int       `json:"created_by"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	MaxUses   *int       `json:"max_uses"`
+	Uses      int        `json:"uses"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
 }
 
 type PartyMember struct {
@@ -63,9 +380,178 @@ type PartyMember struct {
 	UserID   int `json:"user_id"`
 }
 
+// MemberBudget 为成员在 Party 中的额度使用情况，Budget/Remaining 为空表示不限额。
+type MemberBudget struct {
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	Budget    *int   `json:"budget"`
+	Spent     int    `json:"spent"`
+	Remaining *int   `json:"remaining"`
+}
+
+// PartySummary 为已归档 Party 的概览，EnergyUsed 为全部订单消耗的精力。
+type PartySummary struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Members    int        `json:"members"`
+	Orders     int        `json:"orders"`
+	EnergyUsed int        `json:"energy_used"`
+	EnergyLeft int        `json:"energy_left"`
+	ArchivedAt time.Time  `json:"archived_at"`
+	DeletedAt  *time.Time `json:"deleted_at"`
+}
+
 type Order struct {
-	ID      int `json:"id"`
-	PartyID int `json:"party_id"`
-	UserID  int `json:"user_id"`
-	MenuID  int `json:"menu_id"`
+	ID        int       `json:"id"`
+	PartyID   int       `json:"party_id"`
+	UserID    int       `json:"user_id"`
+	MenuID    int       `json:"menu_id"`
+	Quantity  int       `json:"quantity"`
+	Note      string    `json:"note"`
+	Modifiers []string  `json:"modifiers"`
+	UnitCost  int       `json:"unit_cost"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CartItem struct {
+	MenuID    int      `json:"menu_id"`
+	Quantity  int      `json:"quantity"`
+	Note      string   `json:"note"`
+	Modifiers []string `json:"modifiers"`
+}
+
+type OrderItem struct {
+	ID         int      `json:"id"`
+	Username   string   `json:"username"`
+	MenuName   string   `json:"menu_name"`
+	MenuID     int      `json:"menu_id"`
+	ImageURLs  []string `json:"image_urls"`
+	EnergyCost int      `json:"energy_cost"`
+	Quantity   int      `json:"quantity"`
+	Note       string   `json:"note"`
+	Modifiers  []string `json:"modifiers"`
+	// Conflicts 为菜品与下单用户饮食档案冲突的成分，无冲突时为空。
+	Conflicts []string `json:"conflicts"`
+}
+
+// UserOrder 为用户在某个 Party 中的一条订单记录，用于查看个人历史订单。
+type UserOrder struct {
+	ID         int       `json:"id"`
+	PartyID    int       `json:"party_id"`
+	PartyName  string    `json:"party_name"`
+	PartyState string    `json:"party_state"`
+	MenuID     int       `json:"menu_id"`
+	MenuName   string    `json:"menu_name"`
+	Quantity   int       `json:"quantity"`
+	UnitCost   int       `json:"unit_cost"`
+	Note       string    `json:"note"`
+	Modifiers  []string  `json:"modifiers"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// KitchenItem 是按菜品与选项组合汇总后的出餐清单条目。
+type KitchenItem struct {
+	MenuID    int      `json:"menu_id"`
+	MenuName  string   `json:"menu_name"`
+	Modifiers []string `json:"modifiers"`
+	Quantity  int      `json:"quantity"`
+	Notes     []string `json:"notes"`
+	// RestaurantID 为菜品所属餐厅，Energy 为该条目消耗的精力合计。
+	RestaurantID *int `json:"restaurant_id"`
+	Energy       int  `json:"energy"`
+}
+
+// RestaurantOrder 为某个餐厅的出餐汇总，Restaurant 为空表示未关联餐厅的菜品。
+type RestaurantOrder struct {
+	Restaurant   *Restaurant   `json:"restaurant"`
+	Items        []KitchenItem `json:"items"`
+	Subtotal     int           `json:"subtotal"`
+	BelowMinimum bool          `json:"below_minimum"`
+}
+
+// 统计分组方式：按下单日期、周（周一开始）、月份，或按用户、菜品汇总。
+const (
+	StatsByDay   = "day"
+	StatsByWeek  = "week"
+	StatsByMonth = "month"
+	StatsByUser  = "user"
+	StatsByDish  = "dish"
+)
+
+func ValidStatsGroup(group string) bool {
+	switch group {
+	case StatsByDay, StatsByWeek, StatsByMonth, StatsByUser, StatsByDish:
+		return true
+	}
+	return false
+}
+
+// StatsPeriod 返回 t 在本地时区所属的日期、周或月份，周以周一的日期表示。
+func StatsPeriod(t time.Time, group string) string {
+	t = t.Local()
+	switch group {
+	case StatsByWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return StartOfDay(t).AddDate(0, 0, -offset).Format(time.DateOnly)
+	case StatsByMonth:
+		return t.Format("2006-01")
+	}
+	return t.Format(time.DateOnly)
+}
+
+// StatsOverview 为时间范围内的消费概况，AvgEnergyPerParty 按有订单的 Party 计算。
+type StatsOverview struct {
+	Orders            int     `json:"orders"`
+	Quantity          int     `json:"quantity"`
+	Energy            int     `json:"energy"`
+	Parties           int     `json:"parties"`
+	Users             int     `json:"users"`
+	AvgEnergyPerParty float64 `json:"avg_energy_per_party"`
+}
+
+// StatsBucket 为一个分组的汇总，Key 为日期、周、月份、用户 ID 或菜品 ID，Label 用于展示。
+type StatsBucket struct {
+	Key      string `json:"key"`
+	Label    string `json:"label"`
+	Orders   int    `json:"orders"`
+	Quantity int    `json:"quantity"`
+	Energy   int    `json:"energy"`
+	Parties  int    `json:"parties"`
+	Users    int    `json:"users"`
+}
+
+// InactiveUser 为时间范围内未加入任何 Party 的用户，LastJoinedAt 为其最近一次加入的时间。
+type InactiveUser struct {
+	ID           int        `json:"id"`
+	Username     string     `json:"username"`
+	Role         string     `json:"role"`
+	LastJoinedAt *time.Time `json:"last_joined_at"`
+}
+
+// AuditEvent 为一次成功操作的审计记录。ActorID 为空表示未登录用户或系统操作，ActorName 仅用于展示；
+// Before/After 为操作前后数据的 JSON，新建时 Before 为空，删除时 After 为空。
+type AuditEvent struct {
+	ID         int             `json:"id"`
+	ActorID    *int            `json:"actor_id"`
+	ActorName  string          `json:"actor_name"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	IP         string          `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// Session 为服务端保存的会话。ID 为 Cookie 中会话令牌的哈希，令牌本身不落库；UserID 为空表示未登录；
+// Data 为编码后的会话数据；Current 仅在列出会话时标记当前请求所用的会话。
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     *int      `json:"user_id"`
+	Data       []byte    `json:"-"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
 }
@@ -0,0 +1,520 @@
+// Package qrcode 生成 QR 码（ISO/IEC 18004，字节模式，版本 1–10）并输出为 PNG，仅依赖标准库。
+package qrcode
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// Level 为纠错等级，可恢复约 7%（L）、15%（M）、25%（Q）、30%（H）的码字。
+type Level int
+
+const (
+	L Level = iota
+	M
+	Q
+	H
+)
+
+// formatBits 为格式信息中纠错等级的编码。
+var formatBits = [4]int{L: 1, M: 0, Q: 3, H: 2}
+
+const maxVersion = 10
+
+// 每个纠错块的纠错码字数与纠错块数，按 [纠错等级][版本] 索引，版本 0 不使用。
+var (
+	eccPerBlock = [4][maxVersion + 1]int{
+		L: {0, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18},
+		M: {0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26},
+		Q: {0, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24},
+		H: {0, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28},
+	}
+	numBlocks = [4][maxVersion + 1]int{
+		L: {0, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4},
+		M: {0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5},
+		Q: {0, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8},
+		H: {0, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8},
+	}
+)
+
+// ErrTooLong 表示数据超出版本 10 在该纠错等级下的容量。
+var ErrTooLong = errors.New("qrcode: 数据过长")
+
+// Code 为生成的 QR 码，modules[y][x] 为 true 表示深色模块。
+type Code struct {
+	Version int
+	Size    int
+	modules [][]bool
+	// isFunction 标记定位、校正、时序、格式与版本信息等功能图形，掩码不作用于这些模块
+	isFunction [][]bool
+}
+
+// Encode 以字节模式编码 data，自动选择能容纳数据的最小版本与评分最低的掩码。
+func Encode(data []byte, level Level) (*Code, error) {
+	version := 0
+	for v := 1; v <= maxVersion; v++ {
+		if 4+charCountBits(v)+8*len(data) <= dataCodewords(v, level)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	var bb bitBuffer
+	bb.append(0b0100, 4)
+	bb.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+	capacity := dataCodewords(version, level) * 8
+	bb.append(0, min(4, capacity-len(bb)))
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xEC; len(bb) < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+	codewords := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			codewords[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+
+	size := version*4 + 17
+	c := &Code{Version: version, Size: size, modules: grid(size), isFunction: grid(size)}
+	c.drawFunctionPatterns()
+	c.drawCodewords(addECCAndInterleave(codewords, version, level))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(level, mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		// 掩码为异或运算，再次应用即可撤销
+		c.applyMask(mask)
+	}
+	c.applyMask(best)
+	c.drawFormatBits(level, best)
+	return c, nil
+}
+
+// Black 返回 (x, y) 处是否为深色模块，超出范围时返回 false。
+func (c *Code) Black(x, y int) bool {
+	return x >= 0 && x < c.Size && y >= 0 && y < c.Size && c.modules[y][x]
+}
+
+// Image 返回每个模块为 scale 像素、四周留 4 个模块空白的黑白图像。
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	const quiet = 4
+	n := (c.Size + 2*quiet) * scale
+	img := image.NewPaletted(image.Rect(0, 0, n, n), color.Palette{color.White, color.Black})
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				row := ((y+quiet)*scale + dy) * img.Stride
+				for dx := 0; dx < scale; dx++ {
+					img.Pix[row+(x+quiet)*scale+dx] = 1
+				}
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG 将 Image(scale) 以 PNG 格式写入 w。
+func (c *Code) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, c.Image(scale))
+}
+
+func grid(size int) [][]bool {
+	g := make([][]bool, size)
+	for i := range g {
+		g[i] = make([]bool, size)
+	}
+	return g
+}
+
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// rawDataModules 返回除功能图形外可放置数据与纠错码字的模块数。
+func rawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		result -= (25*align-10)*align - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func dataCodewords(version int, level Level) int {
+	return rawDataModules(version)/8 - eccPerBlock[level][version]*numBlocks[level][version]
+}
+
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, value>>i&1 != 0)
+	}
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.isFunction[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+	pos := alignmentPositions(c.Version)
+	last := len(pos) - 1
+	for i := range pos {
+		for j := range pos {
+			// 与定位图形重叠的三个角不放校正图形
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			c.drawAlignment(pos[i], pos[j])
+		}
+	}
+	// 先占位，选定掩码后再写入实际的格式信息
+	c.drawFormatBits(L, 0)
+	c.drawVersion()
+}
+
+// drawFinder 绘制以 (x, y) 为中心的定位图形及其分隔符。
+func (c *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= c.Size || yy < 0 || yy >= c.Size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			c.setFunction(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, version*4+10; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+func (c *Code) drawFormatBits(level Level, mask int) {
+	data := formatBits[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 != 0 }
+
+	// 左上角
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(i))
+	}
+	c.setFunction(8, 7, bit(6))
+	c.setFunction(8, 8, bit(7))
+	c.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(i))
+	}
+	// 右上角与左下角
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.Size-15+i, bit(i))
+	}
+	c.setFunction(8, c.Size-8, true)
+}
+
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	rem := c.Version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	bits := c.Version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 != 0
+		a, b := c.Size-11+i%3, i/3
+		c.setFunction(a, b, dark)
+		c.setFunction(b, a, dark)
+	}
+}
+
+// addECCAndInterleave 将数据码字分块、为每块计算 Reed-Solomon 纠错码字后交错排列。
+// 短块排在前面，比长块少一个数据码字。
+func addECCAndInterleave(data []byte, version int, level Level) []byte {
+	blocks, ecc := numBlocks[level][version], eccPerBlock[level][version]
+	raw := rawDataModules(version) / 8
+	shortBlocks := blocks - raw%blocks
+	shortLen := raw / blocks
+
+	divisor := rsDivisor(ecc)
+	all := make([][]byte, blocks)
+	k := 0
+	for i := range all {
+		n := shortLen - ecc
+		if i >= shortBlocks {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		if i < shortBlocks {
+			// 占位使各块等长，交错时跳过
+			block = append(block, 0)
+		}
+		all[i] = append(block, rsRemainder(data[k-n:k], divisor)...)
+	}
+
+	result := make([]byte, 0, raw)
+	for i := range all[0] {
+		for j, block := range all {
+			if i != shortLen-ecc || j >= shortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// drawCodewords 从右下角开始按两列一组、上下交替的顺序放置数据位，跳过功能图形与第 6 列的时序图形。
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.isFunction[y][x] || i >= len(data)*8 {
+					continue
+				}
+				c.modules[y][x] = data[i>>3]>>(7-i&7)&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.isFunction[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// 掩码评分规则的罚分权重。
+const (
+	penaltyRun     = 3
+	penaltyBlock   = 3
+	penaltyFinder  = 40
+	penaltyBalance = 10
+)
+
+// penalty 按标准的四条规则计算当前图形的罚分，用于选择掩码。
+func (c *Code) penalty() int {
+	result := 0
+	for _, vertical := range []bool{false, true} {
+		for a := 0; a < c.Size; a++ {
+			var history [7]int
+			runDark, run := false, 0
+			for b := 0; b < c.Size; b++ {
+				m := c.modules[a][b]
+				if vertical {
+					m = c.modules[b][a]
+				}
+				if m == runDark {
+					run++
+					if run == 5 {
+						result += penaltyRun
+					} else if run > 5 {
+						result++
+					}
+					continue
+				}
+				c.addRunHistory(run, &history)
+				if !runDark {
+					result += finderLike(&history) * penaltyFinder
+				}
+				runDark, run = m, 1
+			}
+			// 行尾视为接上空白区
+			if runDark {
+				c.addRunHistory(run, &history)
+				run = 0
+			}
+			c.addRunHistory(run+c.Size, &history)
+			result += finderLike(&history) * penaltyFinder
+		}
+	}
+	for y := 0; y < c.Size-1; y++ {
+		for x := 0; x < c.Size-1; x++ {
+			m := c.modules[y][x]
+			if m == c.modules[y][x+1] && m == c.modules[y+1][x] && m == c.modules[y+1][x+1] {
+				result += penaltyBlock
+			}
+		}
+	}
+	dark := 0
+	for _, row := range c.modules {
+		for _, m := range row {
+			if m {
+				dark++
+			}
+		}
+	}
+	total := c.Size * c.Size
+	// 深色模块占比每偏离 50% 五个百分点罚一次
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	result += k * penaltyBalance
+	return result
+}
+
+// addRunHistory 记录最近 7 段同色游程的长度，行首的浅色游程加上空白区宽度。
+func (c *Code) addRunHistory(run int, history *[7]int) {
+	if history[0] == 0 {
+		run += c.Size
+	}
+	copy(history[1:], history[:6])
+	history[0] = run
+}
+
+// finderLike 返回游程中 1:1:3:1:1 且一侧有 4 倍空白的类定位图形个数。
+func finderLike(h *[7]int) int {
+	n := h[1]
+	core := n > 0 && h[2] == n && h[3] == n*3 && h[4] == n && h[5] == n
+	count := 0
+	if core && h[0] >= n*4 && h[6] >= n {
+		count++
+	}
+	if core && h[6] >= n*4 && h[0] >= n {
+		count++
+	}
+	return count
+}
+
+// rsDivisor 返回 degree 次 Reed-Solomon 生成多项式的系数（不含最高次项），
+// 在 GF(2^8)（本原多项式 0x11D）上计算。
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMul(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,393 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// 以下向量取自 ISO/IEC 18004 的表格与常见教程，用于独立校验编码器。
+
+// 各版本的码字总数（数据 + 纠错）。
+var totalCodewords = [maxVersion + 1]int{0, 26, 44, 70, 100, 134, 172, 196, 242, 292, 346}
+
+// 各纠错等级、各版本的数据码字数。
+var wantDataCodewords = [4][maxVersion + 1]int{
+	L: {0, 19, 34, 55, 80, 108, 136, 156, 194, 232, 274},
+	M: {0, 16, 28, 44, 64, 86, 108, 124, 154, 182, 216},
+	Q: {0, 13, 22, 34, 48, 62, 76, 88, 110, 132, 154},
+	H: {0, 9, 16, 26, 36, 46, 60, 66, 86, 100, 122},
+}
+
+// 格式信息（已加 BCH 纠错位并与 101010000010010 异或），高位在前，按 [纠错等级][掩码] 索引。
+var formatStrings = [4][8]string{
+	L: {"111011111000100", "111001011110011", "111110110101010", "111100010011101",
+		"110011000101111", "110001100011000", "110110001000001", "110100101110110"},
+	M: {"101010000010010", "101000100100101", "101111001111100", "101101101001011",
+		"100010111111001", "100000011001110", "100111110010111", "100101010100000"},
+	Q: {"011010101011111", "011000001101000", "011111100110001", "011101000000110",
+		"010010010110100", "010000110000011", "010111011011010", "010101111101101"},
+	H: {"001011010001001", "001001110111110", "001110011100111", "001100111010000",
+		"000011101100010", "000001001010101", "000110100001100", "000100000111011"},
+}
+
+// 版本信息（含 BCH 纠错位）。
+var versionInfo = map[int]int{7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3}
+
+// 各版本校正图形的中心坐标。
+var alignmentCenters = [maxVersion + 1][]int{
+	2: {6, 18}, 3: {6, 22}, 4: {6, 26}, 5: {6, 30}, 6: {6, 34},
+	7: {6, 22, 38}, 8: {6, 24, 42}, 9: {6, 26, 46}, 10: {6, 28, 50},
+}
+
+func TestCapacityTables(t *testing.T) {
+	for v := 1; v <= maxVersion; v++ {
+		if got := rawDataModules(v) / 8; got != totalCodewords[v] {
+			t.Errorf("版本 %d 码字总数 = %d, 期望 %d", v, got, totalCodewords[v])
+		}
+		for level := L; level <= H; level++ {
+			if got := dataCodewords(v, level); got != wantDataCodewords[level][v] {
+				t.Errorf("版本 %d 等级 %d 数据码字 = %d, 期望 %d", v, level, got, wantDataCodewords[level][v])
+			}
+		}
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	// "HELLO WORLD" 1-M 的数据码字与纠错码字
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Fatalf("纠错码字 = %v, 期望 %v", got, want)
+	}
+}
+
+// readFormat 读取两处格式信息并返回高位在前的字符串。
+func readFormat(c *Code) (string, string) {
+	bit := func(x, y int) byte {
+		if c.Black(x, y) {
+			return '1'
+		}
+		return '0'
+	}
+	var first, second []byte
+	for _, x := range []int{0, 1, 2, 3, 4, 5, 7, 8} {
+		first = append(first, bit(x, 8))
+	}
+	for _, y := range []int{7, 5, 4, 3, 2, 1, 0} {
+		first = append(first, bit(8, y))
+	}
+	for y := c.Size - 1; y >= c.Size-7; y-- {
+		second = append(second, bit(8, y))
+	}
+	for x := c.Size - 8; x < c.Size; x++ {
+		second = append(second, bit(x, 8))
+	}
+	return string(first), string(second)
+}
+
+// readVersion 读取右上角与左下角的版本信息，低位位于 (Size-11, 0) 及其转置位置。
+func readVersion(c *Code) (int, int) {
+	var topRight, bottomLeft int
+	for i := 0; i < 18; i++ {
+		a, b := c.Size-11+i%3, i/3
+		if c.Black(a, b) {
+			topRight |= 1 << i
+		}
+		if c.Black(b, a) {
+			bottomLeft |= 1 << i
+		}
+	}
+	return topRight, bottomLeft
+}
+
+// isReserved 按规范独立计算功能图形区域，不使用编码器中的 isFunction。
+func isReserved(version, x, y int) bool {
+	size := version*4 + 17
+	switch {
+	case x <= 8 && y <= 8, x >= size-8 && y <= 8, x <= 8 && y >= size-8:
+		return true
+	case x == 6 || y == 6:
+		return true
+	case version >= 7 && (x >= size-11 && x < size-8 && y < 6 || y >= size-11 && y < size-8 && x < 6):
+		return true
+	}
+	centers := alignmentCenters[version]
+	for _, cx := range centers {
+		for _, cy := range centers {
+			// 与定位图形重叠的校正图形不绘制
+			if overlapsFinder(size, cx, cy) {
+				continue
+			}
+			if abs(x-cx) <= 2 && abs(y-cy) <= 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func overlapsFinder(size, cx, cy int) bool {
+	return cx < 9 && cy < 9 || cx >= size-9 && cy < 9 || cx < 9 && cy >= size-9
+}
+
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (y+x)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (y+x)%3 == 0
+	case 4:
+		return (y/2+x/3)%2 == 0
+	case 5:
+		return y*x%2+y*x%3 == 0
+	case 6:
+		return (y*x%2+y*x%3)%2 == 0
+	default:
+		return ((y+x)%2+y*x%3)%2 == 0
+	}
+}
+
+// decode 按规范解码 QR 码：读取格式信息、去掩码、按之字形读取码字、解交错并校验纠错码，返回字节模式数据。
+func decode(c *Code) ([]byte, Level, error) {
+	first, second := readFormat(c)
+	if first != second {
+		return nil, 0, errors.New("两处格式信息不一致")
+	}
+	level, mask := Level(-1), -1
+	for l := L; l <= H; l++ {
+		for m, s := range formatStrings[l] {
+			if s == first {
+				level, mask = l, m
+			}
+		}
+	}
+	if mask < 0 {
+		return nil, 0, errors.New("格式信息无效: " + first)
+	}
+	version := (c.Size - 17) / 4
+
+	// 之字形读取：从右下角开始，每两列一组交替向上、向下，跳过第 6 列的时序图形
+	var bits []bool
+	up := true
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for i := 0; i < c.Size; i++ {
+			y := i
+			if up {
+				y = c.Size - 1 - i
+			}
+			for _, x := range []int{right, right - 1} {
+				if !isReserved(version, x, y) {
+					bits = append(bits, c.Black(x, y) != masked(mask, x, y))
+				}
+			}
+		}
+		up = !up
+	}
+	raw := make([]byte, totalCodewords[version])
+	for i := range raw {
+		for j := 0; j < 8; j++ {
+			if bits[i*8+j] {
+				raw[i] |= 1 << (7 - j)
+			}
+		}
+	}
+
+	blocks, ecc := numBlocks[level][version], eccPerBlock[level][version]
+	shortData := len(raw)/blocks - ecc
+	longBlocks := len(raw) % blocks
+	data := make([][]byte, blocks)
+	pos := 0
+	for i := 0; i <= shortData; i++ {
+		for b := range data {
+			if i < shortData || b >= blocks-longBlocks {
+				data[b] = append(data[b], raw[pos])
+				pos++
+			}
+		}
+	}
+	var codewords []byte
+	for b := range data {
+		block := append([]byte{}, data[b]...)
+		for i := 0; i < ecc; i++ {
+			block = append(block, raw[pos+i*blocks+b])
+		}
+		for _, r := range rsRemainder(block, rsDivisor(ecc)) {
+			if r != 0 {
+				return nil, 0, errors.New("纠错码校验失败")
+			}
+		}
+		codewords = append(codewords, data[b]...)
+	}
+
+	read := func(offset, n int) int {
+		v := 0
+		for i := offset; i < offset+n; i++ {
+			v = v<<1 | int(codewords[i/8]>>(7-i%8)&1)
+		}
+		return v
+	}
+	if read(0, 4) != 0b0100 {
+		return nil, 0, errors.New("不是字节模式")
+	}
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	n := read(4, countBits)
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(read(4+countBits+8*i, 8))
+	}
+	return out, level, nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	for level := L; level <= H; level++ {
+		// 覆盖每个版本的容量上限，以及长短块混合的版本
+		for v := 1; v <= maxVersion; v++ {
+			n := dataCodewords(v, level) - 2
+			if v >= 10 {
+				n--
+			}
+			data := bytes.Repeat([]byte("https://dine.example/join/"), n/26+1)[:n]
+			c, err := Encode(data, level)
+			if err != nil {
+				t.Fatalf("等级 %d 版本 %d: %v", level, v, err)
+			}
+			if c.Version != v || c.Size != v*4+17 {
+				t.Fatalf("等级 %d 长度 %d: 版本 = %d, 尺寸 = %d, 期望版本 %d", level, n, c.Version, c.Size, v)
+			}
+			got, gotLevel, err := decode(c)
+			if err != nil {
+				t.Fatalf("等级 %d 版本 %d 解码: %v", level, v, err)
+			}
+			if gotLevel != level || !bytes.Equal(got, data) {
+				t.Fatalf("等级 %d 版本 %d 解码 = %q (等级 %d), 期望 %q", level, v, got, gotLevel, data)
+			}
+			if v >= 7 {
+				topRight, bottomLeft := readVersion(c)
+				if topRight != versionInfo[v] || bottomLeft != versionInfo[v] {
+					t.Fatalf("版本 %d 版本信息 = %#x/%#x, 期望 %#x", v, topRight, bottomLeft, versionInfo[v])
+				}
+			}
+		}
+	}
+}
+
+func TestReservedMatchesEncoder(t *testing.T) {
+	for v := 1; v <= maxVersion; v++ {
+		c, err := Encode(bytes.Repeat([]byte{'x'}, dataCodewords(v, L)-3), L)
+		if err != nil {
+			t.Fatal(err)
+		}
+		free := 0
+		for y := 0; y < c.Size; y++ {
+			for x := 0; x < c.Size; x++ {
+				if isReserved(v, x, y) != c.isFunction[y][x] {
+					t.Fatalf("版本 %d (%d, %d): 功能图形标记不一致", v, x, y)
+				}
+				if !isReserved(v, x, y) {
+					free++
+				}
+			}
+		}
+		if free != rawDataModules(v) {
+			t.Fatalf("版本 %d 数据模块 = %d, 期望 %d", v, free, rawDataModules(v))
+		}
+	}
+}
+
+// golden 为 "DineTogether" 以 M 级纠错编码的版本 1 矩阵，已由 decode 与格式信息表校验。
+var golden = []string{
+	"#######..###..#######",
+	"#.....#.#..##.#.....#",
+	"#.###.#...#...#.###.#",
+	"#.###.#..#.#..#.###.#",
+	"#.###.#.#####.#.###.#",
+	"#.....#..###..#.....#",
+	"#######.#.#.#.#######",
+	"..........#..........",
+	"#.#.#.#..#..#...#..#.",
+	".##....#.###...######",
+	"##.##.###.##.########",
+	".###.#.....##...#..#.",
+	"#.#.###.####.#.##..##",
+	"........#.##...##.##.",
+	"#######...#.#.###..##",
+	"#.....#...###...#..#.",
+	"#.###.#.###.#..#...#.",
+	"#.###.#...#.#####..#.",
+	"#.###.#.#.###.#####.#",
+	"#.....#..##....#...#.",
+	"#######.#####..######",
+}
+
+func TestGolden(t *testing.T) {
+	c, err := Encode([]byte("DineTogether"), M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for y := 0; y < c.Size; y++ {
+		var row strings.Builder
+		for x := 0; x < c.Size; x++ {
+			if c.Black(x, y) {
+				row.WriteByte('#')
+			} else {
+				row.WriteByte('.')
+			}
+		}
+		got = append(got, row.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(golden, "\n") {
+		t.Fatalf("矩阵与 golden 不一致:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	n := dataCodewords(maxVersion, H) - 3
+	if _, err := Encode(make([]byte, n), H); err != nil {
+		t.Fatalf("%d 字节: %v", n, err)
+	}
+	if _, err := Encode(make([]byte, n+1), H); !errors.Is(err, ErrTooLong) {
+		t.Fatalf("%d 字节: err = %v, 期望 ErrTooLong", n+1, err)
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	c, err := Encode([]byte("DineTogether"), M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := c.WritePNG(&buf, 3); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := (c.Size + 8) * 3; img.Bounds().Dx() != n || img.Bounds().Dy() != n {
+		t.Fatalf("图像尺寸 = %v, 期望 %d", img.Bounds(), n)
+	}
+	// 左上角定位图形的第一个模块位于留白之后
+	for _, p := range []struct {
+		x, y int
+		dark bool
+	}{{0, 0, false}, {11, 11, false}, {12, 12, true}, {14, 14, true}} {
+		r, _, _, _ := img.At(p.x, p.y).RGBA()
+		if dark := r == 0; dark != p.dark {
+			t.Errorf("像素 (%d, %d) 深色 = %v, 期望 %v", p.x, p.y, dark, p.dark)
+		}
+	}
+}
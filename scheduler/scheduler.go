@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"DineTogether/events"
+	"DineTogether/models"
+	"DineTogether/store"
+	"context"
+	"log/slog"
+	"time"
+)
+
+const DefaultInterval = 10 * time.Second
+
+// Run 每隔 interval 锁定一次已到截止时间的 Party，直到 ctx 取消。
+// 截止时间在下单时已精确校验，这里只负责把状态推进为 locked。
+func Run(ctx context.Context, parties store.PartyStore, hub *events.Hub, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		lockDue(parties, hub, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func lockDue(parties store.PartyStore, hub *events.Hub, now time.Time) {
+	ids, err := parties.LockDue(now)
+	if err != nil {
+		slog.Error("自动锁定到期 Party 失败", "err", err)
+		return
+	}
+	for _, id := range ids {
+		slog.Info("Party 已到截止时间，自动锁定", "party_id", id)
+		hub.Publish(id, events.StateChanged, map[string]string{"from": models.PartyOpen, "state": models.PartyLocked})
+	}
+}
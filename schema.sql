@@ -1,8 +1,13 @@
+-- 此文件由 `go generate` 根据 migrations/sql 生成，请勿手动修改。
+
 CREATE TABLE IF NOT EXISTS users (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     username TEXT NOT NULL UNIQUE,
     password TEXT NOT NULL,
-    role TEXT NOT NULL DEFAULT 'guest'
+    role TEXT NOT NULL DEFAULT 'guest',
+    allergies TEXT NOT NULL DEFAULT '[]',
+    diets TEXT NOT NULL DEFAULT '[]',
+    diet_strict INTEGER NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS menus (
@@ -10,15 +15,17 @@ CREATE TABLE IF NOT EXISTS menus (
     name TEXT NOT NULL,
     description TEXT DEFAULT '',
     energy_cost INTEGER NOT NULL CHECK(energy_cost > 0),
-    image_urls TEXT DEFAULT '[]'
-);
-
-CREATE TABLE IF NOT EXISTS parties (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    name TEXT NOT NULL UNIQUE,
-    password TEXT NOT NULL,
-    energy_left INTEGER NOT NULL CHECK(energy_left >= 0),
-    is_active INTEGER NOT NULL DEFAULT 1
+    image_urls TEXT DEFAULT '[]',
+    modifiers TEXT NOT NULL DEFAULT '[]',
+    category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    allergens TEXT NOT NULL DEFAULT '[]',
+    available INTEGER NOT NULL DEFAULT 1,
+    available_from TEXT NOT NULL DEFAULT '',
+    available_until TEXT NOT NULL DEFAULT '',
+    daily_stock INTEGER CHECK(daily_stock >= 0),
+    party_stock INTEGER CHECK(party_stock >= 0),
+    restaurant_id INTEGER REFERENCES restaurants(id) ON DELETE SET NULL,
+    deleted_at DATETIME
 );
 
 CREATE TABLE IF NOT EXISTS party_members (
@@ -26,6 +33,7 @@ CREATE TABLE IF NOT EXISTS party_members (
     party_id INTEGER NOT NULL,
     user_id INTEGER NOT NULL,
     joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    budget INTEGER CHECK(budget >= 0),
     UNIQUE(party_id, user_id),
     FOREIGN KEY (party_id) REFERENCES parties(id) ON DELETE CASCADE,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
@@ -37,7 +45,152 @@ CREATE TABLE IF NOT EXISTS orders (
     user_id INTEGER NOT NULL,
     menu_id INTEGER NOT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK(quantity > 0),
+    note TEXT NOT NULL DEFAULT '',
+    modifiers TEXT NOT NULL DEFAULT '[]',
+    unit_cost INTEGER NOT NULL DEFAULT 0,
     FOREIGN KEY (party_id) REFERENCES parties(id) ON DELETE CASCADE,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
     FOREIGN KEY (menu_id) REFERENCES menus(id) ON DELETE CASCADE
 );
+
+CREATE TABLE IF NOT EXISTS party_state_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    party_id INTEGER NOT NULL,
+    from_state TEXT NOT NULL DEFAULT '',
+    to_state TEXT NOT NULL,
+    changed_by INTEGER,
+    changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (party_id) REFERENCES parties(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_party_state_history_party ON party_state_history(party_id, id);
+
+CREATE TABLE IF NOT EXISTS categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    sort_order INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS menu_tags (
+    menu_id INTEGER NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (menu_id, tag),
+    FOREIGN KEY (menu_id) REFERENCES menus(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_menus_category ON menus(category_id);
+
+CREATE INDEX IF NOT EXISTS idx_menu_tags_tag ON menu_tags(tag);
+
+CREATE INDEX IF NOT EXISTS idx_orders_menu_created ON orders(menu_id, created_at);
+
+CREATE TABLE IF NOT EXISTS collections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS collection_menus (
+    collection_id INTEGER NOT NULL,
+    menu_id INTEGER NOT NULL,
+    PRIMARY KEY (collection_id, menu_id),
+    FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (menu_id) REFERENCES menus(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_menus_menu ON collection_menus(menu_id);
+
+CREATE TABLE IF NOT EXISTS restaurants (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    contact TEXT NOT NULL DEFAULT '',
+    address TEXT NOT NULL DEFAULT '',
+    opening_hours TEXT NOT NULL DEFAULT '',
+    min_order INTEGER NOT NULL DEFAULT 0,
+    delivery_fee INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_menus_restaurant ON menus(restaurant_id);
+
+CREATE TABLE IF NOT EXISTS party_restaurants (
+    party_id INTEGER NOT NULL,
+    restaurant_id INTEGER NOT NULL,
+    PRIMARY KEY (party_id, restaurant_id),
+    FOREIGN KEY (party_id) REFERENCES parties(id) ON DELETE CASCADE,
+    FOREIGN KEY (restaurant_id) REFERENCES restaurants(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_created ON orders(user_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_orders_created ON orders(created_at);
+
+CREATE INDEX IF NOT EXISTS idx_party_members_user_joined ON party_members(user_id, joined_at);
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id INTEGER,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL DEFAULT '',
+    target_id TEXT NOT NULL DEFAULT '',
+    before TEXT,
+    after TEXT,
+    ip TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created ON audit_events(created_at);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id, created_at);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER,
+    data BLOB NOT NULL,
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_last_seen ON sessions(last_seen_at);
+
+CREATE TABLE IF NOT EXISTS party_invites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    party_id INTEGER NOT NULL,
+    created_by INTEGER,
+    expires_at DATETIME NOT NULL,
+    max_uses INTEGER CHECK(max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0,
+    revoked_at DATETIME,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (party_id) REFERENCES parties(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_party_invites_party ON party_invites(party_id);
+
+CREATE TABLE IF NOT EXISTS "parties" (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    password TEXT NOT NULL,
+    energy_left INTEGER NOT NULL CHECK(energy_left >= 0),
+    state TEXT NOT NULL DEFAULT 'open' CHECK(state IN ('draft', 'open', 'locked', 'submitted', 'archived')),
+    opens_at DATETIME,
+    closes_at DATETIME,
+    budget_mode TEXT NOT NULL DEFAULT 'shared' CHECK(budget_mode IN ('shared', 'fixed', 'split')),
+    member_budget INTEGER NOT NULL DEFAULT 0 CHECK(member_budget >= 0),
+    collection_id INTEGER REFERENCES collections(id) ON DELETE SET NULL,
+    deleted_at DATETIME,
+    owner_id INTEGER REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_parties_state_closes_at ON parties(state, closes_at);
+
+CREATE INDEX IF NOT EXISTS idx_parties_owner ON parties(owner_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_parties_name_active ON parties(name) WHERE deleted_at IS NULL;
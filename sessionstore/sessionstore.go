@@ -0,0 +1,239 @@
+// Package sessionstore 提供服务端保存的 gin session：cookie 中只有签名后的随机令牌，
+// 会话数据保存在 store.SessionStore 中，可按用户列出和吊销，并支持空闲超时与绝对超时。
+package sessionstore
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+)
+
+const (
+	DefaultIdleTimeout     = 2 * time.Hour
+	DefaultAbsoluteTimeout = 7 * 24 * time.Hour
+	// 最近活动时间的刷新间隔，避免每个请求都写库
+	touchInterval = time.Minute
+)
+
+// Store 实现 sessions.Store。
+type Store struct {
+	sessions        store.SessionStore
+	codecs          []securecookie.Codec
+	options         *gsessions.Options
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+}
+
+// New 创建 Store，keyPairs 用于签名 cookie 中的令牌；超时不大于 0 时使用默认值。
+func New(sessions store.SessionStore, idleTimeout, absoluteTimeout time.Duration, keyPairs ...[]byte) *Store {
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleTimeout
+	}
+	if absoluteTimeout <= 0 {
+		absoluteTimeout = DefaultAbsoluteTimeout
+	}
+	codecs := securecookie.CodecsFromPairs(keyPairs...)
+	// 签名的有效期与绝对超时一致，过期由服务端判断
+	for _, codec := range codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(int(absoluteTimeout.Seconds()))
+		}
+	}
+	return &Store{
+		sessions:        sessions,
+		codecs:          codecs,
+		options:         &gsessions.Options{Path: "/"},
+		idleTimeout:     idleTimeout,
+		absoluteTimeout: absoluteTimeout,
+	}
+}
+
+// Key 返回令牌对应的存储 ID，数据库中只保存令牌的哈希。
+func Key(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Store) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+}
+
+func (s *Store) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+// New 读取 cookie 对应的会话；令牌无效、会话已吊销或超时时返回新的空会话。
+func (s *Store) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	opts := *s.options
+	session.Options = &opts
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	var token string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &token, s.codecs...); err != nil {
+		return session, nil
+	}
+	row, err := s.sessions.Get(Key(token))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return session, nil
+		}
+		return session, err
+	}
+	now := time.Now()
+	if now.Sub(row.LastSeenAt) > s.idleTimeout || now.Sub(row.CreatedAt) > s.absoluteTimeout {
+		s.sessions.Delete(row.ID)
+		return session, nil
+	}
+	if err := gob.NewDecoder(bytes.NewReader(row.Data)).Decode(&session.Values); err != nil {
+		s.sessions.Delete(row.ID)
+		return session, nil
+	}
+	if now.Sub(row.LastSeenAt) > touchInterval {
+		s.sessions.Touch(row.ID, now)
+	}
+	session.ID = token
+	session.IsNew = false
+	return session, nil
+}
+
+// Save 保存会话。清空或设置 MaxAge < 0 时删除会话；登录用户变化时更换令牌，防止会话固定；
+// 请求期间已被吊销的会话不会被重新写入。
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	if session.Options.MaxAge < 0 || len(session.Values) == 0 {
+		if session.ID != "" {
+			if err := s.sessions.Delete(Key(session.ID)); err != nil && !errors.Is(err, store.ErrNotFound) {
+				return err
+			}
+			session.ID = ""
+		}
+		http.SetCookie(w, gsessions.NewCookie(session.Name(), "", expired(session.Options)))
+		return nil
+	}
+
+	userID := sessionUserID(session)
+	now := time.Now().UTC()
+	row := &models.Session{UserID: userID, CreatedAt: now}
+	if session.ID != "" {
+		old, err := s.sessions.Get(Key(session.ID))
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			if !session.IsNew {
+				session.ID = ""
+				http.SetCookie(w, gsessions.NewCookie(session.Name(), "", expired(session.Options)))
+				return nil
+			}
+		case err != nil:
+			return err
+		case !sameUser(old.UserID, userID):
+			if err := s.sessions.Delete(old.ID); err != nil && !errors.Is(err, store.ErrNotFound) {
+				return err
+			}
+			session.ID = ""
+		default:
+			row.CreatedAt = old.CreatedAt
+		}
+	}
+	if session.ID == "" {
+		token, err := newToken()
+		if err != nil {
+			return err
+		}
+		session.ID = token
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(session.Values); err != nil {
+		return err
+	}
+	row.ID = Key(session.ID)
+	row.Data = buf.Bytes()
+	row.IP = remoteIP(r)
+	row.UserAgent = r.UserAgent()
+	row.LastSeenAt = now
+	if err := s.sessions.Save(row); err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, session.Options))
+	session.IsNew = false
+	return nil
+}
+
+// Cleanup 每隔 interval 删除已超时的会话，直到 ctx 结束。
+func (s *Store) Cleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			n, err := s.sessions.DeleteExpired(now.Add(-s.idleTimeout), now.Add(-s.absoluteTimeout))
+			if err != nil {
+				slog.Error("清理过期会话失败", "err", err)
+			} else if n > 0 {
+				slog.Info("已清理过期会话", "count", n)
+			}
+		}
+	}
+}
+
+func sessionUserID(session *gsessions.Session) *int {
+	if id, ok := session.Values["user_id"].(int); ok && id > 0 {
+		return &id
+	}
+	return nil
+}
+
+func sameUser(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func expired(options *gsessions.Options) *gsessions.Options {
+	opts := *options
+	opts.MaxAge = -1
+	return &opts
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
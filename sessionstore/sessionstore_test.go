@@ -0,0 +1,226 @@
+package sessionstore
+
+import (
+	"DineTogether/store"
+	"DineTogether/store/memory"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gsessions "github.com/gorilla/sessions"
+)
+
+const cookieName = "session"
+
+func newTestStore() (*Store, store.SessionStore) {
+	sessions := memory.New().Sessions
+	return New(sessions, time.Hour, 24*time.Hour, []byte("secret")), sessions
+}
+
+// load 用 cookie 读取会话，cookie 为 nil 时模拟首次访问。
+func load(t *testing.T, s *Store, cookie *http.Cookie) (*gsessions.Session, *http.Request) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	session, err := s.New(r, cookieName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session, r
+}
+
+// save 保存会话并返回响应中的 cookie。
+func save(t *testing.T, s *Store, r *http.Request, session *gsessions.Session) *http.Cookie {
+	t.Helper()
+	w := httptest.NewRecorder()
+	if err := s.Save(r, w, session); err != nil {
+		t.Fatal(err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("响应中有 %d 个 cookie", len(cookies))
+	}
+	return cookies[0]
+}
+
+// backdate 修改会话的创建与最近访问时间。SessionStore.Save 覆盖时保留创建时间，因此先删除再写入。
+func backdate(t *testing.T, sessions store.SessionStore, token string, created, lastSeen time.Time) {
+	t.Helper()
+	row, err := sessions.Get(Key(token))
+	if err != nil {
+		t.Fatal(err)
+	}
+	row.CreatedAt = created
+	row.LastSeenAt = lastSeen
+	if err := sessions.Delete(row.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions.Save(row); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// login 创建属于 userID 的会话，返回 cookie 与令牌。
+func login(t *testing.T, s *Store, userID int) (*http.Cookie, string) {
+	t.Helper()
+	session, r := load(t, s, nil)
+	session.Values["user_id"] = userID
+	cookie := save(t, s, r, session)
+	return cookie, session.ID
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	s, sessions := newTestStore()
+	cookie, token := login(t, s, 1)
+
+	session, _ := load(t, s, cookie)
+	if session.IsNew || session.ID != token || session.Values["user_id"] != 1 {
+		t.Fatalf("会话 = %+v", session)
+	}
+	// 数据库中只保存令牌的哈希
+	if _, err := sessions.Get(token); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("按明文令牌查询: %v", err)
+	}
+	row, err := sessions.Get(Key(token))
+	if err != nil || row.UserID == nil || *row.UserID != 1 {
+		t.Fatalf("Get = %+v, %v", row, err)
+	}
+
+	// 篡改过的 cookie 视为新会话
+	forged := *cookie
+	forged.Value = cookie.Value[:len(cookie.Value)-2] + "xx"
+	if session, _ := load(t, s, &forged); !session.IsNew || len(session.Values) != 0 {
+		t.Fatalf("篡改的 cookie 读到了会话: %+v", session)
+	}
+}
+
+func TestSessionIdleTimeout(t *testing.T) {
+	s, sessions := newTestStore()
+	cookie, token := login(t, s, 1)
+
+	now := time.Now()
+	backdate(t, sessions, token, now.Add(-2*time.Hour), now.Add(-time.Hour-time.Minute))
+
+	session, _ := load(t, s, cookie)
+	if !session.IsNew || len(session.Values) != 0 {
+		t.Fatalf("空闲超时的会话仍可读取: %+v", session)
+	}
+	if _, err := sessions.Get(Key(token)); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("空闲超时的会话未删除: %v", err)
+	}
+}
+
+func TestSessionAbsoluteTimeout(t *testing.T) {
+	s, sessions := newTestStore()
+	cookie, token := login(t, s, 1)
+	now := time.Now()
+	created := now.Add(-23 * time.Hour).UTC().Truncate(time.Second)
+	backdate(t, sessions, token, created, now)
+
+	// 持续活动的会话再次保存时保留创建时间，绝对超时不会被顺延
+	session, r := load(t, s, cookie)
+	if session.IsNew {
+		t.Fatal("未超时的会话被丢弃")
+	}
+	session.Values["party_id"] = 2
+	cookie = save(t, s, r, session)
+	row, err := sessions.Get(Key(token))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !row.CreatedAt.Equal(created) {
+		t.Fatalf("CreatedAt = %v, 期望 %v", row.CreatedAt, created)
+	}
+
+	// 仍在活动但超过绝对超时
+	backdate(t, sessions, token, now.Add(-24*time.Hour-time.Minute), now)
+	if session, _ := load(t, s, cookie); !session.IsNew || len(session.Values) != 0 {
+		t.Fatalf("超过绝对超时的会话仍可读取: %+v", session)
+	}
+	if _, err := sessions.Get(Key(token)); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("超过绝对超时的会话未删除: %v", err)
+	}
+}
+
+func TestRevokedSessionNotResaved(t *testing.T) {
+	s, sessions := newTestStore()
+	cookie, token := login(t, s, 1)
+
+	// 请求读取会话后，会话在其他请求中被吊销
+	session, r := load(t, s, cookie)
+	if session.IsNew {
+		t.Fatal("会话未读取到")
+	}
+	if err := sessions.Delete(Key(token)); err != nil {
+		t.Fatal(err)
+	}
+	session.Values["party_id"] = 2
+	cleared := save(t, s, r, session)
+	if cleared.MaxAge >= 0 || cleared.Value != "" {
+		t.Fatalf("被吊销的会话未清除 cookie: %+v", cleared)
+	}
+	if _, err := sessions.Get(Key(token)); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("被吊销的会话被重新写入: %v", err)
+	}
+	if list, err := sessions.ListByUser(1); err != nil || len(list) != 0 {
+		t.Fatalf("ListByUser = %+v, %v", list, err)
+	}
+	if session, _ := load(t, s, cookie); !session.IsNew {
+		t.Fatal("被吊销的会话仍可读取")
+	}
+}
+
+func TestTokenRotatesWhenUserChanges(t *testing.T) {
+	s, sessions := newTestStore()
+
+	// 未登录时已有会话（例如记录了跳转地址）
+	session, r := load(t, s, nil)
+	session.Values["redirect"] = "/order"
+	cookie := save(t, s, r, session)
+	anonymous := session.ID
+
+	// 登录后令牌更换，旧令牌失效
+	session, r = load(t, s, cookie)
+	if session.IsNew || session.ID != anonymous {
+		t.Fatalf("匿名会话未读取到: %+v", session)
+	}
+	session.Values["user_id"] = 1
+	cookie = save(t, s, r, session)
+	if session.ID == anonymous {
+		t.Fatal("登录后令牌未更换")
+	}
+	if _, err := sessions.Get(Key(anonymous)); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("旧令牌未删除: %v", err)
+	}
+	loggedIn := session.ID
+	session, r = load(t, s, cookie)
+	if session.ID != loggedIn || session.Values["redirect"] != "/order" {
+		t.Fatalf("登录后的会话 = %+v", session)
+	}
+
+	// 同一用户再次保存时保留令牌
+	session.Values["party_id"] = 2
+	cookie = save(t, s, r, session)
+	if session.ID != loggedIn {
+		t.Fatal("同一用户保存时令牌被更换")
+	}
+
+	// 切换为其他用户时再次更换
+	session, r = load(t, s, cookie)
+	session.Values["user_id"] = 2
+	save(t, s, r, session)
+	if session.ID == loggedIn {
+		t.Fatal("切换用户后令牌未更换")
+	}
+	row, err := sessions.Get(Key(session.ID))
+	if err != nil || row.UserID == nil || *row.UserID != 2 {
+		t.Fatalf("Get = %+v, %v", row, err)
+	}
+	if list, err := sessions.ListByUser(1); err != nil || len(list) != 0 {
+		t.Fatalf("原用户仍有会话: %+v, %v", list, err)
+	}
+}
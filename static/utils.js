@@ -92,11 +92,12 @@ async function deleteImage(imageUrl) {
     }
 }
 
-async function checkAuth(redirectTo, requiredRole = null) {
+// checkAuth 确认登录状态，requiredPermission 非空时还要求当前角色拥有该权限（如 'menu:write'）。
+async function checkAuth(redirectTo, requiredPermission = null) {
     const userId = localStorage.getItem('user_id');
-    const role = localStorage.getItem('role') || 'guest';
 
     if (userId) {
+        let auth;
         try {
             const resp = await fetch('/api/check-auth', { credentials: 'include' });
             if (!resp.ok) {
@@ -107,18 +108,21 @@ async function checkAuth(redirectTo, requiredRole = null) {
                 }
                 return null;
             }
+            auth = await resp.json();
         } catch {
             if (redirectTo) {
                 location.href = redirectTo;
             }
             return null;
         }
-        if (requiredRole && role !== requiredRole) {
-            showMessage('error-message', '需要管理员权限！');
+        localStorage.setItem('role', auth.role);
+        const permissions = auth.permissions || [];
+        if (requiredPermission && !permissions.includes(requiredPermission)) {
+            showMessage('error-message', '权限不足！');
             setTimeout(() => location.href = redirectTo, 1000);
             return null;
         }
-        return { userId, role };
+        return { userId: auth.user_id, role: auth.role, permissions, can: p => permissions.includes(p) };
     }
 
     if (redirectTo) {
@@ -127,6 +131,26 @@ async function checkAuth(redirectTo, requiredRole = null) {
     return null;
 }
 
+// nextPath 读取地址栏中的 next 参数，只接受站内路径，避免被用作跳转到外部站点。
+function nextPath(fallback) {
+    const next = new URLSearchParams(location.search).get('next');
+    if (next && next.startsWith('/') && !next.startsWith('//') && !next.startsWith('/\\')) {
+        return next;
+    }
+    return fallback;
+}
+
+// withNext 在跳转地址后附带当前页面的 next 参数。
+function withNext(path) {
+    const next = nextPath(null);
+    return next ? `${path}?next=${encodeURIComponent(next)}` : path;
+}
+
+// escapeHTML 转义用户输入，用于拼接 innerHTML 的文本与属性值。
+function escapeHTML(s) {
+    return String(s ?? '').replace(/[&<>"']/g, c => ({ '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;' })[c]);
+}
+
 function showMessage(elementId, message, isError = true) {
     const errorDiv = document.getElementById(elementId);
     if (errorDiv) {
@@ -142,3 +166,154 @@ function showMessage(elementId, message, isError = true) {
         console.error('消息显示失败：未找到元素', elementId);
     }
 }
+
+// 菜品选项文本格式：每行一个，"名称" 或 "名称:加价"
+function parseModifiers(text) {
+    return text.split('\n')
+        .map(line => line.trim())
+        .filter(line => line)
+        .map(line => {
+            const idx = line.search(/[:：]/);
+            if (idx < 0) return { name: line, surcharge: 0 };
+            return { name: line.slice(0, idx).trim(), surcharge: parseInt(line.slice(idx + 1)) || 0 };
+        });
+}
+
+function formatModifiers(modifiers) {
+    return (modifiers || []).map(m => m.surcharge ? `${m.name}:${m.surcharge}` : m.name).join('\n');
+}
+
+const PARTY_STATES = {
+    draft: { label: '草稿', color: 'text-gray-500', next: 'open', action: '开放点餐' },
+    open: { label: '点餐中', color: 'text-green-600', next: 'locked', action: '锁定' },
+    locked: { label: '已锁定', color: 'text-yellow-600', next: 'submitted', action: '提交' },
+    submitted: { label: '已提交', color: 'text-blue-600', next: 'archived', action: '归档' },
+    archived: { label: '已归档', color: 'text-red-600', next: null, action: null }
+};
+
+function partyStateLabel(state) {
+    return (PARTY_STATES[state] || { label: state }).label;
+}
+
+const ROLE_LABELS = {
+    admin: '管理员', organizer: '组织者', menu_editor: '菜单编辑', viewer: '查看者', guest: '普通用户'
+};
+
+const ALLERGEN_LABELS = {
+    peanut: '花生', tree_nut: '坚果', gluten: '麸质', dairy: '乳制品', egg: '蛋类', soy: '大豆',
+    fish: '鱼类', shellfish: '甲壳类', sesame: '芝麻', meat: '肉类', pork: '猪肉', alcohol: '酒精'
+};
+
+const DIET_LABELS = { vegetarian: '素食', vegan: '纯素', halal: '清真' };
+
+function allergenLabels(list) {
+    return (list || []).map(a => ALLERGEN_LABELS[a] || a).join('、');
+}
+
+// 渲染一组复选框，选中值在 selected 中
+function renderCheckboxes(container, name, labels, selected = []) {
+    container.innerHTML = Object.entries(labels).map(([value, label]) => `
+        <label class="text-sm text-gray-700 mr-3"><input type="checkbox" name="${name}" value="${value}" ${selected.includes(value) ? 'checked' : ''}> ${label}</label>
+    `).join('');
+}
+
+function checkedValues(name) {
+    return Array.from(document.querySelectorAll(`input[name="${name}"]:checked`)).map(el => el.value);
+}
+
+// 限量输入框留空表示不限量
+function parseStock(value) {
+    const n = parseInt(value);
+    return isNaN(n) ? null : n;
+}
+
+// 菜品供应状态：是否可点及展示文字，供应时段以服务器判断为准
+function menuAvailability(menu) {
+    if (!menu.available) return { orderable: false, label: '已下架' };
+    if (menu.daily_stock != null && menu.sold_today >= menu.daily_stock) return { orderable: false, label: '今日已售罄' };
+    const parts = [];
+    if (menu.available_from) parts.push(`供应时间 ${menu.available_from}-${menu.available_until}`);
+    if (menu.daily_stock != null) parts.push(`今日剩余 ${menu.daily_stock - menu.sold_today}`);
+    if (menu.party_stock != null) parts.push(`每个 Party 限 ${menu.party_stock} 份`);
+    return { orderable: true, label: parts.join('，') };
+}
+
+// datetime-local 输入框与 ISO 时间互转，空值对应 null
+function toDateTimeInput(iso) {
+    if (!iso) return '';
+    const d = new Date(iso);
+    d.setMinutes(d.getMinutes() - d.getTimezoneOffset());
+    return d.toISOString().slice(0, 16);
+}
+
+function fromDateTimeInput(value) {
+    return value ? new Date(value).toISOString() : null;
+}
+
+function formatCountdown(ms) {
+    const total = Math.max(0, Math.floor(ms / 1000));
+    const h = Math.floor(total / 3600);
+    const m = Math.floor(total % 3600 / 60);
+    const s = total % 60;
+    const pad = n => String(n).padStart(2, '0');
+    return h > 0 ? `${h}:${pad(m)}:${pad(s)}` : `${pad(m)}:${pad(s)}`;
+}
+
+// 标签输入格式：以逗号或空格分隔
+function parseTags(text) {
+    return text.split(/[,，\s]+/).map(t => t.trim()).filter(t => t);
+}
+
+async function loadCategoryOptions(select, selectedId, emptyLabel = '未分类') {
+    const result = await makeRequest('/categories');
+    select.innerHTML = `<option value="">${emptyLabel}</option>` + (result.categories || [])
+        .map(c => `<option value="${c.id}" ${c.id === selectedId ? 'selected' : ''}>${c.name}</option>`)
+        .join('');
+}
+
+async function loadCollectionOptions(select, selectedId) {
+    const result = await makeRequest('/collections');
+    select.innerHTML = '<option value="">全部菜品</option>' + (result.collections || [])
+        .map(c => `<option value="${c.id}" ${c.id === selectedId ? 'selected' : ''}>${c.name}</option>`)
+        .join('');
+}
+
+async function loadRestaurantOptions(select, selectedId, emptyLabel = '未关联餐厅') {
+    const result = await makeRequest('/restaurants');
+    select.innerHTML = `<option value="">${emptyLabel}</option>` + (result.restaurants || [])
+        .map(r => `<option value="${r.id}" ${r.id === selectedId ? 'selected' : ''}>${r.name}</option>`)
+        .join('');
+}
+
+// 渲染餐厅多选框，返回的勾选值通过 checkedValues('restaurant') 读取
+async function loadRestaurantCheckboxes(container, selected = []) {
+    const result = await makeRequest('/restaurants');
+    const labels = {};
+    (result.restaurants || []).forEach(r => labels[r.id] = r.name);
+    if (Object.keys(labels).length === 0) {
+        container.innerHTML = '<span class="text-sm text-gray-500">暂无餐厅</span>';
+        return;
+    }
+    renderCheckboxes(container, 'restaurant', labels, selected.map(String));
+}
+
+// 历史记录查询参数：from/to 为 YYYY-MM-DD 日期，可为空
+function historyQuery(from, to, page) {
+    const params = new URLSearchParams({ page });
+    if (from) params.set('from', from);
+    if (to) params.set('to', to);
+    return params.toString();
+}
+
+// 渲染分页按钮，点击时以目标页码调用 onPage
+function renderPager(container, page, pageSize, total, onPage) {
+    const pages = Math.max(1, Math.ceil(total / pageSize));
+    container.innerHTML = `
+        <button class="btn btn-secondary" style="width:auto;padding:8px 14px" ${page <= 1 ? 'disabled' : ''}>上一页</button>
+        <span class="text-sm text-gray-600">第 ${page} / ${pages} 页，共 ${total} 条</span>
+        <button class="btn btn-secondary" style="width:auto;padding:8px 14px" ${page >= pages ? 'disabled' : ''}>下一页</button>
+    `;
+    const [prev, next] = container.querySelectorAll('button');
+    prev.onclick = () => onPage(page - 1);
+    next.onclick = () => onPage(page + 1);
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+type auditStore struct {
+	d *db
+}
+
+func copyJSON(raw json.RawMessage) json.RawMessage {
+	if raw == nil {
+		return nil
+	}
+	return append(json.RawMessage{}, raw...)
+}
+
+func (s *auditStore) Record(event *models.AuditEvent) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	e := *event
+	e.ID = s.d.newID("audit_events")
+	e.ActorID = copyInt(event.ActorID)
+	e.ActorName = ""
+	e.Before = copyJSON(event.Before)
+	e.After = copyJSON(event.After)
+	e.CreatedAt = time.Now().UTC()
+	s.d.audit = append(s.d.audit, e)
+	return nil
+}
+
+func (s *auditStore) List(filter store.AuditFilter) ([]models.AuditEvent, int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	events := make([]models.AuditEvent, 0)
+	for _, e := range slices.Backward(s.d.audit) {
+		if filter.ActorID > 0 && (e.ActorID == nil || *e.ActorID != filter.ActorID) {
+			continue
+		}
+		if (filter.Action != "" && e.Action != filter.Action) ||
+			(filter.TargetType != "" && e.TargetType != filter.TargetType) ||
+			(filter.TargetID != "" && e.TargetID != filter.TargetID) ||
+			!inRange(e.CreatedAt, filter.HistoryFilter) {
+			continue
+		}
+		if e.ActorID != nil {
+			e.ActorName = s.d.users[*e.ActorID].Username
+		}
+		e.ActorID = copyInt(e.ActorID)
+		e.Before = copyJSON(e.Before)
+		e.After = copyJSON(e.After)
+		events = append(events, e)
+	}
+	total := len(events)
+	return paginate(events, filter.HistoryFilter), total, nil
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"sort"
+)
+
+type categoryStore struct {
+	d *db
+}
+
+func (s *categoryStore) Create(category *models.Category) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	for _, c := range s.d.categories {
+		if c.Name == category.Name {
+			return 0, store.ErrDuplicate
+		}
+	}
+	c := *category
+	c.ID = s.d.newID("categories")
+	s.d.categories[c.ID] = c
+	return c.ID, nil
+}
+
+func (s *categoryStore) Get(id int) (*models.Category, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	c, ok := s.d.categories[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &c, nil
+}
+
+func (s *categoryStore) List() ([]models.Category, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	categories := make([]models.Category, 0, len(s.d.categories))
+	for _, c := range s.d.categories {
+		categories = append(categories, c)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].SortOrder != categories[j].SortOrder {
+			return categories[i].SortOrder < categories[j].SortOrder
+		}
+		return categories[i].ID < categories[j].ID
+	})
+	return categories, nil
+}
+
+func (s *categoryStore) Update(category *models.Category) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, ok := s.d.categories[category.ID]; !ok {
+		return store.ErrNotFound
+	}
+	for _, c := range s.d.categories {
+		if c.ID != category.ID && c.Name == category.Name {
+			return store.ErrDuplicate
+		}
+	}
+	s.d.categories[category.ID] = *category
+	return nil
+}
+
+func (s *categoryStore) Delete(id int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, ok := s.d.categories[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.d.categories, id)
+	for menuID, m := range s.d.menus {
+		if m.CategoryID != nil && *m.CategoryID == id {
+			m.CategoryID = nil
+			s.d.menus[menuID] = m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"slices"
+	"sort"
+)
+
+type collectionStore struct {
+	d *db
+}
+
+func (s *collectionStore) Create(collection *models.Collection) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if s.d.collectionNameTaken(0, collection.Name) {
+		return 0, store.ErrDuplicate
+	}
+	menuIDs, err := s.d.collectionMenus(collection.MenuIDs)
+	if err != nil {
+		return 0, err
+	}
+	c := *collection
+	c.ID = s.d.newID("collections")
+	c.MenuIDs = menuIDs
+	s.d.collections[c.ID] = c
+	return c.ID, nil
+}
+
+func (s *collectionStore) Get(id int) (*models.Collection, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	c, ok := s.d.collections[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	c.MenuIDs = copyInts(c.MenuIDs)
+	return &c, nil
+}
+
+func (s *collectionStore) List() ([]models.Collection, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	collections := make([]models.Collection, 0, len(s.d.collections))
+	for _, c := range s.d.collections {
+		c.MenuIDs = copyInts(c.MenuIDs)
+		collections = append(collections, c)
+	}
+	sort.Slice(collections, func(i, j int) bool { return collections[i].ID < collections[j].ID })
+	return collections, nil
+}
+
+func (s *collectionStore) Update(collection *models.Collection) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, ok := s.d.collections[collection.ID]; !ok {
+		return store.ErrNotFound
+	}
+	if s.d.collectionNameTaken(collection.ID, collection.Name) {
+		return store.ErrDuplicate
+	}
+	menuIDs, err := s.d.collectionMenus(collection.MenuIDs)
+	if err != nil {
+		return err
+	}
+	c := *collection
+	c.MenuIDs = menuIDs
+	s.d.collections[c.ID] = c
+	return nil
+}
+
+func (s *collectionStore) Delete(id int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, ok := s.d.collections[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.d.collections, id)
+	for partyID, p := range s.d.parties {
+		if p.CollectionID != nil && *p.CollectionID == id {
+			p.CollectionID = nil
+			s.d.parties[partyID] = p
+		}
+	}
+	return nil
+}
+
+func (s *collectionStore) Clone(id int, name string) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	source, ok := s.d.collections[id]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	if s.d.collectionNameTaken(0, name) {
+		return 0, store.ErrDuplicate
+	}
+	c := models.Collection{
+		ID:          s.d.newID("collections"),
+		Name:        name,
+		Description: source.Description,
+		MenuIDs:     copyInts(source.MenuIDs),
+	}
+	s.d.collections[c.ID] = c
+	return c.ID, nil
+}
+
+func (d *db) collectionExists(id *int) bool {
+	if id == nil {
+		return true
+	}
+	_, ok := d.collections[*id]
+	return ok
+}
+
+func (d *db) collectionNameTaken(id int, name string) bool {
+	for _, c := range d.collections {
+		if c.ID != id && c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// collectionMenus 校验菜品均存在，并返回去重排序后的菜品 ID，与 sqlite 实现一致。
+func (d *db) collectionMenus(ids []int) ([]int, error) {
+	menuIDs := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := d.menu(id); !ok {
+			return nil, store.ErrInvalidMenu
+		}
+		if !slices.Contains(menuIDs, id) {
+			menuIDs = append(menuIDs, id)
+		}
+	}
+	slices.Sort(menuIDs)
+	return menuIDs, nil
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"sort"
+	"time"
+)
+
+type inviteStore struct {
+	d *db
+}
+
+func copyInvite(invite models.PartyInvite) models.PartyInvite {
+	invite.CreatedBy = copyInt(invite.CreatedBy)
+	invite.MaxUses = copyInt(invite.MaxUses)
+	invite.RevokedAt = copyTime(invite.RevokedAt)
+	return invite
+}
+
+func (s *inviteStore) Create(invite *models.PartyInvite) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	saved := copyInvite(*invite)
+	saved.ID = s.d.newID("party_invites")
+	saved.Uses = 0
+	saved.RevokedAt = nil
+	saved.ExpiresAt = invite.ExpiresAt.UTC().Truncate(time.Second)
+	saved.CreatedAt = invite.CreatedAt.UTC().Truncate(time.Second)
+	s.d.invites[saved.ID] = saved
+	return saved.ID, nil
+}
+
+func (s *inviteStore) Get(id int) (*models.PartyInvite, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	invite, ok := s.d.invites[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	invite = copyInvite(invite)
+	return &invite, nil
+}
+
+func (s *inviteStore) ListByParty(partyID int) ([]models.PartyInvite, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	invites := make([]models.PartyInvite, 0)
+	for _, invite := range s.d.invites {
+		if invite.PartyID == partyID {
+			invites = append(invites, copyInvite(invite))
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool {
+		if !invites[i].CreatedAt.Equal(invites[j].CreatedAt) {
+			return invites[i].CreatedAt.After(invites[j].CreatedAt)
+		}
+		return invites[i].ID > invites[j].ID
+	})
+	return invites, nil
+}
+
+func (s *inviteStore) Revoke(id int, at time.Time) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	invite, ok := s.d.invites[id]
+	if !ok || invite.RevokedAt != nil {
+		return store.ErrNotFound
+	}
+	at = at.UTC().Truncate(time.Second)
+	invite.RevokedAt = &at
+	s.d.invites[id] = invite
+	return nil
+}
+
+func (s *inviteStore) Redeem(id, userID int, now time.Time) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	invite, ok := s.d.invites[id]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	if invite.RevokedAt != nil || !now.Before(invite.ExpiresAt) {
+		return 0, store.ErrInviteExpired
+	}
+	p, ok := s.d.parties[invite.PartyID]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	if p.State != models.PartyOpen {
+		return 0, store.ErrPartyNotOpen
+	}
+	if s.d.memberIndex(invite.PartyID, userID) >= 0 {
+		return invite.PartyID, nil
+	}
+	if invite.MaxUses != nil && invite.Uses >= *invite.MaxUses {
+		return 0, store.ErrInviteExhausted
+	}
+	if err := s.d.addMember(invite.PartyID, userID); err != nil {
+		return 0, err
+	}
+	invite.Uses++
+	s.d.invites[id] = invite
+	return invite.PartyID, nil
+}
@@ -0,0 +1,227 @@
+package memory
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"sync"
+	"time"
+)
+
+type member struct {
+	partyID  int
+	userID   int
+	joinedAt time.Time
+	budget   *int
+}
+
+// db 保存所有内存数据，各 store 共享同一把锁以模拟事务。
+type db struct {
+	mu          sync.Mutex
+	nextID      map[string]int
+	users       map[int]models.User
+	menus       map[int]models.Menu
+	parties     map[int]models.Party
+	members     []member
+	orders      map[int]models.Order
+	history     []models.PartyStateChange
+	categories  map[int]models.Category
+	dietary     map[int]models.DietaryProfile
+	collections map[int]models.Collection
+	restaurants map[int]models.Restaurant
+	audit       []models.AuditEvent
+	sessions    map[string]models.Session
+	invites     map[int]models.PartyInvite
+}
+
+// New 返回基于内存的 Stores，供测试使用。
+func New() store.Stores {
+	d := &db{
+		nextID:      make(map[string]int),
+		users:       make(map[int]models.User),
+		menus:       make(map[int]models.Menu),
+		parties:     make(map[int]models.Party),
+		orders:      make(map[int]models.Order),
+		categories:  make(map[int]models.Category),
+		dietary:     make(map[int]models.DietaryProfile),
+		collections: make(map[int]models.Collection),
+		restaurants: make(map[int]models.Restaurant),
+		sessions:    make(map[string]models.Session),
+		invites:     make(map[int]models.PartyInvite),
+	}
+	return store.Stores{
+		Users:       &userStore{d},
+		Menus:       &menuStore{d},
+		Parties:     &partyStore{d},
+		Orders:      &orderStore{d},
+		Categories:  &categoryStore{d},
+		Collections: &collectionStore{d},
+		Restaurants: &restaurantStore{d},
+		Stats:       &statsStore{d},
+		Audit:       &auditStore{d},
+		Sessions:    &sessionStore{d},
+		Invites:     &inviteStore{d},
+	}
+}
+
+func (d *db) newID(table string) int {
+	d.nextID[table]++
+	return d.nextID[table]
+}
+
+func (d *db) memberIndex(partyID, userID int) int {
+	for i, m := range d.members {
+		if m.partyID == partyID && m.userID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (d *db) recordTransition(partyID int, from, to string, actorID int) {
+	d.history = append(d.history, models.PartyStateChange{
+		ID:        d.newID("party_state_history"),
+		PartyID:   partyID,
+		FromState: from,
+		ToState:   to,
+		ChangedBy: actorID,
+		ChangedAt: time.Now().UTC(),
+	})
+}
+
+// requireOpen 确认 Party 处于开放点餐状态且在点餐时间窗口内，调用方需持有锁。
+func (d *db) requireOpen(partyID int) error {
+	p, ok := d.parties[partyID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if p.State != models.PartyOpen {
+		return store.ErrPartyNotOpen
+	}
+	if !p.InOrderingWindow(time.Now()) {
+		return store.ErrOutsideWindow
+	}
+	return nil
+}
+
+// sold 返回菜品在满足 match 的订单中的已售份数。
+func (d *db) sold(menuID int, match func(models.Order) bool) int {
+	total := 0
+	for _, o := range d.orders {
+		if o.MenuID == menuID && match(o) {
+			total += o.Quantity
+		}
+	}
+	return total
+}
+
+func (d *db) spent(partyID, userID int) int {
+	total := 0
+	for _, o := range d.orders {
+		if o.PartyID == partyID && o.UserID == userID {
+			total += o.UnitCost * o.Quantity
+		}
+	}
+	return total
+}
+
+// applyBudgets 按 Party 的额度模式重新分配全部成员的额度，调用方需持有锁。
+func (d *db) applyBudgets(partyID int) {
+	p, ok := d.parties[partyID]
+	if !ok {
+		return
+	}
+	var indexes []int
+	pool := p.EnergyLeft
+	for i, m := range d.members {
+		if m.partyID == partyID {
+			indexes = append(indexes, i)
+			pool += d.spent(partyID, m.userID)
+		}
+	}
+	for _, i := range indexes {
+		switch p.BudgetMode {
+		case models.BudgetFixed:
+			budget := p.MemberBudget
+			d.members[i].budget = &budget
+		case models.BudgetSplit:
+			budget := max(pool/len(indexes), d.spent(partyID, d.members[i].userID))
+			d.members[i].budget = &budget
+		default:
+			d.members[i].budget = nil
+		}
+	}
+}
+
+func (d *db) deleteOrdersWhere(match func(models.Order) bool) {
+	for id, o := range d.orders {
+		if match(o) {
+			delete(d.orders, id)
+		}
+	}
+}
+
+func (d *db) deleteMembersWhere(match func(member) bool) {
+	kept := d.members[:0]
+	for _, m := range d.members {
+		if !match(m) {
+			kept = append(kept, m)
+		}
+	}
+	d.members = kept
+}
+
+// inRange 判断 t 是否在 filter 的 [From, To) 时间范围内。
+func inRange(t time.Time, filter store.HistoryFilter) bool {
+	return (filter.From.IsZero() || !t.Before(filter.From)) && (filter.To.IsZero() || t.Before(filter.To))
+}
+
+func paginate[T any](list []T, filter store.HistoryFilter) []T {
+	if filter.Limit <= 0 {
+		return list
+	}
+	start := min(filter.Offset, len(list))
+	return list[start:min(start+filter.Limit, len(list))]
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return append([]string{}, s...)
+}
+
+func copyProfile(p models.DietaryProfile) *models.DietaryProfile {
+	p.Allergies = copyStrings(p.Allergies)
+	p.Diets = copyStrings(p.Diets)
+	return &p
+}
+
+func copyModifiers(s []models.MenuModifier) []models.MenuModifier {
+	if s == nil {
+		return []models.MenuModifier{}
+	}
+	return append([]models.MenuModifier{}, s...)
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.UTC().Truncate(time.Second)
+	return &v
+}
+
+func copyInts(s []int) []int {
+	if s == nil {
+		return []int{}
+	}
+	return append([]int{}, s...)
+}
+
+func copyInt(n *int) *int {
+	if n == nil {
+		return nil
+	}
+	v := *n
+	return &v
+}
@@ -0,0 +1,273 @@
+package memory
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+type menuStore struct {
+	d *db
+}
+
+func (s *menuStore) Create(menu *models.Menu) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if !s.d.categoryExists(menu.CategoryID) {
+		return 0, store.ErrInvalidCategory
+	}
+	if !s.d.restaurantExists(menu.RestaurantID) {
+		return 0, store.ErrInvalidRestaurant
+	}
+	m := *menu
+	m.ID = s.d.newID("menus")
+	s.d.menus[m.ID] = s.d.menuView(m)
+	return m.ID, nil
+}
+
+func (s *menuStore) Get(id int) (*models.Menu, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	m, ok := s.d.menu(id)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	m = s.d.menuView(m)
+	return &m, nil
+}
+
+func (s *menuStore) List(filter store.MenuFilter) ([]models.Menu, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	query := strings.ToLower(filter.Query)
+	menus := make([]models.Menu, 0, len(s.d.menus))
+	for _, m := range s.d.menus {
+		if m.DeletedAt != nil {
+			continue
+		}
+		if filter.CategoryID > 0 && (m.CategoryID == nil || *m.CategoryID != filter.CategoryID) {
+			continue
+		}
+		if filter.CollectionID > 0 && !slices.Contains(s.d.collections[filter.CollectionID].MenuIDs, m.ID) {
+			continue
+		}
+		if filter.RestaurantID > 0 && (m.RestaurantID == nil || *m.RestaurantID != filter.RestaurantID) {
+			continue
+		}
+		if filter.Tag != "" && !containsString(m.Tags, filter.Tag) {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(m.Name), query) && !strings.Contains(strings.ToLower(m.Description), query) {
+			continue
+		}
+		menus = append(menus, s.d.menuView(m))
+	}
+	// 与 sqlite 实现一致：按分类排序值排序，未分类的排在最后
+	rank := func(m models.Menu) (bool, int, int) {
+		if m.CategoryID == nil {
+			return true, 0, 0
+		}
+		c, ok := s.d.categories[*m.CategoryID]
+		return !ok, c.SortOrder, c.ID
+	}
+	sort.Slice(menus, func(i, j int) bool {
+		ni, si, ci := rank(menus[i])
+		nj, sj, cj := rank(menus[j])
+		if ni != nj {
+			return !ni
+		}
+		if si != sj {
+			return si < sj
+		}
+		if ci != cj {
+			return ci < cj
+		}
+		return menus[i].ID < menus[j].ID
+	})
+	return menus, nil
+}
+
+func (s *menuStore) Update(menu *models.Menu) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if !s.d.categoryExists(menu.CategoryID) {
+		return store.ErrInvalidCategory
+	}
+	if !s.d.restaurantExists(menu.RestaurantID) {
+		return store.ErrInvalidRestaurant
+	}
+	existing, ok := s.d.menu(menu.ID)
+	if !ok {
+		return store.ErrNotFound
+	}
+	m := *menu
+	m.Available = existing.Available
+	s.d.menus[menu.ID] = s.d.menuView(m)
+	return nil
+}
+
+func (s *menuStore) SetAvailable(id int, available bool) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	m, ok := s.d.menu(id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	m.Available = available
+	s.d.menus[id] = m
+	return nil
+}
+
+func (s *menuStore) Delete(id int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	m, ok := s.d.menu(id)
+	if !ok {
+		return store.ErrNotFound
+	}
+	// 只撤销草稿和开放 Party 中的订单，其余 Party 的订单作为历史保留
+	cancel := func(o models.Order) bool {
+		if o.MenuID != id {
+			return false
+		}
+		p, ok := s.d.parties[o.PartyID]
+		return ok && (p.State == models.PartyDraft || p.State == models.PartyOpen)
+	}
+	for _, o := range s.d.orders {
+		if cancel(o) {
+			p := s.d.parties[o.PartyID]
+			p.EnergyLeft += o.UnitCost * o.Quantity
+			s.d.parties[p.ID] = p
+		}
+	}
+	s.d.deleteOrdersWhere(cancel)
+	for cid, c := range s.d.collections {
+		c.MenuIDs = slices.DeleteFunc(c.MenuIDs, func(menuID int) bool { return menuID == id })
+		s.d.collections[cid] = c
+	}
+	now := time.Now()
+	m.DeletedAt = &now
+	m.Available = false
+	m.Tags = nil
+	s.d.menus[id] = m
+	return nil
+}
+
+func (s *menuStore) Tags() ([]models.TagCount, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	counts := make(map[string]int)
+	for _, m := range s.d.menus {
+		for _, tag := range m.Tags {
+			counts[tag]++
+		}
+	}
+	tags := make([]models.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, models.TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+	return tags, nil
+}
+
+func (s *menuStore) RenameTag(from, to string) error {
+	return s.rewriteTags(from, func(tags []string) []string {
+		return append(removeString(tags, from), to)
+	})
+}
+
+func (s *menuStore) DeleteTag(tag string) error {
+	return s.rewriteTags(tag, func(tags []string) []string {
+		return removeString(tags, tag)
+	})
+}
+
+// rewriteTags 对所有带有标签 tag 的菜品应用 rewrite，没有菜品带该标签时返回 ErrNotFound。
+func (s *menuStore) rewriteTags(tag string, rewrite func([]string) []string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	found := false
+	for id, m := range s.d.menus {
+		if !containsString(m.Tags, tag) {
+			continue
+		}
+		found = true
+		m.Tags = rewrite(copyStrings(m.Tags))
+		s.d.menus[id] = s.d.menuView(m)
+	}
+	if !found {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// menu 返回未删除的菜品，调用方需持有锁。
+func (d *db) menu(id int) (models.Menu, bool) {
+	m, ok := d.menus[id]
+	if !ok || m.DeletedAt != nil {
+		return models.Menu{}, false
+	}
+	return m, true
+}
+
+// menuView 复制菜品的切片字段、整理标签并填充分类名称，调用方需持有锁。
+func (d *db) menuView(m models.Menu) models.Menu {
+	m.ImageURLs = copyStrings(m.ImageURLs)
+	m.Modifiers = copyModifiers(m.Modifiers)
+	m.Allergens = copyStrings(m.Allergens)
+	m.DailyStock = copyInt(m.DailyStock)
+	m.PartyStock = copyInt(m.PartyStock)
+	today := models.StartOfDay(time.Now())
+	m.SoldToday = d.sold(m.ID, func(o models.Order) bool { return !o.CreatedAt.Before(today) })
+	tags := make([]string, 0, len(m.Tags))
+	for _, tag := range m.Tags {
+		if !containsString(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	m.Tags = tags
+	m.CategoryName = ""
+	if m.CategoryID != nil {
+		id := *m.CategoryID
+		m.CategoryID = &id
+		m.CategoryName = d.categories[id].Name
+	}
+	m.RestaurantName = ""
+	if m.RestaurantID != nil {
+		id := *m.RestaurantID
+		m.RestaurantID = &id
+		m.RestaurantName = d.restaurants[id].Name
+	}
+	return m
+}
+
+func (d *db) categoryExists(id *int) bool {
+	if id == nil {
+		return true
+	}
+	_, ok := d.categories[*id]
+	return ok
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	kept := list[:0]
+	for _, v := range list {
+		if v != s {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,279 @@
+package memory
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+type orderStore struct {
+	d *db
+}
+
+func (s *orderStore) Place(partyID, userID int, items []models.CartItem) ([]int, int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	mi := s.d.memberIndex(partyID, userID)
+	if mi < 0 {
+		return nil, 0, store.ErrNotMember
+	}
+	if err := s.d.requireOpen(partyID); err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	orders := make([]models.Order, 0, len(items))
+	total := 0
+	quantities := make(map[int]int)
+	for _, item := range items {
+		m, ok := s.d.menu(item.MenuID)
+		if !ok {
+			return nil, 0, store.ErrNotFound
+		}
+		if id := s.d.parties[partyID].CollectionID; id != nil && !slices.Contains(s.d.collections[*id].MenuIDs, m.ID) {
+			return nil, 0, fmt.Errorf("%w: %s", store.ErrNotInCollection, m.Name)
+		}
+		if err := store.CheckServing(&m, now); err != nil {
+			return nil, 0, err
+		}
+		quantities[m.ID] += item.Quantity
+		unitCost, modifiers, err := store.PriceItem(&m, item.Modifiers)
+		if err != nil {
+			return nil, 0, err
+		}
+		orders = append(orders, models.Order{
+			PartyID:   partyID,
+			UserID:    userID,
+			MenuID:    item.MenuID,
+			Quantity:  item.Quantity,
+			Note:      item.Note,
+			Modifiers: modifiers,
+			UnitCost:  unitCost,
+			CreatedAt: now,
+		})
+		total += unitCost * item.Quantity
+	}
+	for id, quantity := range quantities {
+		m := s.d.menus[id]
+		if m.DailyStock != nil && s.d.sold(id, func(o models.Order) bool { return !o.CreatedAt.Before(models.StartOfDay(now)) })+quantity > *m.DailyStock {
+			return nil, 0, fmt.Errorf("%w: %s", store.ErrSoldOut, m.Name)
+		}
+		if m.PartyStock != nil && s.d.sold(id, func(o models.Order) bool { return o.PartyID == partyID })+quantity > *m.PartyStock {
+			return nil, 0, fmt.Errorf("%w: %s", store.ErrSoldOut, m.Name)
+		}
+	}
+	if budget := s.d.members[mi].budget; budget != nil && s.d.spent(partyID, userID)+total > *budget {
+		return nil, 0, store.ErrBudgetExceeded
+	}
+	p, ok := s.d.parties[partyID]
+	if !ok {
+		return nil, 0, store.ErrNotFound
+	}
+	if p.EnergyLeft < total {
+		return nil, 0, store.ErrInsufficientEnergy
+	}
+	p.EnergyLeft -= total
+	s.d.parties[p.ID] = p
+	ids := make([]int, 0, len(orders))
+	for _, o := range orders {
+		o.ID = s.d.newID("orders")
+		s.d.orders[o.ID] = o
+		ids = append(ids, o.ID)
+	}
+	return ids, total, nil
+}
+
+func (s *orderStore) Delete(orderID, partyID, userID, quantity int) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if err := s.d.requireOpen(partyID); err != nil {
+		return 0, err
+	}
+	o, ok := s.d.orders[orderID]
+	if !ok || o.PartyID != partyID || (userID != 0 && o.UserID != userID) {
+		return 0, store.ErrNotFound
+	}
+	// 与 ListByParty 的合并规则一致，按整组扣减，从最新的订单开始
+	var group []int
+	total := 0
+	for id, other := range s.d.orders {
+		if other.PartyID == o.PartyID && other.UserID == o.UserID && other.MenuID == o.MenuID && other.UnitCost == o.UnitCost &&
+			other.Note == o.Note && slices.Equal(other.Modifiers, o.Modifiers) {
+			group = append(group, id)
+			total += other.Quantity
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(group)))
+	if quantity <= 0 || quantity > total {
+		quantity = total
+	}
+	remaining := quantity
+	for _, id := range group {
+		if remaining == 0 {
+			break
+		}
+		other := s.d.orders[id]
+		if other.Quantity <= remaining {
+			delete(s.d.orders, id)
+			remaining -= other.Quantity
+		} else {
+			other.Quantity -= remaining
+			s.d.orders[id] = other
+			remaining = 0
+		}
+	}
+	refund := o.UnitCost * quantity
+	if p, ok := s.d.parties[partyID]; ok {
+		p.EnergyLeft += refund
+		s.d.parties[partyID] = p
+	}
+	return refund, nil
+}
+
+func (s *orderStore) ListByParty(partyID int) ([]models.OrderItem, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	type key struct {
+		userID, menuID, unitCost int
+		note, modifiers          string
+	}
+	grouped := make(map[key]*models.OrderItem)
+	for _, o := range s.d.orders {
+		if o.PartyID != partyID {
+			continue
+		}
+		u, ok := s.d.users[o.UserID]
+		if !ok {
+			continue
+		}
+		m, ok := s.d.menus[o.MenuID]
+		if !ok {
+			continue
+		}
+		k := key{o.UserID, o.MenuID, o.UnitCost, o.Note, strings.Join(o.Modifiers, "\x00")}
+		item, ok := grouped[k]
+		if !ok {
+			item = &models.OrderItem{
+				ID:         o.ID,
+				Username:   u.Username,
+				MenuName:   m.Name,
+				MenuID:     m.ID,
+				ImageURLs:  copyStrings(m.ImageURLs),
+				EnergyCost: o.UnitCost,
+				Note:       o.Note,
+				Modifiers:  copyStrings(o.Modifiers),
+				Conflicts:  copyProfile(s.d.dietary[o.UserID]).Conflicts(m.Allergens),
+			}
+			grouped[k] = item
+		}
+		if o.ID < item.ID {
+			item.ID = o.ID
+		}
+		item.Quantity += o.Quantity
+	}
+	orders := make([]models.OrderItem, 0, len(grouped))
+	for _, item := range grouped {
+		orders = append(orders, *item)
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID < orders[j].ID })
+	return orders, nil
+}
+
+func (s *orderStore) KitchenSummary(partyID int) ([]models.KitchenItem, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	type key struct {
+		menuID    int
+		modifiers string
+	}
+	ids := make([]int, 0, len(s.d.orders))
+	for id := range s.d.orders {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	grouped := make(map[key]*models.KitchenItem)
+	for _, id := range ids {
+		o := s.d.orders[id]
+		if o.PartyID != partyID {
+			continue
+		}
+		if _, ok := s.d.users[o.UserID]; !ok {
+			continue
+		}
+		m, ok := s.d.menus[o.MenuID]
+		if !ok {
+			continue
+		}
+		k := key{o.MenuID, strings.Join(o.Modifiers, "\x00")}
+		item, ok := grouped[k]
+		if !ok {
+			item = &models.KitchenItem{
+				MenuID:       m.ID,
+				MenuName:     m.Name,
+				Modifiers:    copyStrings(o.Modifiers),
+				Notes:        []string{},
+				RestaurantID: copyInt(m.RestaurantID),
+			}
+			grouped[k] = item
+		}
+		item.Quantity += o.Quantity
+		item.Energy += o.UnitCost * o.Quantity
+		if o.Note != "" {
+			item.Notes = append(item.Notes, o.Note)
+		}
+	}
+	items := make([]models.KitchenItem, 0, len(grouped))
+	for _, item := range grouped {
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].MenuID != items[j].MenuID {
+			return items[i].MenuID < items[j].MenuID
+		}
+		return strings.Join(items[i].Modifiers, "\x00") < strings.Join(items[j].Modifiers, "\x00")
+	})
+	return items, nil
+}
+
+func (s *orderStore) ListByUser(userID int, filter store.HistoryFilter) ([]models.UserOrder, int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	orders := make([]models.UserOrder, 0)
+	for _, o := range s.d.orders {
+		if o.UserID != userID || !inRange(o.CreatedAt, filter) {
+			continue
+		}
+		p, ok := s.d.parties[o.PartyID]
+		if !ok {
+			continue
+		}
+		m, ok := s.d.menus[o.MenuID]
+		if !ok {
+			continue
+		}
+		orders = append(orders, models.UserOrder{
+			ID:         o.ID,
+			PartyID:    p.ID,
+			PartyName:  p.Name,
+			PartyState: p.State,
+			MenuID:     m.ID,
+			MenuName:   m.Name,
+			Quantity:   o.Quantity,
+			UnitCost:   o.UnitCost,
+			Note:       o.Note,
+			Modifiers:  copyStrings(o.Modifiers),
+			CreatedAt:  o.CreatedAt.UTC(),
+		})
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].CreatedAt.After(orders[j].CreatedAt)
+		}
+		return orders[i].ID > orders[j].ID
+	})
+	total := len(orders)
+	return paginate(orders, filter), total, nil
+}
@@ -0,0 +1,372 @@
+package memory
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"sort"
+	"time"
+)
+
+type partyStore struct {
+	d *db
+}
+
+func (s *partyStore) Create(party *models.Party) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	for _, p := range s.d.parties {
+		if p.Name == party.Name && p.DeletedAt == nil {
+			return 0, store.ErrDuplicate
+		}
+	}
+	if !s.d.collectionExists(party.CollectionID) {
+		return 0, store.ErrInvalidCollection
+	}
+	restaurantIDs, err := s.d.partyRestaurants(party.RestaurantIDs)
+	if err != nil {
+		return 0, err
+	}
+	p := *party
+	p.CollectionID = copyInt(party.CollectionID)
+	p.OwnerID = copyInt(party.OwnerID)
+	p.RestaurantIDs = restaurantIDs
+	p.OpensAt = copyTime(party.OpensAt)
+	p.ClosesAt = copyTime(party.ClosesAt)
+	p.ID = s.d.newID("parties")
+	s.d.parties[p.ID] = p
+	s.d.recordTransition(p.ID, "", p.State, 0)
+	return p.ID, nil
+}
+
+func (s *partyStore) Get(id int) (*models.Party, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	p, ok := s.d.parties[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	p.CollectionID = copyInt(p.CollectionID)
+	p.OwnerID = copyInt(p.OwnerID)
+	p.RestaurantIDs = copyInts(p.RestaurantIDs)
+	p.DeletedAt = copyTime(p.DeletedAt)
+	return &p, nil
+}
+
+func (s *partyStore) GetByName(name string) (*models.Party, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	for _, p := range s.d.parties {
+		if p.Name == name && p.DeletedAt == nil {
+			p.CollectionID = copyInt(p.CollectionID)
+			p.OwnerID = copyInt(p.OwnerID)
+			p.RestaurantIDs = copyInts(p.RestaurantIDs)
+			return &p, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *partyStore) List() ([]models.Party, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	parties := make([]models.Party, 0, len(s.d.parties))
+	for _, p := range s.d.parties {
+		if p.DeletedAt != nil {
+			continue
+		}
+		p.Password = ""
+		p.CollectionID = copyInt(p.CollectionID)
+		p.OwnerID = copyInt(p.OwnerID)
+		p.RestaurantIDs = copyInts(p.RestaurantIDs)
+		parties = append(parties, p)
+	}
+	sort.Slice(parties, func(i, j int) bool { return parties[i].ID < parties[j].ID })
+	return parties, nil
+}
+
+func (s *partyStore) CountByState() (map[string]int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	counts := make(map[string]int)
+	for _, p := range s.d.parties {
+		if p.DeletedAt == nil {
+			counts[p.State]++
+		}
+	}
+	return counts, nil
+}
+
+func (s *partyStore) ListByOwner(userID int) ([]models.Party, error) {
+	list, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	owned := make([]models.Party, 0)
+	for _, p := range list {
+		if p.OwnerID != nil && *p.OwnerID == userID {
+			owned = append(owned, p)
+		}
+	}
+	return owned, nil
+}
+
+func (s *partyStore) Update(party *models.Party) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	current, ok := s.d.parties[party.ID]
+	if !ok || current.DeletedAt != nil {
+		return store.ErrNotFound
+	}
+	for _, p := range s.d.parties {
+		if p.ID != party.ID && p.Name == party.Name && p.DeletedAt == nil {
+			return store.ErrDuplicate
+		}
+	}
+	if !s.d.collectionExists(party.CollectionID) {
+		return store.ErrInvalidCollection
+	}
+	restaurantIDs, err := s.d.partyRestaurants(party.RestaurantIDs)
+	if err != nil {
+		return err
+	}
+	current.Name = party.Name
+	current.Password = party.Password
+	current.EnergyLeft = party.EnergyLeft
+	current.OpensAt = copyTime(party.OpensAt)
+	current.ClosesAt = copyTime(party.ClosesAt)
+	current.CollectionID = copyInt(party.CollectionID)
+	current.RestaurantIDs = restaurantIDs
+	rebalance := current.BudgetMode != party.BudgetMode || current.MemberBudget != party.MemberBudget || party.BudgetMode == models.BudgetSplit
+	current.BudgetMode = party.BudgetMode
+	current.MemberBudget = party.MemberBudget
+	s.d.parties[party.ID] = current
+	if rebalance {
+		s.d.applyBudgets(party.ID)
+	}
+	return nil
+}
+
+func (s *partyStore) Delete(id, actorID int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	p, ok := s.d.parties[id]
+	if !ok || p.DeletedAt != nil {
+		return store.ErrNotFound
+	}
+	if p.State != models.PartyArchived {
+		s.d.recordTransition(id, p.State, models.PartyArchived, actorID)
+		p.State = models.PartyArchived
+	}
+	now := time.Now()
+	p.DeletedAt = copyTime(&now)
+	s.d.parties[id] = p
+	return nil
+}
+
+func (s *partyStore) ListArchived(filter store.HistoryFilter) ([]models.PartySummary, int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	archivedAt := make(map[int]time.Time)
+	for _, h := range s.d.history {
+		if h.ToState == models.PartyArchived {
+			archivedAt[h.PartyID] = h.ChangedAt
+		}
+	}
+	summaries := make([]models.PartySummary, 0)
+	for id, p := range s.d.parties {
+		at, ok := archivedAt[id]
+		if p.State != models.PartyArchived || !ok || !inRange(at, filter) {
+			continue
+		}
+		summary := models.PartySummary{
+			ID:         id,
+			Name:       p.Name,
+			EnergyLeft: p.EnergyLeft,
+			ArchivedAt: at,
+			DeletedAt:  copyTime(p.DeletedAt),
+		}
+		for _, m := range s.d.members {
+			if m.partyID == id {
+				summary.Members++
+			}
+		}
+		for _, o := range s.d.orders {
+			if o.PartyID == id {
+				summary.Orders += o.Quantity
+				summary.EnergyUsed += o.UnitCost * o.Quantity
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if !summaries[i].ArchivedAt.Equal(summaries[j].ArchivedAt) {
+			return summaries[i].ArchivedAt.After(summaries[j].ArchivedAt)
+		}
+		return summaries[i].ID > summaries[j].ID
+	})
+	total := len(summaries)
+	return paginate(summaries, filter), total, nil
+}
+
+func (s *partyStore) Transition(partyID int, to string, actorID int) (string, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	p, ok := s.d.parties[partyID]
+	if !ok {
+		return "", store.ErrNotFound
+	}
+	from := p.State
+	if !models.CanTransitionParty(from, to) {
+		return from, store.ErrInvalidTransition
+	}
+	p.State = to
+	s.d.parties[partyID] = p
+	s.d.recordTransition(partyID, from, to, actorID)
+	return from, nil
+}
+
+func (s *partyStore) LockDue(now time.Time) ([]int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	ids := make([]int, 0)
+	for id, p := range s.d.parties {
+		if p.State != models.PartyOpen || p.ClosesAt == nil || p.ClosesAt.After(now) {
+			continue
+		}
+		p.State = models.PartyLocked
+		s.d.parties[id] = p
+		s.d.recordTransition(id, models.PartyOpen, models.PartyLocked, 0)
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (s *partyStore) History(partyID int) ([]models.PartyStateChange, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	history := make([]models.PartyStateChange, 0)
+	for _, h := range s.d.history {
+		if h.PartyID == partyID {
+			history = append(history, h)
+		}
+	}
+	return history, nil
+}
+
+func (s *partyStore) AddMember(partyID, userID int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if s.d.memberIndex(partyID, userID) >= 0 {
+		return nil
+	}
+	return s.d.addMember(partyID, userID)
+}
+
+// addMember 按 Party 的额度模式加入新成员，调用方需持有锁并确认用户尚未加入。
+func (d *db) addMember(partyID, userID int) error {
+	p, ok := d.parties[partyID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	m := member{partyID: partyID, userID: userID, joinedAt: time.Now()}
+	if p.BudgetMode == models.BudgetFixed {
+		budget := p.MemberBudget
+		m.budget = &budget
+	}
+	d.members = append(d.members, m)
+	if p.BudgetMode == models.BudgetSplit {
+		d.applyBudgets(partyID)
+	}
+	return nil
+}
+
+func (s *partyStore) RemoveMember(partyID, userID int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	p, ok := s.d.parties[partyID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if p.State != models.PartyDraft && p.State != models.PartyOpen {
+		return store.ErrPartyNotOpen
+	}
+	s.d.deleteMembersWhere(func(m member) bool { return m.partyID == partyID && m.userID == userID })
+	s.d.deleteOrdersWhere(func(o models.Order) bool {
+		if o.PartyID != partyID || o.UserID != userID {
+			return false
+		}
+		p.EnergyLeft += o.UnitCost * o.Quantity
+		return true
+	})
+	s.d.parties[partyID] = p
+	if p.BudgetMode == models.BudgetSplit {
+		s.d.applyBudgets(partyID)
+	}
+	return nil
+}
+
+func (s *partyStore) SetMemberBudget(partyID, userID int, budget *int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	i := s.d.memberIndex(partyID, userID)
+	if i < 0 {
+		return store.ErrNotMember
+	}
+	if budget != nil {
+		v := *budget
+		budget = &v
+	}
+	s.d.members[i].budget = budget
+	return nil
+}
+
+func (s *partyStore) MemberBudgets(partyID int) ([]models.MemberBudget, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	budgets := make([]models.MemberBudget, 0)
+	for _, m := range s.d.members {
+		if m.partyID != partyID {
+			continue
+		}
+		b := models.MemberBudget{
+			UserID:   m.userID,
+			Username: s.d.users[m.userID].Username,
+			Spent:    s.d.spent(partyID, m.userID),
+		}
+		if m.budget != nil {
+			limit := *m.budget
+			remaining := max(limit-b.Spent, 0)
+			b.Budget, b.Remaining = &limit, &remaining
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, nil
+}
+
+func (s *partyStore) IsMember(partyID, userID int) (bool, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	return s.d.memberIndex(partyID, userID) >= 0, nil
+}
+
+func (s *partyStore) FindByMember(userID int) (*models.Party, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	var found *models.Party
+	for _, m := range s.d.members {
+		if m.userID != userID {
+			continue
+		}
+		if p, ok := s.d.parties[m.partyID]; ok && p.State != models.PartyArchived && (found == nil || p.ID > found.ID) {
+			found = &p
+		}
+	}
+	if found == nil {
+		return nil, store.ErrNotFound
+	}
+	found.CollectionID = copyInt(found.CollectionID)
+	found.OwnerID = copyInt(found.OwnerID)
+	found.RestaurantIDs = copyInts(found.RestaurantIDs)
+	return found, nil
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"slices"
+	"sort"
+)
+
+type restaurantStore struct {
+	d *db
+}
+
+func (s *restaurantStore) Create(restaurant *models.Restaurant) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	for _, r := range s.d.restaurants {
+		if r.Name == restaurant.Name {
+			return 0, store.ErrDuplicate
+		}
+	}
+	r := *restaurant
+	r.ID = s.d.newID("restaurants")
+	s.d.restaurants[r.ID] = r
+	return r.ID, nil
+}
+
+func (s *restaurantStore) Get(id int) (*models.Restaurant, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	r, ok := s.d.restaurants[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &r, nil
+}
+
+func (s *restaurantStore) List() ([]models.Restaurant, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	restaurants := make([]models.Restaurant, 0, len(s.d.restaurants))
+	for _, r := range s.d.restaurants {
+		restaurants = append(restaurants, r)
+	}
+	sort.Slice(restaurants, func(i, j int) bool { return restaurants[i].ID < restaurants[j].ID })
+	return restaurants, nil
+}
+
+func (s *restaurantStore) Update(restaurant *models.Restaurant) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, ok := s.d.restaurants[restaurant.ID]; !ok {
+		return store.ErrNotFound
+	}
+	for _, r := range s.d.restaurants {
+		if r.ID != restaurant.ID && r.Name == restaurant.Name {
+			return store.ErrDuplicate
+		}
+	}
+	s.d.restaurants[restaurant.ID] = *restaurant
+	return nil
+}
+
+func (s *restaurantStore) Delete(id int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, ok := s.d.restaurants[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.d.restaurants, id)
+	for menuID, m := range s.d.menus {
+		if m.RestaurantID != nil && *m.RestaurantID == id {
+			m.RestaurantID = nil
+			s.d.menus[menuID] = m
+		}
+	}
+	for partyID, p := range s.d.parties {
+		p.RestaurantIDs = slices.DeleteFunc(p.RestaurantIDs, func(r int) bool { return r == id })
+		s.d.parties[partyID] = p
+	}
+	return nil
+}
+
+func (d *db) restaurantExists(id *int) bool {
+	if id == nil {
+		return true
+	}
+	_, ok := d.restaurants[*id]
+	return ok
+}
+
+// partyRestaurants 校验餐厅均存在，并返回去重排序后的餐厅 ID，与 sqlite 实现一致。
+func (d *db) partyRestaurants(ids []int) ([]int, error) {
+	restaurantIDs := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !d.restaurantExists(&id) {
+			return nil, store.ErrInvalidRestaurant
+		}
+		if !slices.Contains(restaurantIDs, id) {
+			restaurantIDs = append(restaurantIDs, id)
+		}
+	}
+	slices.Sort(restaurantIDs)
+	return restaurantIDs, nil
+}
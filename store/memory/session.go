@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"sort"
+	"time"
+)
+
+type sessionStore struct {
+	d *db
+}
+
+func copySession(s models.Session) models.Session {
+	s.UserID = copyInt(s.UserID)
+	s.Data = append([]byte(nil), s.Data...)
+	return s
+}
+
+func (s *sessionStore) Get(id string) (*models.Session, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	session, ok := s.d.sessions[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	session = copySession(session)
+	return &session, nil
+}
+
+func (s *sessionStore) Save(session *models.Session) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	saved := copySession(*session)
+	saved.Current = false
+	saved.CreatedAt = session.CreatedAt.UTC().Truncate(time.Second)
+	saved.LastSeenAt = session.LastSeenAt.UTC().Truncate(time.Second)
+	if old, ok := s.d.sessions[session.ID]; ok {
+		saved.CreatedAt = old.CreatedAt
+	}
+	s.d.sessions[session.ID] = saved
+	return nil
+}
+
+func (s *sessionStore) Touch(id string, at time.Time) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	session, ok := s.d.sessions[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	session.LastSeenAt = at.UTC().Truncate(time.Second)
+	s.d.sessions[id] = session
+	return nil
+}
+
+func (s *sessionStore) Delete(id string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, ok := s.d.sessions[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.d.sessions, id)
+	return nil
+}
+
+func (s *sessionStore) ListByUser(userID int) ([]models.Session, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	list := make([]models.Session, 0)
+	for _, session := range s.d.sessions {
+		if session.UserID != nil && *session.UserID == userID {
+			session = copySession(session)
+			session.Data = nil
+			list = append(list, session)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].LastSeenAt.Equal(list[j].LastSeenAt) {
+			return list[i].LastSeenAt.After(list[j].LastSeenAt)
+		}
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (s *sessionStore) DeleteByUser(userID int, exceptID string) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	n := 0
+	for id, session := range s.d.sessions {
+		if session.UserID != nil && *session.UserID == userID && id != exceptID {
+			delete(s.d.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *sessionStore) DeleteExpired(idleBefore, createdBefore time.Time) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	n := 0
+	for id, session := range s.d.sessions {
+		if session.LastSeenAt.Before(idleBefore) || session.CreatedAt.Before(createdBefore) {
+			delete(s.d.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type statsStore struct {
+	d *db
+}
+
+func statsRange(filter store.StatsFilter) store.HistoryFilter {
+	return store.HistoryFilter{From: filter.From, To: filter.To}
+}
+
+func (s *statsStore) Overview(filter store.StatsFilter) (*models.StatsOverview, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	var o models.StatsOverview
+	parties := make(map[int]bool)
+	users := make(map[int]bool)
+	for _, order := range s.d.orders {
+		if !inRange(order.CreatedAt, statsRange(filter)) {
+			continue
+		}
+		o.Orders++
+		o.Quantity += order.Quantity
+		o.Energy += order.UnitCost * order.Quantity
+		parties[order.PartyID] = true
+		users[order.UserID] = true
+	}
+	o.Parties, o.Users = len(parties), len(users)
+	if o.Parties > 0 {
+		o.AvgEnergyPerParty = float64(o.Energy) / float64(o.Parties)
+	}
+	return &o, nil
+}
+
+func (s *statsStore) Series(filter store.StatsFilter) ([]models.StatsBucket, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	type group struct {
+		bucket  models.StatsBucket
+		firstID int
+		parties map[int]bool
+		users   map[int]bool
+	}
+	groups := make(map[string]*group)
+	for _, o := range s.d.orders {
+		if !inRange(o.CreatedAt, statsRange(filter)) {
+			continue
+		}
+		var key, label string
+		switch filter.GroupBy {
+		case models.StatsByUser:
+			u, ok := s.d.users[o.UserID]
+			if !ok {
+				continue
+			}
+			key, label = strconv.Itoa(o.UserID), u.Username
+		case models.StatsByDish:
+			m, ok := s.d.menus[o.MenuID]
+			if !ok {
+				continue
+			}
+			key, label = strconv.Itoa(o.MenuID), m.Name
+		default:
+			key = models.StatsPeriod(o.CreatedAt, filter.GroupBy)
+			label = key
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{bucket: models.StatsBucket{Key: key, Label: label}, firstID: o.ID, parties: make(map[int]bool), users: make(map[int]bool)}
+			groups[key] = g
+		}
+		g.firstID = min(g.firstID, o.ID)
+		g.bucket.Orders++
+		g.bucket.Quantity += o.Quantity
+		g.bucket.Energy += o.UnitCost * o.Quantity
+		g.parties[o.PartyID] = true
+		g.users[o.UserID] = true
+	}
+	list := make([]*group, 0, len(groups))
+	for _, g := range groups {
+		g.bucket.Parties, g.bucket.Users = len(g.parties), len(g.users)
+		list = append(list, g)
+	}
+	byTime := filter.GroupBy != models.StatsByUser && filter.GroupBy != models.StatsByDish
+	sort.Slice(list, func(i, j int) bool {
+		if byTime {
+			return list[i].bucket.Key < list[j].bucket.Key
+		}
+		if list[i].bucket.Quantity != list[j].bucket.Quantity {
+			return list[i].bucket.Quantity > list[j].bucket.Quantity
+		}
+		return list[i].firstID < list[j].firstID
+	})
+	if !byTime && filter.Limit > 0 && len(list) > filter.Limit {
+		list = list[:filter.Limit]
+	}
+	buckets := make([]models.StatsBucket, 0, len(list))
+	for _, g := range list {
+		buckets = append(buckets, g.bucket)
+	}
+	return buckets, nil
+}
+
+func (s *statsStore) InactiveUsers(filter store.StatsFilter) ([]models.InactiveUser, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	joined := make(map[int]bool)
+	lastJoined := make(map[int]time.Time)
+	for _, m := range s.d.members {
+		if inRange(m.joinedAt, statsRange(filter)) {
+			joined[m.userID] = true
+		}
+		if m.joinedAt.After(lastJoined[m.userID]) {
+			lastJoined[m.userID] = m.joinedAt
+		}
+	}
+	users := make([]models.InactiveUser, 0)
+	for id, u := range s.d.users {
+		if joined[id] {
+			continue
+		}
+		user := models.InactiveUser{ID: id, Username: u.Username, Role: u.Role}
+		if t, ok := lastJoined[id]; ok {
+			user.LastJoinedAt = copyTime(&t)
+		}
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
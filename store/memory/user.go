@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"sort"
+)
+
+type userStore struct {
+	d *db
+}
+
+func (s *userStore) Create(user *models.User) (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	for _, u := range s.d.users {
+		if u.Username == user.Username {
+			return 0, store.ErrDuplicate
+		}
+	}
+	u := *user
+	u.ID = s.d.newID("users")
+	s.d.users[u.ID] = u
+	return u.ID, nil
+}
+
+func (s *userStore) Get(id int) (*models.User, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	u, ok := s.d.users[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &u, nil
+}
+
+func (s *userStore) GetByUsername(username string) (*models.User, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	for _, u := range s.d.users {
+		if u.Username == username {
+			return &u, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *userStore) List() ([]models.User, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	users := make([]models.User, 0, len(s.d.users))
+	for _, u := range s.d.users {
+		u.Password = ""
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (s *userStore) Update(user *models.User) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, ok := s.d.users[user.ID]; !ok {
+		return store.ErrNotFound
+	}
+	for _, u := range s.d.users {
+		if u.ID != user.ID && u.Username == user.Username {
+			return store.ErrDuplicate
+		}
+	}
+	s.d.users[user.ID] = *user
+	return nil
+}
+
+func (s *userStore) UpdateRole(id int, role string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	u, ok := s.d.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.Role = role
+	s.d.users[id] = u
+	return nil
+}
+
+func (s *userStore) UpdatePassword(id int, password string) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	u, ok := s.d.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.Password = password
+	s.d.users[id] = u
+	return nil
+}
+
+func (s *userStore) Delete(id int) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, ok := s.d.users[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.d.users, id)
+	delete(s.d.dietary, id)
+	s.d.deleteMembersWhere(func(m member) bool { return m.userID == id })
+	s.d.deleteOrdersWhere(func(o models.Order) bool { return o.UserID == id })
+	for partyID, p := range s.d.parties {
+		if p.OwnerID != nil && *p.OwnerID == id {
+			p.OwnerID = nil
+			s.d.parties[partyID] = p
+		}
+	}
+	for inviteID, invite := range s.d.invites {
+		if invite.CreatedBy != nil && *invite.CreatedBy == id {
+			invite.CreatedBy = nil
+			s.d.invites[inviteID] = invite
+		}
+	}
+	return nil
+}
+
+func (s *userStore) Exists(id int) (bool, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	_, ok := s.d.users[id]
+	return ok, nil
+}
+
+func (s *userStore) CountAdmins() (int, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	count := 0
+	for _, u := range s.d.users {
+		if u.Role == "admin" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *userStore) DietaryProfile(id int) (*models.DietaryProfile, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, ok := s.d.users[id]; !ok {
+		return nil, store.ErrNotFound
+	}
+	return copyProfile(s.d.dietary[id]), nil
+}
+
+func (s *userStore) UpdateDietaryProfile(id int, profile *models.DietaryProfile) error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if _, ok := s.d.users[id]; !ok {
+		return store.ErrNotFound
+	}
+	s.d.dietary[id] = *copyProfile(*profile)
+	return nil
+}
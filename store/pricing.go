@@ -0,0 +1,48 @@
+package store
+
+import (
+	"DineTogether/models"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var ErrInvalidModifier = errors.New("无效的菜品选项")
+
+// PriceItem 校验所选选项均属于该菜品，返回含加价的单价与去重排序后的选项，
+// 排序保证相同的菜品与选项组合可以直接分组汇总。
+func PriceItem(menu *models.Menu, selected []string) (int, []string, error) {
+	surcharges := make(map[string]int, len(menu.Modifiers))
+	for _, m := range menu.Modifiers {
+		surcharges[m.Name] = m.Surcharge
+	}
+	unitCost := menu.EnergyCost
+	seen := make(map[string]bool, len(selected))
+	normalized := make([]string, 0, len(selected))
+	for _, name := range selected {
+		surcharge, ok := surcharges[name]
+		if !ok {
+			return 0, nil, ErrInvalidModifier
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		unitCost += surcharge
+		normalized = append(normalized, name)
+	}
+	sort.Strings(normalized)
+	return unitCost, normalized, nil
+}
+
+// CheckServing 校验菜品在 now 时可点，错误中包含菜品名称以便提示具体是哪道菜。
+func CheckServing(menu *models.Menu, now time.Time) error {
+	if !menu.Available {
+		return fmt.Errorf("%w: %s", ErrMenuUnavailable, menu.Name)
+	}
+	if !menu.InServingHours(now) {
+		return fmt.Errorf("%w: %s", ErrNotServing, menu.Name)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"database/sql"
+	"encoding/json"
+)
+
+type auditStore struct {
+	db *sql.DB
+}
+
+func (s *auditStore) Record(event *models.AuditEvent) error {
+	_, err := s.db.Exec("INSERT INTO audit_events (actor_id, action, target_type, target_id, before, after, ip) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		event.ActorID, event.Action, event.TargetType, event.TargetID, encodeJSON(event.Before), encodeJSON(event.After), event.IP)
+	return err
+}
+
+func encodeJSON(raw json.RawMessage) any {
+	if raw == nil {
+		return nil
+	}
+	return string(raw)
+}
+
+func (s *auditStore) List(filter store.AuditFilter) ([]models.AuditEvent, int, error) {
+	where := "1 = 1"
+	var args []any
+	if filter.ActorID > 0 {
+		where += " AND a.actor_id = ?"
+		args = append(args, filter.ActorID)
+	}
+	if filter.Action != "" {
+		where += " AND a.action = ?"
+		args = append(args, filter.Action)
+	}
+	if filter.TargetType != "" {
+		where += " AND a.target_type = ?"
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		where += " AND a.target_id = ?"
+		args = append(args, filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		where += " AND a.created_at >= ?"
+		args = append(args, encodeTimestamp(filter.From))
+	}
+	if !filter.To.IsZero() {
+		where += " AND a.created_at < ?"
+		args = append(args, encodeTimestamp(filter.To))
+	}
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM audit_events a WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := `
+		SELECT a.id, a.actor_id, COALESCE(u.username, ''), a.action, a.target_type, a.target_id, a.before, a.after, a.ip, a.created_at
+		FROM audit_events a
+		LEFT JOIN users u ON a.actor_id = u.id
+		WHERE ` + where + `
+		ORDER BY a.id DESC`
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := make([]models.AuditEvent, 0)
+	for rows.Next() {
+		var e models.AuditEvent
+		var actorID sql.NullInt64
+		var before, after sql.NullString
+		if err := rows.Scan(&e.ID, &actorID, &e.ActorName, &e.Action, &e.TargetType, &e.TargetID, &before, &after, &e.IP, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		e.ActorID = decodeOptionalInt(actorID)
+		if before.Valid {
+			e.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			e.After = json.RawMessage(after.String)
+		}
+		e.CreatedAt = e.CreatedAt.UTC()
+		events = append(events, e)
+	}
+	return events, total, rows.Err()
+}
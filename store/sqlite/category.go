@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"database/sql"
+)
+
+type categoryStore struct {
+	db *sql.DB
+}
+
+func (s *categoryStore) Create(category *models.Category) (int, error) {
+	result, err := s.db.Exec("INSERT INTO categories (name, sort_order) VALUES (?, ?)", category.Name, category.SortOrder)
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return 0, store.ErrDuplicate
+		}
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (s *categoryStore) Get(id int) (*models.Category, error) {
+	var category models.Category
+	err := s.db.QueryRow("SELECT id, name, sort_order FROM categories WHERE id = ?", id).Scan(&category.ID, &category.Name, &category.SortOrder)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (s *categoryStore) List() ([]models.Category, error) {
+	rows, err := s.db.Query("SELECT id, name, sort_order FROM categories ORDER BY sort_order, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := make([]models.Category, 0)
+	for rows.Next() {
+		var category models.Category
+		if err := rows.Scan(&category.ID, &category.Name, &category.SortOrder); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+func (s *categoryStore) Update(category *models.Category) error {
+	result, err := s.db.Exec("UPDATE categories SET name = ?, sort_order = ? WHERE id = ?", category.Name, category.SortOrder, category.ID)
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return store.ErrDuplicate
+		}
+		return err
+	}
+	return expectAffected(result)
+}
+
+func (s *categoryStore) Delete(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE menus SET category_id = NULL WHERE category_id = ?", id); err != nil {
+		return err
+	}
+	result, err := tx.Exec("DELETE FROM categories WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
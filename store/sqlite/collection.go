@@ -0,0 +1,178 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"database/sql"
+	"encoding/json"
+)
+
+type collectionStore struct {
+	db *sql.DB
+}
+
+const collectionColumns = `id, name, description,
+	(SELECT json_group_array(menu_id) FROM (SELECT menu_id FROM collection_menus WHERE collection_id = collections.id ORDER BY menu_id))`
+
+func (s *collectionStore) Create(collection *models.Collection) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := insertCollection(tx, collection.Name, collection.Description)
+	if err != nil {
+		return 0, err
+	}
+	if err := replaceCollectionMenus(tx, id, collection.MenuIDs); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *collectionStore) Get(id int) (*models.Collection, error) {
+	collection, err := scanCollection(s.db.QueryRow("SELECT "+collectionColumns+" FROM collections WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	return collection, err
+}
+
+func (s *collectionStore) List() ([]models.Collection, error) {
+	rows, err := s.db.Query("SELECT " + collectionColumns + " FROM collections ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := make([]models.Collection, 0)
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, *collection)
+	}
+	return collections, rows.Err()
+}
+
+func (s *collectionStore) Update(collection *models.Collection) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE collections SET name = ?, description = ? WHERE id = ?", collection.Name, collection.Description, collection.ID)
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return store.ErrDuplicate
+		}
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+	if err := replaceCollectionMenus(tx, collection.ID, collection.MenuIDs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *collectionStore) Delete(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE parties SET collection_id = NULL WHERE collection_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM collection_menus WHERE collection_id = ?", id); err != nil {
+		return err
+	}
+	result, err := tx.Exec("DELETE FROM collections WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *collectionStore) Clone(id int, name string) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var description string
+	if err := tx.QueryRow("SELECT description FROM collections WHERE id = ?", id).Scan(&description); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, store.ErrNotFound
+		}
+		return 0, err
+	}
+	newID, err := insertCollection(tx, name, description)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("INSERT INTO collection_menus (collection_id, menu_id) SELECT ?, menu_id FROM collection_menus WHERE collection_id = ?", newID, id); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return newID, nil
+}
+
+func insertCollection(tx *sql.Tx, name, description string) (int, error) {
+	result, err := tx.Exec("INSERT INTO collections (name, description) VALUES (?, ?)", name, description)
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return 0, store.ErrDuplicate
+		}
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func replaceCollectionMenus(tx *sql.Tx, collectionID int, menuIDs []int) error {
+	if _, err := tx.Exec("DELETE FROM collection_menus WHERE collection_id = ?", collectionID); err != nil {
+		return err
+	}
+	for _, menuID := range menuIDs {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM menus WHERE id = ? AND deleted_at IS NULL)", menuID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return store.ErrInvalidMenu
+		}
+		if _, err := tx.Exec("INSERT OR IGNORE INTO collection_menus (collection_id, menu_id) VALUES (?, ?)", collectionID, menuID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanCollection(row scanner) (*models.Collection, error) {
+	var collection models.Collection
+	var menuIDs string
+	if err := row.Scan(&collection.ID, &collection.Name, &collection.Description, &menuIDs); err != nil {
+		return nil, err
+	}
+	collection.MenuIDs = []int{}
+	if err := json.Unmarshal([]byte(menuIDs), &collection.MenuIDs); err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"database/sql"
+	"time"
+)
+
+type inviteStore struct {
+	db *sql.DB
+}
+
+const inviteColumns = "id, party_id, created_by, expires_at, max_uses, uses, revoked_at, created_at"
+
+func (s *inviteStore) Create(invite *models.PartyInvite) (int, error) {
+	result, err := s.db.Exec("INSERT INTO party_invites (party_id, created_by, expires_at, max_uses, created_at) VALUES (?, ?, ?, ?, ?)",
+		invite.PartyID, invite.CreatedBy, encodeTime(&invite.ExpiresAt), invite.MaxUses, encodeTime(&invite.CreatedAt))
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func scanInvite(row scanner) (*models.PartyInvite, error) {
+	var invite models.PartyInvite
+	var createdBy, maxUses sql.NullInt64
+	var revokedAt sql.NullTime
+	if err := row.Scan(&invite.ID, &invite.PartyID, &createdBy, &invite.ExpiresAt, &maxUses, &invite.Uses, &revokedAt, &invite.CreatedAt); err != nil {
+		return nil, err
+	}
+	invite.CreatedBy = decodeOptionalInt(createdBy)
+	invite.MaxUses = decodeOptionalInt(maxUses)
+	invite.RevokedAt = decodeTime(revokedAt)
+	invite.ExpiresAt = invite.ExpiresAt.UTC()
+	invite.CreatedAt = invite.CreatedAt.UTC()
+	return &invite, nil
+}
+
+func (s *inviteStore) Get(id int) (*models.PartyInvite, error) {
+	invite, err := scanInvite(s.db.QueryRow("SELECT "+inviteColumns+" FROM party_invites WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	return invite, err
+}
+
+func (s *inviteStore) ListByParty(partyID int) ([]models.PartyInvite, error) {
+	rows, err := s.db.Query("SELECT "+inviteColumns+" FROM party_invites WHERE party_id = ? ORDER BY created_at DESC, id DESC", partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := make([]models.PartyInvite, 0)
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, *invite)
+	}
+	return invites, rows.Err()
+}
+
+func (s *inviteStore) Revoke(id int, at time.Time) error {
+	result, err := s.db.Exec("UPDATE party_invites SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", encodeTime(&at), id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+func (s *inviteStore) Redeem(id, userID int, now time.Time) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	invite, err := scanInvite(tx.QueryRow("SELECT "+inviteColumns+" FROM party_invites WHERE id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, store.ErrNotFound
+		}
+		return 0, err
+	}
+	if invite.RevokedAt != nil || !now.Before(invite.ExpiresAt) {
+		return 0, store.ErrInviteExpired
+	}
+	state, err := partyState(tx, invite.PartyID)
+	if err != nil {
+		return 0, err
+	}
+	if state != models.PartyOpen {
+		return 0, store.ErrPartyNotOpen
+	}
+	var member bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM party_members WHERE party_id = ? AND user_id = ?)", invite.PartyID, userID).Scan(&member); err != nil {
+		return 0, err
+	}
+	if member {
+		return invite.PartyID, nil
+	}
+	if invite.MaxUses != nil && invite.Uses >= *invite.MaxUses {
+		return 0, store.ErrInviteExhausted
+	}
+	if _, err := tx.Exec("INSERT INTO party_members (party_id, user_id) VALUES (?, ?)", invite.PartyID, userID); err != nil {
+		return 0, err
+	}
+	if err := assignBudget(tx, invite.PartyID, userID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("UPDATE party_invites SET uses = uses + 1 WHERE id = ?", id); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return invite.PartyID, nil
+}
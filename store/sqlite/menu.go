@@ -0,0 +1,402 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type menuStore struct {
+	db *sql.DB
+}
+
+// menuColumns 只能用于 FROM menus（不带别名）的查询。
+const menuColumns = `id, name, description, energy_cost, image_urls, modifiers, allergens,
+	available, available_from, available_until, daily_stock, party_stock, category_id,
+	COALESCE((SELECT name FROM categories WHERE id = menus.category_id), ''), restaurant_id,
+	COALESCE((SELECT name FROM restaurants WHERE id = menus.restaurant_id), ''),
+	(SELECT json_group_array(tag) FROM (SELECT tag FROM menu_tags WHERE menu_id = menus.id ORDER BY tag))`
+
+func (s *menuStore) Create(menu *models.Menu) (int, error) {
+	imageURLsJSON, modifiersJSON, allergensJSON, err := encodeMenu(menu)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := checkCategory(tx, menu.CategoryID); err != nil {
+		return 0, err
+	}
+	if err := checkRestaurant(tx, menu.RestaurantID); err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec(`
+		INSERT INTO menus (name, description, energy_cost, image_urls, modifiers, allergens, available, available_from, available_until, daily_stock, party_stock, category_id, restaurant_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		menu.Name, menu.Description, menu.EnergyCost, imageURLsJSON, modifiersJSON, allergensJSON,
+		menu.Available, menu.AvailableFrom, menu.AvailableUntil, menu.DailyStock, menu.PartyStock, menu.CategoryID, menu.RestaurantID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := replaceTags(tx, int(id), menu.Tags); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+func (s *menuStore) Get(id int) (*models.Menu, error) {
+	menu, err := scanMenu(s.db.QueryRow("SELECT "+menuColumns+" FROM menus WHERE id = ? AND deleted_at IS NULL", id))
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM orders WHERE menu_id = ? AND created_at >= ?",
+		id, encodeTimestamp(models.StartOfDay(time.Now()))).Scan(&menu.SoldToday)
+	return menu, err
+}
+
+func (s *menuStore) List(filter store.MenuFilter) ([]models.Menu, error) {
+	where := []string{"deleted_at IS NULL"}
+	var args []any
+	if filter.CategoryID > 0 {
+		where = append(where, "category_id = ?")
+		args = append(args, filter.CategoryID)
+	}
+	if filter.CollectionID > 0 {
+		where = append(where, "id IN (SELECT menu_id FROM collection_menus WHERE collection_id = ?)")
+		args = append(args, filter.CollectionID)
+	}
+	if filter.RestaurantID > 0 {
+		where = append(where, "restaurant_id = ?")
+		args = append(args, filter.RestaurantID)
+	}
+	if filter.Tag != "" {
+		where = append(where, "id IN (SELECT menu_id FROM menu_tags WHERE tag = ?)")
+		args = append(args, filter.Tag)
+	}
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		where = append(where, `(name LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+	query := "SELECT " + menuColumns + " FROM menus WHERE " + strings.Join(where, " AND ")
+	query += `
+		ORDER BY (SELECT sort_order FROM categories WHERE id = menus.category_id) IS NULL,
+			(SELECT sort_order FROM categories WHERE id = menus.category_id), category_id, id`
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	menus := make([]models.Menu, 0)
+	for rows.Next() {
+		menu, err := scanMenu(rows)
+		if err != nil {
+			return nil, err
+		}
+		menus = append(menus, *menu)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sold, err := s.soldSince(models.StartOfDay(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	for i := range menus {
+		menus[i].SoldToday = sold[menus[i].ID]
+	}
+	return menus, nil
+}
+
+// soldSince 返回各菜品自 since 起的已售份数。
+func (s *menuStore) soldSince(since time.Time) (map[int]int, error) {
+	rows, err := s.db.Query("SELECT menu_id, SUM(quantity) FROM orders WHERE created_at >= ? GROUP BY menu_id", encodeTimestamp(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sold := make(map[int]int)
+	for rows.Next() {
+		var menuID, quantity int
+		if err := rows.Scan(&menuID, &quantity); err != nil {
+			return nil, err
+		}
+		sold[menuID] = quantity
+	}
+	return sold, rows.Err()
+}
+
+func (s *menuStore) SetAvailable(id int, available bool) error {
+	result, err := s.db.Exec("UPDATE menus SET available = ? WHERE id = ? AND deleted_at IS NULL", available, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+func (s *menuStore) Update(menu *models.Menu) error {
+	imageURLsJSON, modifiersJSON, allergensJSON, err := encodeMenu(menu)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkCategory(tx, menu.CategoryID); err != nil {
+		return err
+	}
+	if err := checkRestaurant(tx, menu.RestaurantID); err != nil {
+		return err
+	}
+	result, err := tx.Exec(`
+		UPDATE menus SET name = ?, description = ?, energy_cost = ?, image_urls = ?, modifiers = ?, allergens = ?,
+			available_from = ?, available_until = ?, daily_stock = ?, party_stock = ?, category_id = ?, restaurant_id = ?
+		WHERE id = ? AND deleted_at IS NULL`,
+		menu.Name, menu.Description, menu.EnergyCost, imageURLsJSON, modifiersJSON, allergensJSON,
+		menu.AvailableFrom, menu.AvailableUntil, menu.DailyStock, menu.PartyStock, menu.CategoryID, menu.RestaurantID, menu.ID)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+	if err := replaceTags(tx, menu.ID, menu.Tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func checkCategory(tx *sql.Tx, categoryID *int) error {
+	if categoryID == nil {
+		return nil
+	}
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE id = ?)", *categoryID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrInvalidCategory
+	}
+	return nil
+}
+
+func replaceTags(tx *sql.Tx, menuID int, tags []string) error {
+	if _, err := tx.Exec("DELETE FROM menu_tags WHERE menu_id = ?", menuID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO menu_tags (menu_id, tag) VALUES (?, ?)", menuID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (s *menuStore) Delete(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM menus WHERE id = ? AND deleted_at IS NULL)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrNotFound
+	}
+	// 只撤销草稿和开放 Party 中的订单，其余 Party 的订单作为历史保留
+	rows, err := tx.Query(`
+		SELECT o.party_id, SUM(o.unit_cost * o.quantity) FROM orders o
+		JOIN parties p ON o.party_id = p.id
+		WHERE o.menu_id = ? AND p.state IN (?, ?)
+		GROUP BY o.party_id`, id, models.PartyDraft, models.PartyOpen)
+	if err != nil {
+		return err
+	}
+	partyEnergyUpdates := make(map[int]int)
+	for rows.Next() {
+		var partyID, energyCost int
+		if err := rows.Scan(&partyID, &energyCost); err != nil {
+			rows.Close()
+			return err
+		}
+		partyEnergyUpdates[partyID] = energyCost
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for partyID, energyToRestore := range partyEnergyUpdates {
+		if _, err := tx.Exec("UPDATE parties SET energy_left = energy_left + ? WHERE id = ?", energyToRestore, partyID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM orders WHERE menu_id = ? AND party_id = ?", id, partyID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM menu_tags WHERE menu_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM collection_menus WHERE menu_id = ?", id); err != nil {
+		return err
+	}
+	now := time.Now()
+	if _, err := tx.Exec("UPDATE menus SET deleted_at = ?, available = 0 WHERE id = ?", encodeTime(&now), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *menuStore) Tags() ([]models.TagCount, error) {
+	rows, err := s.db.Query("SELECT tag, COUNT(*) FROM menu_tags GROUP BY tag ORDER BY tag")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]models.TagCount, 0)
+	for rows.Next() {
+		var t models.TagCount
+		if err := rows.Scan(&t.Tag, &t.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+func (s *menuStore) RenameTag(from, to string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM menu_tags WHERE tag = ?)", from).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrNotFound
+	}
+	// 已同时带有两个标签的菜品只保留 to
+	_, err = tx.Exec("DELETE FROM menu_tags WHERE tag = ? AND menu_id IN (SELECT menu_id FROM menu_tags WHERE tag = ?)", from, to)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE menu_tags SET tag = ? WHERE tag = ?", to, from); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *menuStore) DeleteTag(tag string) error {
+	result, err := s.db.Exec("DELETE FROM menu_tags WHERE tag = ?", tag)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMenu(row scanner) (*models.Menu, error) {
+	var menu models.Menu
+	var description, imageURLs sql.NullString
+	var modifiers, allergens, tags string
+	var categoryID, restaurantID, dailyStock, partyStock sql.NullInt64
+	if err := row.Scan(&menu.ID, &menu.Name, &description, &menu.EnergyCost, &imageURLs, &modifiers, &allergens,
+		&menu.Available, &menu.AvailableFrom, &menu.AvailableUntil, &dailyStock, &partyStock, &categoryID, &menu.CategoryName,
+		&restaurantID, &menu.RestaurantName, &tags); err != nil {
+		return nil, err
+	}
+	menu.Description = description.String
+	urls, err := decodeImageURLs(imageURLs)
+	if err != nil {
+		return nil, err
+	}
+	menu.ImageURLs = urls
+	if menu.Modifiers, err = decodeModifiers(modifiers); err != nil {
+		return nil, err
+	}
+	if menu.Tags, err = decodeStrings(tags); err != nil {
+		return nil, err
+	}
+	if menu.Allergens, err = decodeStrings(allergens); err != nil {
+		return nil, err
+	}
+	menu.CategoryID = decodeOptionalInt(categoryID)
+	menu.RestaurantID = decodeOptionalInt(restaurantID)
+	menu.DailyStock = decodeOptionalInt(dailyStock)
+	menu.PartyStock = decodeOptionalInt(partyStock)
+	return &menu, nil
+}
+
+func encodeMenu(menu *models.Menu) (string, string, string, error) {
+	imageURLs := menu.ImageURLs
+	if imageURLs == nil {
+		imageURLs = []string{}
+	}
+	imageURLsJSON, err := json.Marshal(imageURLs)
+	if err != nil {
+		return "", "", "", err
+	}
+	modifiers := menu.Modifiers
+	if modifiers == nil {
+		modifiers = []models.MenuModifier{}
+	}
+	modifiersJSON, err := json.Marshal(modifiers)
+	if err != nil {
+		return "", "", "", err
+	}
+	allergensJSON, err := encodeStrings(menu.Allergens)
+	if err != nil {
+		return "", "", "", err
+	}
+	return string(imageURLsJSON), string(modifiersJSON), allergensJSON, nil
+}
+
+func decodeModifiers(raw string) ([]models.MenuModifier, error) {
+	modifiers := []models.MenuModifier{}
+	if raw == "" {
+		return modifiers, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &modifiers); err != nil {
+		return nil, err
+	}
+	if modifiers == nil {
+		modifiers = []models.MenuModifier{}
+	}
+	return modifiers, nil
+}
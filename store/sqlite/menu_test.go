@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"errors"
+	"testing"
+)
+
+func TestMenuDeleteKeepsLockedPartyOrders(t *testing.T) {
+	st := New(openTestDB(t))
+	userID, err := st.Users.Create(&models.User{Username: "alice", Password: "x", Role: models.RoleGuest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	menuID, err := st.Menus.Create(&models.Menu{Name: "noodles", EnergyCost: 4, Available: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	party := func(name string) int {
+		id, err := st.Parties.Create(&models.Party{Name: name, Password: "pw", EnergyLeft: 20, State: models.PartyOpen, BudgetMode: models.BudgetShared})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := st.Parties.AddMember(id, userID); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := st.Orders.Place(id, userID, []models.CartItem{{MenuID: menuID, Quantity: 2}}); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	openID, lockedID := party("open"), party("locked")
+	if _, err := st.Parties.Transition(lockedID, models.PartyLocked, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.Menus.Delete(menuID); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Menus.Delete(menuID); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("重复删除: %v", err)
+	}
+	if _, err := st.Menus.Get(menuID); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("已删除菜品 Get: %v", err)
+	}
+	if list, _ := st.Menus.List(store.MenuFilter{}); len(list) != 0 {
+		t.Fatalf("已删除菜品仍在列表中: %v", list)
+	}
+
+	check := func(partyID, energy, orders int) {
+		t.Helper()
+		p, err := st.Parties.Get(partyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items, err := st.Orders.ListByParty(partyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.EnergyLeft != energy || len(items) != orders {
+			t.Fatalf("Party %d: 精力 %d、订单 %d 条，期望 %d、%d", partyID, p.EnergyLeft, len(items), energy, orders)
+		}
+	}
+	// 开放 Party 的订单被撤销并退还精力，锁定 Party 的订单与精力保持不变
+	check(openID, 20, 0)
+	check(lockedID, 12, 1)
+	if items, _ := st.Orders.ListByParty(lockedID); items[0].MenuName != "noodles" {
+		t.Fatalf("历史订单菜品名 = %q", items[0].MenuName)
+	}
+	if _, _, err := st.Orders.Place(openID, userID, []models.CartItem{{MenuID: menuID, Quantity: 1}}); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("点已删除的菜品: %v", err)
+	}
+}
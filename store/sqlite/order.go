@@ -0,0 +1,368 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type orderStore struct {
+	db *sql.DB
+}
+
+func (s *orderStore) Place(partyID, userID int, items []models.CartItem) ([]int, int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	var budget sql.NullInt64
+	row := tx.QueryRow("SELECT budget FROM party_members WHERE party_id = ? AND user_id = ?", partyID, userID)
+	if err := row.Scan(&budget); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, store.ErrNotMember
+		}
+		return nil, 0, err
+	}
+	if err := requireOpen(tx, partyID); err != nil {
+		return nil, 0, err
+	}
+	var collectionID sql.NullInt64
+	if err := tx.QueryRow("SELECT collection_id FROM parties WHERE id = ?", partyID).Scan(&collectionID); err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	orders := make([]models.Order, 0, len(items))
+	total := 0
+	menus := make(map[int]*models.Menu)
+	quantities := make(map[int]int)
+	for _, item := range items {
+		menu, err := scanMenu(tx.QueryRow("SELECT "+menuColumns+" FROM menus WHERE id = ? AND deleted_at IS NULL", item.MenuID))
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, 0, store.ErrNotFound
+			}
+			return nil, 0, err
+		}
+		if collectionID.Valid {
+			var inCollection bool
+			row := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM collection_menus WHERE collection_id = ? AND menu_id = ?)", collectionID.Int64, menu.ID)
+			if err := row.Scan(&inCollection); err != nil {
+				return nil, 0, err
+			}
+			if !inCollection {
+				return nil, 0, fmt.Errorf("%w: %s", store.ErrNotInCollection, menu.Name)
+			}
+		}
+		if err := store.CheckServing(menu, now); err != nil {
+			return nil, 0, err
+		}
+		menus[menu.ID] = menu
+		quantities[menu.ID] += item.Quantity
+		unitCost, modifiers, err := store.PriceItem(menu, item.Modifiers)
+		if err != nil {
+			return nil, 0, err
+		}
+		orders = append(orders, models.Order{
+			PartyID:   partyID,
+			UserID:    userID,
+			MenuID:    item.MenuID,
+			Quantity:  item.Quantity,
+			Note:      item.Note,
+			Modifiers: modifiers,
+			UnitCost:  unitCost,
+		})
+		total += unitCost * item.Quantity
+	}
+	for id, quantity := range quantities {
+		if err := checkStock(tx, menus[id], partyID, quantity, now); err != nil {
+			return nil, 0, err
+		}
+	}
+	if budget.Valid {
+		var spent int
+		row := tx.QueryRow("SELECT COALESCE(SUM(unit_cost * quantity), 0) FROM orders WHERE party_id = ? AND user_id = ?", partyID, userID)
+		if err := row.Scan(&spent); err != nil {
+			return nil, 0, err
+		}
+		if spent+total > int(budget.Int64) {
+			return nil, 0, store.ErrBudgetExceeded
+		}
+	}
+	if err := debitParty(tx, partyID, total); err != nil {
+		return nil, 0, err
+	}
+	ids := make([]int, 0, len(orders))
+	for _, o := range orders {
+		modifiersJSON, err := encodeStrings(o.Modifiers)
+		if err != nil {
+			return nil, 0, err
+		}
+		result, err := tx.Exec("INSERT INTO orders (party_id, user_id, menu_id, quantity, note, modifiers, unit_cost) VALUES (?, ?, ?, ?, ?, ?, ?)", o.PartyID, o.UserID, o.MenuID, o.Quantity, o.Note, modifiersJSON, o.UnitCost)
+		if err != nil {
+			return nil, 0, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, 0, err
+		}
+		ids = append(ids, int(id))
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, 0, err
+	}
+	return ids, total, nil
+}
+
+// checkStock 按已有订单统计菜品今日及本 Party 的已售份数，加上 quantity 超出限量时返回 ErrSoldOut。
+func checkStock(tx *sql.Tx, menu *models.Menu, partyID, quantity int, now time.Time) error {
+	if menu.DailyStock != nil {
+		var sold int
+		row := tx.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM orders WHERE menu_id = ? AND created_at >= ?", menu.ID, encodeTimestamp(models.StartOfDay(now)))
+		if err := row.Scan(&sold); err != nil {
+			return err
+		}
+		if sold+quantity > *menu.DailyStock {
+			return fmt.Errorf("%w: %s", store.ErrSoldOut, menu.Name)
+		}
+	}
+	if menu.PartyStock != nil {
+		var sold int
+		row := tx.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM orders WHERE menu_id = ? AND party_id = ?", menu.ID, partyID)
+		if err := row.Scan(&sold); err != nil {
+			return err
+		}
+		if sold+quantity > *menu.PartyStock {
+			return fmt.Errorf("%w: %s", store.ErrSoldOut, menu.Name)
+		}
+	}
+	return nil
+}
+
+// debitParty 仅在剩余精力足够时扣除，避免并发点餐超额消耗。
+func debitParty(tx *sql.Tx, partyID, amount int) error {
+	result, err := tx.Exec("UPDATE parties SET energy_left = energy_left - ? WHERE id = ? AND energy_left >= ?", amount, partyID, amount)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM parties WHERE id = ?)", partyID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrNotFound
+	}
+	return store.ErrInsufficientEnergy
+}
+
+func (s *orderStore) Delete(orderID, partyID, userID, quantity int) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := requireOpen(tx, partyID); err != nil {
+		return 0, err
+	}
+	// ListByParty 将相同的订单合并为一条，按整组扣减，从最新的订单开始
+	var ownerID, menuID, unitCost int
+	var note, modifiers string
+	row := tx.QueryRow("SELECT user_id, menu_id, note, modifiers, unit_cost FROM orders WHERE id = ? AND (? = 0 OR user_id = ?) AND party_id = ?", orderID, userID, userID, partyID)
+	if err := row.Scan(&ownerID, &menuID, &note, &modifiers, &unitCost); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, store.ErrNotFound
+		}
+		return 0, err
+	}
+	rows, err := tx.Query(`
+		SELECT id, quantity FROM orders
+		WHERE party_id = ? AND user_id = ? AND menu_id = ? AND note = ? AND modifiers = ? AND unit_cost = ?
+		ORDER BY id DESC`, partyID, ownerID, menuID, note, modifiers, unitCost)
+	if err != nil {
+		return 0, err
+	}
+	type groupRow struct{ id, quantity int }
+	var group []groupRow
+	total := 0
+	for rows.Next() {
+		var r groupRow
+		if err := rows.Scan(&r.id, &r.quantity); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		group = append(group, r)
+		total += r.quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if quantity <= 0 || quantity > total {
+		quantity = total
+	}
+	remaining := quantity
+	for _, r := range group {
+		if remaining == 0 {
+			break
+		}
+		if r.quantity <= remaining {
+			_, err = tx.Exec("DELETE FROM orders WHERE id = ?", r.id)
+			remaining -= r.quantity
+		} else {
+			_, err = tx.Exec("UPDATE orders SET quantity = quantity - ? WHERE id = ?", remaining, r.id)
+			remaining = 0
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	refund := unitCost * quantity
+	if _, err := tx.Exec("UPDATE parties SET energy_left = energy_left + ? WHERE id = ?", refund, partyID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return refund, nil
+}
+
+func (s *orderStore) ListByParty(partyID int) ([]models.OrderItem, error) {
+	rows, err := s.db.Query(`
+		SELECT MIN(o.id) as id, u.username, m.name, m.id, m.image_urls, o.unit_cost, SUM(o.quantity) as quantity, o.note, o.modifiers,
+			m.allergens, u.allergies, u.diets
+		FROM orders o
+		JOIN users u ON o.user_id = u.id
+		JOIN menus m ON o.menu_id = m.id
+		WHERE o.party_id = ?
+		GROUP BY u.id, m.id, o.note, o.modifiers, o.unit_cost
+		ORDER BY MIN(o.id)`, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]models.OrderItem, 0)
+	for rows.Next() {
+		var order models.OrderItem
+		var imageURLs sql.NullString
+		var modifiers, allergens, allergies, diets string
+		if err := rows.Scan(&order.ID, &order.Username, &order.MenuName, &order.MenuID, &imageURLs, &order.EnergyCost, &order.Quantity, &order.Note, &modifiers,
+			&allergens, &allergies, &diets); err != nil {
+			return nil, err
+		}
+		urls, err := decodeImageURLs(imageURLs)
+		if err != nil {
+			return nil, err
+		}
+		order.ImageURLs = urls
+		if order.Modifiers, err = decodeStrings(modifiers); err != nil {
+			return nil, err
+		}
+		menuAllergens, err := decodeStrings(allergens)
+		if err != nil {
+			return nil, err
+		}
+		profile, err := decodeProfile(allergies, diets, false)
+		if err != nil {
+			return nil, err
+		}
+		order.Conflicts = profile.Conflicts(menuAllergens)
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+func (s *orderStore) KitchenSummary(partyID int) ([]models.KitchenItem, error) {
+	rows, err := s.db.Query(`
+		SELECT m.id, m.name, o.modifiers, SUM(o.quantity), json_group_array(o.note) FILTER (WHERE o.note != ''),
+			m.restaurant_id, SUM(o.unit_cost * o.quantity)
+		FROM orders o
+		JOIN users u ON o.user_id = u.id
+		JOIN menus m ON o.menu_id = m.id
+		WHERE o.party_id = ?
+		GROUP BY m.id, o.modifiers
+		ORDER BY m.id, o.modifiers`, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.KitchenItem, 0)
+	for rows.Next() {
+		var item models.KitchenItem
+		var modifiers, notes string
+		var restaurantID sql.NullInt64
+		if err := rows.Scan(&item.MenuID, &item.MenuName, &modifiers, &item.Quantity, &notes, &restaurantID, &item.Energy); err != nil {
+			return nil, err
+		}
+		item.RestaurantID = decodeOptionalInt(restaurantID)
+		if item.Modifiers, err = decodeStrings(modifiers); err != nil {
+			return nil, err
+		}
+		if item.Notes, err = decodeStrings(notes); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s *orderStore) ListByUser(userID int, filter store.HistoryFilter) ([]models.UserOrder, int, error) {
+	where := "o.user_id = ?"
+	args := []any{userID}
+	if !filter.From.IsZero() {
+		where += " AND o.created_at >= ?"
+		args = append(args, encodeTimestamp(filter.From))
+	}
+	if !filter.To.IsZero() {
+		where += " AND o.created_at < ?"
+		args = append(args, encodeTimestamp(filter.To))
+	}
+	from := `
+		FROM orders o
+		JOIN parties p ON o.party_id = p.id
+		JOIN menus m ON o.menu_id = m.id
+		WHERE ` + where
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*)"+from, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := `
+		SELECT o.id, o.party_id, p.name, p.state, o.menu_id, m.name, o.quantity, o.unit_cost, o.note, o.modifiers, o.created_at` + from + `
+		ORDER BY o.created_at DESC, o.id DESC`
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	orders := make([]models.UserOrder, 0)
+	for rows.Next() {
+		var order models.UserOrder
+		var modifiers string
+		if err := rows.Scan(&order.ID, &order.PartyID, &order.PartyName, &order.PartyState, &order.MenuID, &order.MenuName, &order.Quantity, &order.UnitCost, &order.Note, &modifiers, &order.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		order.CreatedAt = order.CreatedAt.UTC()
+		if order.Modifiers, err = decodeStrings(modifiers); err != nil {
+			return nil, 0, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, total, rows.Err()
+}
@@ -0,0 +1,195 @@
+package sqlite
+
+import (
+	"DineTogether/migrations"
+	"DineTogether/models"
+	"DineTogether/store"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestMain(m *testing.M) {
+	// 迁移过程的日志无助于测试结果
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// openTestDB 在临时目录创建与线上相同连接参数的数据库并执行全部迁移。
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.sqlite")
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestPlaceConcurrentNeverOverspends(t *testing.T) {
+	const (
+		initialEnergy = 100
+		cost          = 3
+		members       = 20
+		ordersEach    = 5
+	)
+	st := New(openTestDB(t))
+	partyID, err := st.Parties.Create(&models.Party{Name: "load", Password: "pw", EnergyLeft: initialEnergy, State: models.PartyOpen, BudgetMode: models.BudgetShared})
+	if err != nil {
+		t.Fatal(err)
+	}
+	menuID, err := st.Menus.Create(&models.Menu{Name: "dumplings", EnergyCost: cost, Available: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	userIDs := make([]int, members)
+	for i := range userIDs {
+		userIDs[i], err = st.Users.Create(&models.User{Username: fmt.Sprintf("user%d", i), Password: "x", Role: models.RoleGuest})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := st.Parties.AddMember(partyID, userIDs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		spent    int
+		placed   int
+		rejected int
+	)
+	start := make(chan struct{})
+	for _, userID := range userIDs {
+		wg.Add(1)
+		go func(userID int) {
+			defer wg.Done()
+			<-start
+			for range ordersEach {
+				_, energy, err := st.Orders.Place(partyID, userID, []models.CartItem{{MenuID: menuID, Quantity: 1}})
+				mu.Lock()
+				switch {
+				case err == nil:
+					spent += energy
+					placed++
+				case errors.Is(err, store.ErrInsufficientEnergy):
+					rejected++
+				default:
+					t.Errorf("下单失败: %v", err)
+				}
+				mu.Unlock()
+			}
+		}(userID)
+	}
+	close(start)
+	wg.Wait()
+
+	party, err := st.Parties.Get(partyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if party.EnergyLeft < 0 {
+		t.Fatalf("剩余精力为负: %d", party.EnergyLeft)
+	}
+	if spent+party.EnergyLeft != initialEnergy {
+		t.Fatalf("已扣除 %d + 剩余 %d != 初始 %d", spent, party.EnergyLeft, initialEnergy)
+	}
+	if placed != initialEnergy/cost || placed+rejected != members*ordersEach {
+		t.Fatalf("成功 %d 单，拒绝 %d 单", placed, rejected)
+	}
+	orders, err := st.Orders.ListByParty(partyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quantity := 0
+	for _, o := range orders {
+		quantity += o.Quantity
+	}
+	if quantity != placed {
+		t.Fatalf("订单份数 %d != 成功下单数 %d", quantity, placed)
+	}
+}
+
+func TestDeleteActsOnGroupedOrders(t *testing.T) {
+	st := New(openTestDB(t))
+	partyID, err := st.Parties.Create(&models.Party{Name: "lunch", Password: "pw", EnergyLeft: 100, State: models.PartyOpen, BudgetMode: models.BudgetShared})
+	if err != nil {
+		t.Fatal(err)
+	}
+	menuID, err := st.Menus.Create(&models.Menu{Name: "noodles", EnergyCost: 2, Available: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, err := st.Users.Create(&models.User{Username: "alice", Password: "x", Role: models.RoleGuest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Parties.AddMember(partyID, userID); err != nil {
+		t.Fatal(err)
+	}
+	// 分三次下单同一菜品，另有一份带备注的订单单独成组
+	for _, item := range []models.CartItem{{MenuID: menuID, Quantity: 2}, {MenuID: menuID, Quantity: 2}, {MenuID: menuID, Quantity: 1}, {MenuID: menuID, Quantity: 1, Note: "不要香菜"}} {
+		if _, _, err := st.Orders.Place(partyID, userID, []models.CartItem{item}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := func() []models.OrderItem {
+		t.Helper()
+		items, err := st.Orders.ListByParty(partyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return items
+	}
+	items := list()
+	if len(items) != 2 || items[0].Quantity != 5 || items[1].Quantity != 1 {
+		t.Fatalf("合并后的订单 = %+v", items)
+	}
+	groupID := items[0].ID
+
+	// 跨越多条订单扣减 4 份
+	refund, err := st.Orders.Delete(groupID, partyID, userID, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refund != 8 {
+		t.Fatalf("退还精力 = %d, 期望 8", refund)
+	}
+	if items = list(); len(items) != 2 || items[0].ID != groupID || items[0].Quantity != 1 || items[1].Quantity != 1 {
+		t.Fatalf("扣减后的订单 = %+v", items)
+	}
+	// quantity 为 0 时删除整组，不影响带备注的订单
+	if refund, err = st.Orders.Delete(groupID, partyID, userID, 0); err != nil || refund != 2 {
+		t.Fatalf("删除整组: %d, %v", refund, err)
+	}
+	if items = list(); len(items) != 1 || items[0].Note != "不要香菜" {
+		t.Fatalf("删除整组后的订单 = %+v", items)
+	}
+	if _, err := st.Orders.Delete(groupID, partyID, userID, 0); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("重复删除: %v", err)
+	}
+	party, err := st.Parties.Get(partyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if party.EnergyLeft != 98 {
+		t.Fatalf("剩余精力 = %d, 期望 98", party.EnergyLeft)
+	}
+}
@@ -0,0 +1,574 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+type partyStore struct {
+	db *sql.DB
+}
+
+// partyColumns 只能用于 FROM parties（不带别名）的查询。
+const partyColumns = `id, name, password, energy_left, state, opens_at, closes_at, budget_mode, member_budget, collection_id, deleted_at, owner_id,
+	(SELECT json_group_array(restaurant_id) FROM (SELECT restaurant_id FROM party_restaurants WHERE party_id = parties.id ORDER BY restaurant_id))`
+
+func (s *partyStore) Create(party *models.Party) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := checkCollection(tx, party.CollectionID); err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec("INSERT INTO parties (name, password, energy_left, state, opens_at, closes_at, budget_mode, member_budget, collection_id, owner_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", party.Name, party.Password, party.EnergyLeft, party.State, encodeTime(party.OpensAt), encodeTime(party.ClosesAt), party.BudgetMode, party.MemberBudget, party.CollectionID, party.OwnerID)
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return 0, store.ErrDuplicate
+		}
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := replacePartyRestaurants(tx, int(id), party.RestaurantIDs); err != nil {
+		return 0, err
+	}
+	if err := recordTransition(tx, int(id), "", party.State, 0); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+func (s *partyStore) Get(id int) (*models.Party, error) {
+	return s.scanOne(s.db.QueryRow("SELECT "+partyColumns+" FROM parties WHERE id = ?", id))
+}
+
+func (s *partyStore) GetByName(name string) (*models.Party, error) {
+	return s.scanOne(s.db.QueryRow("SELECT "+partyColumns+" FROM parties WHERE name = ? AND deleted_at IS NULL", name))
+}
+
+func (s *partyStore) scanOne(row *sql.Row) (*models.Party, error) {
+	party, err := scanParty(row)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	return party, err
+}
+
+func scanParty(row scanner) (*models.Party, error) {
+	var party models.Party
+	var opensAt, closesAt, deletedAt sql.NullTime
+	var collectionID, ownerID sql.NullInt64
+	var restaurantIDs string
+	if err := row.Scan(&party.ID, &party.Name, &party.Password, &party.EnergyLeft, &party.State, &opensAt, &closesAt, &party.BudgetMode, &party.MemberBudget, &collectionID, &deletedAt, &ownerID, &restaurantIDs); err != nil {
+		return nil, err
+	}
+	party.OpensAt = decodeTime(opensAt)
+	party.ClosesAt = decodeTime(closesAt)
+	party.CollectionID = decodeOptionalInt(collectionID)
+	party.DeletedAt = decodeTime(deletedAt)
+	party.OwnerID = decodeOptionalInt(ownerID)
+	party.RestaurantIDs = []int{}
+	if err := json.Unmarshal([]byte(restaurantIDs), &party.RestaurantIDs); err != nil {
+		return nil, err
+	}
+	return &party, nil
+}
+
+func (s *partyStore) List() ([]models.Party, error) {
+	return s.list("SELECT " + partyColumns + " FROM parties WHERE deleted_at IS NULL")
+}
+
+func (s *partyStore) CountByState() (map[string]int, error) {
+	rows, err := s.db.Query("SELECT state, COUNT(*) FROM parties WHERE deleted_at IS NULL GROUP BY state")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var state string
+		var n int
+		if err := rows.Scan(&state, &n); err != nil {
+			return nil, err
+		}
+		counts[state] = n
+	}
+	return counts, rows.Err()
+}
+
+func (s *partyStore) ListByOwner(userID int) ([]models.Party, error) {
+	return s.list("SELECT "+partyColumns+" FROM parties WHERE owner_id = ? AND deleted_at IS NULL ORDER BY id", userID)
+}
+
+func (s *partyStore) list(query string, args ...any) ([]models.Party, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parties := make([]models.Party, 0)
+	for rows.Next() {
+		party, err := scanParty(rows)
+		if err != nil {
+			return nil, err
+		}
+		party.Password = ""
+		parties = append(parties, *party)
+	}
+	return parties, rows.Err()
+}
+
+func (s *partyStore) Update(party *models.Party) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldMode string
+	var oldBudget int
+	if err := tx.QueryRow("SELECT budget_mode, member_budget FROM parties WHERE id = ? AND deleted_at IS NULL", party.ID).Scan(&oldMode, &oldBudget); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		return err
+	}
+	if err := checkCollection(tx, party.CollectionID); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE parties SET name = ?, password = ?, energy_left = ?, opens_at = ?, closes_at = ?, budget_mode = ?, member_budget = ?, collection_id = ? WHERE id = ?", party.Name, party.Password, party.EnergyLeft, encodeTime(party.OpensAt), encodeTime(party.ClosesAt), party.BudgetMode, party.MemberBudget, party.CollectionID, party.ID)
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return store.ErrDuplicate
+		}
+		return err
+	}
+	if err := replacePartyRestaurants(tx, party.ID, party.RestaurantIDs); err != nil {
+		return err
+	}
+	if party.BudgetMode != oldMode || party.MemberBudget != oldBudget || party.BudgetMode == models.BudgetSplit {
+		if err := applyBudgets(tx, party.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func checkCollection(tx *sql.Tx, collectionID *int) error {
+	if collectionID == nil {
+		return nil
+	}
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM collections WHERE id = ?)", *collectionID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrInvalidCollection
+	}
+	return nil
+}
+
+func replacePartyRestaurants(tx *sql.Tx, partyID int, restaurantIDs []int) error {
+	if _, err := tx.Exec("DELETE FROM party_restaurants WHERE party_id = ?", partyID); err != nil {
+		return err
+	}
+	for _, id := range restaurantIDs {
+		if err := checkRestaurant(tx, &id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT OR IGNORE INTO party_restaurants (party_id, restaurant_id) VALUES (?, ?)", partyID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyBudgets 按 Party 当前的额度模式重新分配全部成员的额度。
+// split 模式以剩余精力加已消耗精力为总额平分，且不低于成员已消耗的精力。
+func applyBudgets(tx *sql.Tx, partyID int) error {
+	var mode string
+	var memberBudget, energyLeft int
+	if err := tx.QueryRow("SELECT budget_mode, member_budget, energy_left FROM parties WHERE id = ?", partyID).Scan(&mode, &memberBudget, &energyLeft); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		return err
+	}
+	var err error
+	switch mode {
+	case models.BudgetFixed:
+		_, err = tx.Exec("UPDATE party_members SET budget = ? WHERE party_id = ?", memberBudget, partyID)
+	case models.BudgetSplit:
+		var members, spent int
+		row := tx.QueryRow(`
+			SELECT COUNT(*), COALESCE((SELECT SUM(unit_cost * quantity) FROM orders WHERE party_id = ?), 0)
+			FROM party_members WHERE party_id = ?`, partyID, partyID)
+		if err := row.Scan(&members, &spent); err != nil {
+			return err
+		}
+		if members == 0 {
+			return nil
+		}
+		share := (energyLeft + spent) / members
+		_, err = tx.Exec(`
+			UPDATE party_members SET budget = MAX(?, (
+				SELECT COALESCE(SUM(o.unit_cost * o.quantity), 0) FROM orders o
+				WHERE o.party_id = party_members.party_id AND o.user_id = party_members.user_id
+			)) WHERE party_id = ?`, share, partyID)
+	default:
+		_, err = tx.Exec("UPDATE party_members SET budget = NULL WHERE party_id = ?", partyID)
+	}
+	return err
+}
+
+func (s *partyStore) Delete(id, actorID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var state string
+	if err := tx.QueryRow("SELECT state FROM parties WHERE id = ? AND deleted_at IS NULL", id).Scan(&state); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		return err
+	}
+	if state != models.PartyArchived {
+		if _, err := tx.Exec("UPDATE parties SET state = ? WHERE id = ?", models.PartyArchived, id); err != nil {
+			return err
+		}
+		if err := recordTransition(tx, id, state, models.PartyArchived, actorID); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	if _, err := tx.Exec("UPDATE parties SET deleted_at = ? WHERE id = ?", encodeTime(&now), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *partyStore) ListArchived(filter store.HistoryFilter) ([]models.PartySummary, int, error) {
+	where := "p.state = ?"
+	args := []any{models.PartyArchived}
+	if !filter.From.IsZero() {
+		where += " AND h.changed_at >= ?"
+		args = append(args, encodeTimestamp(filter.From))
+	}
+	if !filter.To.IsZero() {
+		where += " AND h.changed_at < ?"
+		args = append(args, encodeTimestamp(filter.To))
+	}
+	// 归档为终态，每个已归档的 Party 只有一条归档记录
+	from := `
+		FROM parties p
+		JOIN party_state_history h ON h.party_id = p.id AND h.to_state = p.state
+		WHERE ` + where
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*)"+from, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	query := `
+		SELECT p.id, p.name, p.energy_left, p.deleted_at, h.changed_at,
+			(SELECT COUNT(*) FROM party_members pm WHERE pm.party_id = p.id),
+			(SELECT COALESCE(SUM(o.quantity), 0) FROM orders o WHERE o.party_id = p.id),
+			(SELECT COALESCE(SUM(o.unit_cost * o.quantity), 0) FROM orders o WHERE o.party_id = p.id)` + from + `
+		ORDER BY h.changed_at DESC, p.id DESC`
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	summaries := make([]models.PartySummary, 0)
+	for rows.Next() {
+		var p models.PartySummary
+		var deletedAt sql.NullTime
+		if err := rows.Scan(&p.ID, &p.Name, &p.EnergyLeft, &deletedAt, &p.ArchivedAt, &p.Members, &p.Orders, &p.EnergyUsed); err != nil {
+			return nil, 0, err
+		}
+		p.DeletedAt = decodeTime(deletedAt)
+		p.ArchivedAt = p.ArchivedAt.UTC()
+		summaries = append(summaries, p)
+	}
+	return summaries, total, rows.Err()
+}
+
+func (s *partyStore) Transition(partyID int, to string, actorID int) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	from, err := partyState(tx, partyID)
+	if err != nil {
+		return "", err
+	}
+	if !models.CanTransitionParty(from, to) {
+		return from, store.ErrInvalidTransition
+	}
+	if _, err := tx.Exec("UPDATE parties SET state = ? WHERE id = ?", to, partyID); err != nil {
+		return from, err
+	}
+	if err := recordTransition(tx, partyID, from, to, actorID); err != nil {
+		return from, err
+	}
+	return from, tx.Commit()
+}
+
+func recordTransition(tx *sql.Tx, partyID int, from, to string, actorID int) error {
+	var changedBy sql.NullInt64
+	if actorID > 0 {
+		changedBy = sql.NullInt64{Int64: int64(actorID), Valid: true}
+	}
+	_, err := tx.Exec("INSERT INTO party_state_history (party_id, from_state, to_state, changed_by) VALUES (?, ?, ?, ?)", partyID, from, to, changedBy)
+	return err
+}
+
+func partyState(tx *sql.Tx, partyID int) (string, error) {
+	var state string
+	if err := tx.QueryRow("SELECT state FROM parties WHERE id = ?", partyID).Scan(&state); err != nil {
+		if err == sql.ErrNoRows {
+			return "", store.ErrNotFound
+		}
+		return "", err
+	}
+	return state, nil
+}
+
+// requireOpen 在事务内确认 Party 处于开放点餐状态且在点餐时间窗口内。
+func requireOpen(tx *sql.Tx, partyID int) error {
+	party, err := scanParty(tx.QueryRow("SELECT "+partyColumns+" FROM parties WHERE id = ?", partyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		return err
+	}
+	if party.State != models.PartyOpen {
+		return store.ErrPartyNotOpen
+	}
+	if !party.InOrderingWindow(time.Now()) {
+		return store.ErrOutsideWindow
+	}
+	return nil
+}
+
+func (s *partyStore) LockDue(now time.Time) ([]int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id FROM parties WHERE state = ? AND closes_at IS NOT NULL AND closes_at <= ?", models.PartyOpen, encodeTime(&now))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if _, err := tx.Exec("UPDATE parties SET state = ? WHERE id = ?", models.PartyLocked, id); err != nil {
+			return nil, err
+		}
+		if err := recordTransition(tx, id, models.PartyOpen, models.PartyLocked, 0); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *partyStore) History(partyID int) ([]models.PartyStateChange, error) {
+	rows, err := s.db.Query(`
+		SELECT id, party_id, from_state, to_state, COALESCE(changed_by, 0), changed_at
+		FROM party_state_history
+		WHERE party_id = ?
+		ORDER BY id`, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]models.PartyStateChange, 0)
+	for rows.Next() {
+		var change models.PartyStateChange
+		if err := rows.Scan(&change.ID, &change.PartyID, &change.FromState, &change.ToState, &change.ChangedBy, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
+
+func (s *partyStore) AddMember(partyID, userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT OR IGNORE INTO party_members (party_id, user_id) VALUES (?, ?)", partyID, userID)
+	if err != nil {
+		return err
+	}
+	added, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if added > 0 {
+		if err := assignBudget(tx, partyID, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// assignBudget 为新加入的成员分配额度。
+func assignBudget(tx *sql.Tx, partyID, userID int) error {
+	var mode string
+	var memberBudget int
+	if err := tx.QueryRow("SELECT budget_mode, member_budget FROM parties WHERE id = ?", partyID).Scan(&mode, &memberBudget); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		return err
+	}
+	switch mode {
+	case models.BudgetFixed:
+		_, err := tx.Exec("UPDATE party_members SET budget = ? WHERE party_id = ? AND user_id = ?", memberBudget, partyID, userID)
+		return err
+	case models.BudgetSplit:
+		return applyBudgets(tx, partyID)
+	}
+	return nil
+}
+
+func (s *partyStore) RemoveMember(partyID, userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := partyState(tx, partyID)
+	if err != nil {
+		return err
+	}
+	if state != models.PartyDraft && state != models.PartyOpen {
+		return store.ErrPartyNotOpen
+	}
+	_, err = tx.Exec(`
+		UPDATE parties SET energy_left = energy_left + (
+			SELECT COALESCE(SUM(unit_cost * quantity), 0) FROM orders WHERE user_id = ? AND party_id = ?
+		) WHERE id = ?`, userID, partyID, partyID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM party_members WHERE user_id = ? AND party_id = ?", userID, partyID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM orders WHERE user_id = ? AND party_id = ?", userID, partyID); err != nil {
+		return err
+	}
+	var mode string
+	if err := tx.QueryRow("SELECT budget_mode FROM parties WHERE id = ?", partyID).Scan(&mode); err != nil {
+		return err
+	}
+	if mode == models.BudgetSplit {
+		if err := applyBudgets(tx, partyID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *partyStore) IsMember(partyID, userID int) (bool, error) {
+	var isMember bool
+	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM party_members WHERE party_id = ? AND user_id = ?)", partyID, userID).Scan(&isMember)
+	return isMember, err
+}
+
+func (s *partyStore) SetMemberBudget(partyID, userID int, budget *int) error {
+	result, err := s.db.Exec("UPDATE party_members SET budget = ? WHERE party_id = ? AND user_id = ?", budget, partyID, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return store.ErrNotMember
+	}
+	return nil
+}
+
+func (s *partyStore) MemberBudgets(partyID int) ([]models.MemberBudget, error) {
+	rows, err := s.db.Query(`
+		SELECT pm.user_id, u.username, pm.budget, COALESCE((
+			SELECT SUM(o.unit_cost * o.quantity) FROM orders o
+			WHERE o.party_id = pm.party_id AND o.user_id = pm.user_id
+		), 0)
+		FROM party_members pm
+		JOIN users u ON pm.user_id = u.id
+		WHERE pm.party_id = ?
+		ORDER BY pm.id`, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := make([]models.MemberBudget, 0)
+	for rows.Next() {
+		var b models.MemberBudget
+		var budget sql.NullInt64
+		if err := rows.Scan(&b.UserID, &b.Username, &budget, &b.Spent); err != nil {
+			return nil, err
+		}
+		if budget.Valid {
+			limit := int(budget.Int64)
+			remaining := max(limit-b.Spent, 0)
+			b.Budget, b.Remaining = &limit, &remaining
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+func (s *partyStore) FindByMember(userID int) (*models.Party, error) {
+	return s.scanOne(s.db.QueryRow(`
+		SELECT `+partyColumns+` FROM parties
+		WHERE state != ? AND id IN (SELECT party_id FROM party_members WHERE user_id = ?)
+		ORDER BY id DESC
+		LIMIT 1`, models.PartyArchived, userID))
+}
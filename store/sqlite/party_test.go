@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"errors"
+	"testing"
+)
+
+func TestPartyNameReusableAfterDelete(t *testing.T) {
+	st := New(openTestDB(t))
+	create := func(name string) (int, error) {
+		return st.Parties.Create(&models.Party{Name: name, Password: "pw", EnergyLeft: 10, State: models.PartyOpen, BudgetMode: models.BudgetShared})
+	}
+	oldID, err := create("lunch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := create("lunch"); !errors.Is(err, store.ErrDuplicate) {
+		t.Fatalf("重名创建: %v", err)
+	}
+	if err := st.Parties.Delete(oldID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	newID, err := create("lunch")
+	if err != nil {
+		t.Fatalf("删除后以相同名称创建: %v", err)
+	}
+	if p, err := st.Parties.GetByName("lunch"); err != nil || p.ID != newID {
+		t.Fatalf("GetByName = %v, %v, 期望 ID %d", p, err, newID)
+	}
+	if p, err := st.Parties.Get(oldID); err != nil || p.Name != "lunch" || p.DeletedAt == nil {
+		t.Fatalf("已删除的 Party = %v, %v", p, err)
+	}
+	// 未删除的 Party 之间仍然不能重名
+	if _, err := create("lunch"); !errors.Is(err, store.ErrDuplicate) {
+		t.Fatalf("再次重名创建: %v", err)
+	}
+	otherID, err := create("dinner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := st.Parties.Get(otherID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Name = "lunch"
+	if err := st.Parties.Update(other); !errors.Is(err, store.ErrDuplicate) {
+		t.Fatalf("改名为已有名称: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"database/sql"
+)
+
+type restaurantStore struct {
+	db *sql.DB
+}
+
+const restaurantColumns = "id, name, contact, address, opening_hours, min_order, delivery_fee"
+
+func (s *restaurantStore) Create(restaurant *models.Restaurant) (int, error) {
+	result, err := s.db.Exec("INSERT INTO restaurants (name, contact, address, opening_hours, min_order, delivery_fee) VALUES (?, ?, ?, ?, ?, ?)",
+		restaurant.Name, restaurant.Contact, restaurant.Address, restaurant.OpeningHours, restaurant.MinOrder, restaurant.DeliveryFee)
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return 0, store.ErrDuplicate
+		}
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (s *restaurantStore) Get(id int) (*models.Restaurant, error) {
+	restaurant, err := scanRestaurant(s.db.QueryRow("SELECT "+restaurantColumns+" FROM restaurants WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	return restaurant, err
+}
+
+func (s *restaurantStore) List() ([]models.Restaurant, error) {
+	rows, err := s.db.Query("SELECT " + restaurantColumns + " FROM restaurants ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	restaurants := make([]models.Restaurant, 0)
+	for rows.Next() {
+		restaurant, err := scanRestaurant(rows)
+		if err != nil {
+			return nil, err
+		}
+		restaurants = append(restaurants, *restaurant)
+	}
+	return restaurants, rows.Err()
+}
+
+func (s *restaurantStore) Update(restaurant *models.Restaurant) error {
+	result, err := s.db.Exec("UPDATE restaurants SET name = ?, contact = ?, address = ?, opening_hours = ?, min_order = ?, delivery_fee = ? WHERE id = ?",
+		restaurant.Name, restaurant.Contact, restaurant.Address, restaurant.OpeningHours, restaurant.MinOrder, restaurant.DeliveryFee, restaurant.ID)
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return store.ErrDuplicate
+		}
+		return err
+	}
+	return expectAffected(result)
+}
+
+func (s *restaurantStore) Delete(id int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE menus SET restaurant_id = NULL WHERE restaurant_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM party_restaurants WHERE restaurant_id = ?", id); err != nil {
+		return err
+	}
+	result, err := tx.Exec("DELETE FROM restaurants WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if err := expectAffected(result); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanRestaurant(row scanner) (*models.Restaurant, error) {
+	var r models.Restaurant
+	if err := row.Scan(&r.ID, &r.Name, &r.Contact, &r.Address, &r.OpeningHours, &r.MinOrder, &r.DeliveryFee); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func checkRestaurant(tx *sql.Tx, restaurantID *int) error {
+	if restaurantID == nil {
+		return nil
+	}
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM restaurants WHERE id = ?)", *restaurantID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrInvalidRestaurant
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"database/sql"
+	"time"
+)
+
+type sessionStore struct {
+	db *sql.DB
+}
+
+func (s *sessionStore) Get(id string) (*models.Session, error) {
+	var session models.Session
+	var userID sql.NullInt64
+	err := s.db.QueryRow("SELECT id, user_id, data, ip, user_agent, created_at, last_seen_at FROM sessions WHERE id = ?", id).
+		Scan(&session.ID, &userID, &session.Data, &session.IP, &session.UserAgent, &session.CreatedAt, &session.LastSeenAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+	session.UserID = decodeOptionalInt(userID)
+	session.CreatedAt = session.CreatedAt.UTC()
+	session.LastSeenAt = session.LastSeenAt.UTC()
+	return &session, nil
+}
+
+func (s *sessionStore) Save(session *models.Session) error {
+	_, err := s.db.Exec(`
+		INSERT INTO sessions (id, user_id, data, ip, user_agent, created_at, last_seen_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET user_id = excluded.user_id, data = excluded.data, ip = excluded.ip,
+			user_agent = excluded.user_agent, last_seen_at = excluded.last_seen_at`,
+		session.ID, session.UserID, session.Data, session.IP, session.UserAgent, encodeTime(&session.CreatedAt), encodeTime(&session.LastSeenAt))
+	return err
+}
+
+func (s *sessionStore) Touch(id string, at time.Time) error {
+	result, err := s.db.Exec("UPDATE sessions SET last_seen_at = ? WHERE id = ?", encodeTime(&at), id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+func (s *sessionStore) Delete(id string) error {
+	result, err := s.db.Exec("DELETE FROM sessions WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+func (s *sessionStore) ListByUser(userID int) ([]models.Session, error) {
+	rows, err := s.db.Query("SELECT id, ip, user_agent, created_at, last_seen_at FROM sessions WHERE user_id = ? ORDER BY last_seen_at DESC, created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]models.Session, 0)
+	for rows.Next() {
+		session := models.Session{UserID: &userID}
+		if err := rows.Scan(&session.ID, &session.IP, &session.UserAgent, &session.CreatedAt, &session.LastSeenAt); err != nil {
+			return nil, err
+		}
+		session.CreatedAt = session.CreatedAt.UTC()
+		session.LastSeenAt = session.LastSeenAt.UTC()
+		list = append(list, session)
+	}
+	return list, rows.Err()
+}
+
+func (s *sessionStore) DeleteByUser(userID int, exceptID string) (int, error) {
+	result, err := s.db.Exec("DELETE FROM sessions WHERE user_id = ? AND id != ?", userID, exceptID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+func (s *sessionStore) DeleteExpired(idleBefore, createdBefore time.Time) (int, error) {
+	result, err := s.db.Exec("DELETE FROM sessions WHERE last_seen_at < ? OR created_at < ?", encodeTime(&idleBefore), encodeTime(&createdBefore))
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
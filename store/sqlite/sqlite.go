@@ -0,0 +1,120 @@
+package sqlite
+
+import (
+	"DineTogether/store"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+func New(db *sql.DB) store.Stores {
+	return store.Stores{
+		Users:       &userStore{db: db},
+		Menus:       &menuStore{db: db},
+		Parties:     &partyStore{db: db},
+		Orders:      &orderStore{db: db},
+		Categories:  &categoryStore{db: db},
+		Collections: &collectionStore{db: db},
+		Restaurants: &restaurantStore{db: db},
+		Stats:       &statsStore{db: db},
+		Audit:       &auditStore{db: db},
+		Sessions:    &sessionStore{db: db},
+		Invites:     &inviteStore{db: db},
+	}
+}
+
+func isUniqueConstraint(err error) bool {
+	if err == nil {
+		return false
+	}
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+func decodeImageURLs(raw sql.NullString) ([]string, error) {
+	if !raw.Valid {
+		return []string{}, nil
+	}
+	var urls []string
+	if err := json.Unmarshal([]byte(raw.String), &urls); err != nil {
+		return nil, err
+	}
+	if urls == nil {
+		urls = []string{}
+	}
+	return urls, nil
+}
+
+func decodeStrings(raw string) ([]string, error) {
+	list := []string{}
+	if raw == "" {
+		return list, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return nil, err
+	}
+	if list == nil {
+		list = []string{}
+	}
+	return list, nil
+}
+
+func encodeStrings(list []string) (string, error) {
+	if list == nil {
+		list = []string{}
+	}
+	data, err := json.Marshal(list)
+	return string(data), err
+}
+
+func expectAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// encodeTime 统一以秒精度的 UTC 时间存储，保证按字符串比较与时间顺序一致。
+func encodeTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Truncate(time.Second)
+}
+
+func decodeTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time.UTC()
+	return &v
+}
+
+// encodeTimestamp 按 CURRENT_TIMESTAMP 的格式（UTC）编码，用于与默认值生成的时间列比较。
+func encodeTimestamp(t time.Time) string {
+	return t.UTC().Format(time.DateTime)
+}
+
+// decodeTimestamp 解析聚合函数返回的 CURRENT_TIMESTAMP 格式时间，聚合结果不带列类型，驱动按字符串返回。
+func decodeTimestamp(raw sql.NullString) (*time.Time, error) {
+	if !raw.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse(time.DateTime, raw.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func decodeOptionalInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type statsStore struct {
+	db *sql.DB
+}
+
+// orderRange 返回按下单时间筛选订单的条件，借助 idx_orders_created 索引。
+func orderRange(filter store.StatsFilter) (string, []any) {
+	where := "1 = 1"
+	var args []any
+	if !filter.From.IsZero() {
+		where += " AND o.created_at >= ?"
+		args = append(args, encodeTimestamp(filter.From))
+	}
+	if !filter.To.IsZero() {
+		where += " AND o.created_at < ?"
+		args = append(args, encodeTimestamp(filter.To))
+	}
+	return where, args
+}
+
+// localOffset 返回把 UTC 时间换算为服务器本地时间的 SQLite 日期修饰符。
+func localOffset() string {
+	_, offset := time.Now().Zone()
+	return fmt.Sprintf("%+d seconds", offset)
+}
+
+func (s *statsStore) Overview(filter store.StatsFilter) (*models.StatsOverview, error) {
+	where, args := orderRange(filter)
+	var o models.StatsOverview
+	row := s.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(o.quantity), 0), COALESCE(SUM(o.unit_cost * o.quantity), 0),
+			COUNT(DISTINCT o.party_id), COUNT(DISTINCT o.user_id)
+		FROM orders o
+		WHERE `+where, args...)
+	if err := row.Scan(&o.Orders, &o.Quantity, &o.Energy, &o.Parties, &o.Users); err != nil {
+		return nil, err
+	}
+	if o.Parties > 0 {
+		o.AvgEnergyPerParty = float64(o.Energy) / float64(o.Parties)
+	}
+	return &o, nil
+}
+
+func (s *statsStore) Series(filter store.StatsFilter) ([]models.StatsBucket, error) {
+	where, args := orderRange(filter)
+	var key, label, join, order string
+	switch filter.GroupBy {
+	case models.StatsByUser:
+		key, label = "CAST(o.user_id AS TEXT)", "u.username"
+		join = "JOIN users u ON o.user_id = u.id"
+	case models.StatsByDish:
+		key, label = "CAST(o.menu_id AS TEXT)", "m.name"
+		join = "JOIN menus m ON o.menu_id = m.id"
+	case models.StatsByWeek:
+		key = "date(o.created_at, ?, 'weekday 0', '-6 days')"
+	case models.StatsByMonth:
+		key = "strftime('%Y-%m', o.created_at, ?)"
+	default:
+		key = "date(o.created_at, ?)"
+	}
+	if label == "" {
+		label = key
+		args = append([]any{localOffset(), localOffset()}, args...)
+		order = "1"
+	} else {
+		order = "SUM(o.quantity) DESC, MIN(o.id)"
+	}
+	query := fmt.Sprintf(`
+		SELECT %s, %s, COUNT(*), SUM(o.quantity), SUM(o.unit_cost * o.quantity), COUNT(DISTINCT o.party_id), COUNT(DISTINCT o.user_id)
+		FROM orders o
+		%s
+		WHERE %s
+		GROUP BY 1
+		ORDER BY %s`, key, label, join, where, order)
+	if filter.Limit > 0 && join != "" {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := make([]models.StatsBucket, 0)
+	for rows.Next() {
+		var b models.StatsBucket
+		if err := rows.Scan(&b.Key, &b.Label, &b.Orders, &b.Quantity, &b.Energy, &b.Parties, &b.Users); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+func (s *statsStore) InactiveUsers(filter store.StatsFilter) ([]models.InactiveUser, error) {
+	where := "pm.user_id = u.id"
+	var args []any
+	if !filter.From.IsZero() {
+		where += " AND pm.joined_at >= ?"
+		args = append(args, encodeTimestamp(filter.From))
+	}
+	if !filter.To.IsZero() {
+		where += " AND pm.joined_at < ?"
+		args = append(args, encodeTimestamp(filter.To))
+	}
+	rows, err := s.db.Query(`
+		SELECT u.id, u.username, u.role, (SELECT MAX(pm.joined_at) FROM party_members pm WHERE pm.user_id = u.id)
+		FROM users u
+		WHERE NOT EXISTS (SELECT 1 FROM party_members pm WHERE `+where+`)
+		ORDER BY u.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.InactiveUser, 0)
+	for rows.Next() {
+		var u models.InactiveUser
+		var lastJoined sql.NullString
+		if err := rows.Scan(&u.ID, &u.Username, &u.Role, &lastJoined); err != nil {
+			return nil, err
+		}
+		if u.LastJoinedAt, err = decodeTimestamp(lastJoined); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
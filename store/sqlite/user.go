@@ -0,0 +1,148 @@
+package sqlite
+
+import (
+	"DineTogether/models"
+	"DineTogether/store"
+	"database/sql"
+)
+
+type userStore struct {
+	db *sql.DB
+}
+
+func (s *userStore) Create(user *models.User) (int, error) {
+	result, err := s.db.Exec("INSERT INTO users (username, password, role) VALUES (?, ?, ?)", user.Username, user.Password, user.Role)
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return 0, store.ErrDuplicate
+		}
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (s *userStore) Get(id int) (*models.User, error) {
+	return s.scanOne(s.db.QueryRow("SELECT id, username, password, role FROM users WHERE id = ?", id))
+}
+
+func (s *userStore) GetByUsername(username string) (*models.User, error) {
+	return s.scanOne(s.db.QueryRow("SELECT id, username, password, role FROM users WHERE username = ?", username))
+}
+
+func (s *userStore) scanOne(row *sql.Row) (*models.User, error) {
+	var user models.User
+	if err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Role); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *userStore) List() ([]models.User, error) {
+	rows, err := s.db.Query("SELECT id, username, role FROM users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (s *userStore) Update(user *models.User) error {
+	result, err := s.db.Exec("UPDATE users SET username = ?, password = ?, role = ? WHERE id = ?", user.Username, user.Password, user.Role, user.ID)
+	if err != nil {
+		if isUniqueConstraint(err) {
+			return store.ErrDuplicate
+		}
+		return err
+	}
+	return expectAffected(result)
+}
+
+func (s *userStore) UpdateRole(id int, role string) error {
+	result, err := s.db.Exec("UPDATE users SET role = ? WHERE id = ?", role, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+func (s *userStore) UpdatePassword(id int, password string) error {
+	result, err := s.db.Exec("UPDATE users SET password = ? WHERE id = ?", password, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+func (s *userStore) Delete(id int) error {
+	result, err := s.db.Exec("DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+func (s *userStore) Exists(id int) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)", id).Scan(&exists)
+	return exists, err
+}
+
+func (s *userStore) CountAdmins() (int, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM users WHERE role = 'admin'").Scan(&count)
+	return count, err
+}
+
+func (s *userStore) DietaryProfile(id int) (*models.DietaryProfile, error) {
+	var allergies, diets string
+	var strict bool
+	err := s.db.QueryRow("SELECT allergies, diets, diet_strict FROM users WHERE id = ?", id).Scan(&allergies, &diets, &strict)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeProfile(allergies, diets, strict)
+}
+
+func (s *userStore) UpdateDietaryProfile(id int, profile *models.DietaryProfile) error {
+	allergies, err := encodeStrings(profile.Allergies)
+	if err != nil {
+		return err
+	}
+	diets, err := encodeStrings(profile.Diets)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec("UPDATE users SET allergies = ?, diets = ?, diet_strict = ? WHERE id = ?", allergies, diets, profile.Strict, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+func decodeProfile(allergies, diets string, strict bool) (*models.DietaryProfile, error) {
+	profile := &models.DietaryProfile{Strict: strict}
+	var err error
+	if profile.Allergies, err = decodeStrings(allergies); err != nil {
+		return nil, err
+	}
+	if profile.Diets, err = decodeStrings(diets); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
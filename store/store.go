@@ -0,0 +1,256 @@
+package store
+
+import (
+	"DineTogether/models"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound           = errors.New("记录不存在")
+	ErrDuplicate          = errors.New("记录已存在")
+	ErrNotMember          = errors.New("用户不是该 Party 成员")
+	ErrInsufficientEnergy = errors.New("Party 精力不足")
+	ErrInvalidTransition  = errors.New("不允许的 Party 状态变更")
+	ErrPartyNotOpen       = errors.New("Party 当前状态不允许该操作")
+	ErrOutsideWindow      = errors.New("不在点餐时间内")
+	ErrBudgetExceeded     = errors.New("成员个人额度不足")
+	ErrInvalidCategory    = errors.New("分类不存在")
+	ErrMenuUnavailable    = errors.New("菜品已下架")
+	ErrNotServing         = errors.New("不在菜品供应时间内")
+	ErrSoldOut            = errors.New("菜品已售罄")
+	ErrInvalidCollection  = errors.New("菜单集不存在")
+	ErrInvalidMenu        = errors.New("菜品不存在")
+	ErrNotInCollection    = errors.New("菜品不在本 Party 的菜单中")
+	ErrInvalidRestaurant  = errors.New("餐厅不存在")
+	ErrInviteExpired      = errors.New("邀请链接已失效")
+	ErrInviteExhausted    = errors.New("邀请链接使用次数已达上限")
+)
+
+// Stores 汇总所有数据访问接口，由 sqlite 与 memory 两种实现提供。
+type Stores struct {
+	Users       UserStore
+	Menus       MenuStore
+	Parties     PartyStore
+	Orders      OrderStore
+	Categories  CategoryStore
+	Collections CollectionStore
+	Restaurants RestaurantStore
+	Stats       StatsStore
+	Audit       AuditStore
+	Sessions    SessionStore
+	Invites     InviteStore
+}
+
+// UserStore 中的 Password 字段均为 bcrypt 哈希。
+type UserStore interface {
+	Create(user *models.User) (int, error)
+	Get(id int) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
+	List() ([]models.User, error)
+	Update(user *models.User) error
+	UpdateRole(id int, role string) error
+	UpdatePassword(id int, password string) error
+	Delete(id int) error
+	Exists(id int) (bool, error)
+	CountAdmins() (int, error)
+	DietaryProfile(id int) (*models.DietaryProfile, error)
+	UpdateDietaryProfile(id int, profile *models.DietaryProfile) error
+}
+
+// MenuFilter 为菜品列表的筛选条件，零值表示不筛选。
+type MenuFilter struct {
+	CategoryID int
+	Tag        string
+	// CollectionID 仅返回该菜单集中的菜品。
+	CollectionID int
+	RestaurantID int
+	// Query 按名称或描述模糊匹配。
+	Query string
+}
+
+// HistoryFilter 为历史记录的时间范围与分页条件，From/To 为零值表示不限制，Limit 为 0 表示不分页。
+type HistoryFilter struct {
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// MenuStore 的 Create/Update 在 CategoryID 指向不存在的分类时返回 ErrInvalidCategory，
+// RestaurantID 指向不存在的餐厅时返回 ErrInvalidRestaurant。
+type MenuStore interface {
+	Create(menu *models.Menu) (int, error)
+	Get(id int) (*models.Menu, error)
+	// List 按分类排序、再按菜品 ID 返回符合筛选条件的菜品，未分类的菜品排在最后。
+	List(filter MenuFilter) ([]models.Menu, error)
+	// Update 不修改上下架状态，上下架通过 SetAvailable 变更。
+	Update(menu *models.Menu) error
+	SetAvailable(id int, available bool) error
+	// Delete 软删除菜品：草稿与开放 Party 中的相关订单被删除并退还精力，其余 Party 的订单作为历史保留。
+	// 已删除的菜品不再由 Get/List 返回，也不能更新或点餐，再次删除返回 ErrNotFound。
+	Delete(id int) error
+	// Tags 返回所有标签及使用该标签的菜品数。
+	Tags() ([]models.TagCount, error)
+	// RenameTag 将所有菜品上的标签 from 改为 to，不存在时返回 ErrNotFound。
+	RenameTag(from, to string) error
+	DeleteTag(tag string) error
+}
+
+type CategoryStore interface {
+	Create(category *models.Category) (int, error)
+	Get(id int) (*models.Category, error)
+	List() ([]models.Category, error)
+	Update(category *models.Category) error
+	// Delete 删除分类，原属该分类的菜品变为未分类。
+	Delete(id int) error
+}
+
+// CollectionStore 的 Create/Update/Clone 在名称重复时返回 ErrDuplicate，
+// MenuIDs 含不存在的菜品时返回 ErrInvalidMenu。
+type CollectionStore interface {
+	Create(collection *models.Collection) (int, error)
+	Get(id int) (*models.Collection, error)
+	List() ([]models.Collection, error)
+	Update(collection *models.Collection) error
+	// Delete 删除菜单集，使用该菜单集的 Party 变为可点全部菜品。
+	Delete(id int) error
+	// Clone 以新名称复制菜单集及其菜品，返回新菜单集 ID。
+	Clone(id int, name string) (int, error)
+}
+
+// RestaurantStore 的 Create/Update 在名称重复时返回 ErrDuplicate。
+type RestaurantStore interface {
+	Create(restaurant *models.Restaurant) (int, error)
+	Get(id int) (*models.Restaurant, error)
+	List() ([]models.Restaurant, error)
+	Update(restaurant *models.Restaurant) error
+	// Delete 删除餐厅，原属该餐厅的菜品变为未关联，并解除与 Party 的关联。
+	Delete(id int) error
+}
+
+// PartyStore 的 Create/Update 在 CollectionID 指向不存在的菜单集时返回 ErrInvalidCollection，
+// RestaurantIDs 含不存在的餐厅时返回 ErrInvalidRestaurant，名称与未删除的 Party 重复时返回 ErrDuplicate。
+type PartyStore interface {
+	Create(party *models.Party) (int, error)
+	// Get 同时返回已删除的 Party，以便查看历史记录。
+	Get(id int) (*models.Party, error)
+	// GetByName 与 List 不返回已删除的 Party。
+	GetByName(name string) (*models.Party, error)
+	List() ([]models.Party, error)
+	// CountByState 返回未删除 Party 按状态统计的数量，没有 Party 的状态不出现在结果中。
+	CountByState() (map[string]int, error)
+	// ListByOwner 按 ID 返回用户创建的未删除 Party，不含密码。
+	ListByOwner(userID int) ([]models.Party, error)
+	// Update 修改名称、密码、精力值、点餐时间窗口、额度模式、菜单集与合作餐厅，状态只能通过 Transition 变更，不修改所有者。
+	// 额度模式或固定额度变化时会重新分配全部成员的额度，split 模式下每次更新都会重新平分。
+	Update(party *models.Party) error
+	// Delete 软删除 Party：未归档的 Party 先归档，成员、订单与状态历史均保留。
+	// 已删除的 Party 不能再更新或删除，返回 ErrNotFound；其名称可被新的 Party 使用。
+	Delete(id, actorID int) error
+	// ListArchived 按归档时间倒序返回已归档（含已删除）的 Party 概览及符合条件的总数，时间范围按归档时间筛选。
+	ListArchived(filter HistoryFilter) ([]models.PartySummary, int, error)
+	// Transition 将 Party 变更为 to 状态并记录历史，actorID 为 0 表示系统操作。
+	// 返回变更前的状态，不允许的变更返回 ErrInvalidTransition。
+	Transition(partyID int, to string, actorID int) (string, error)
+	History(partyID int) ([]models.PartyStateChange, error)
+	// LockDue 将截止时间不晚于 now 的开放 Party 锁定，返回被锁定的 Party ID。
+	LockDue(now time.Time) ([]int, error)
+	// AddMember 按 Party 的额度模式为新成员分配额度，split 模式下重新平分全部成员额度。
+	AddMember(partyID, userID int) error
+	// RemoveMember 移除成员，删除其在该 Party 中的订单并退还精力；
+	// 仅草稿和开放状态允许，否则返回 ErrPartyNotOpen。split 模式下重新平分剩余成员额度。
+	RemoveMember(partyID, userID int) error
+	IsMember(partyID, userID int) (bool, error)
+	// SetMemberBudget 覆盖成员的个人额度，budget 为 nil 表示不限额；非成员返回 ErrNotMember。
+	SetMemberBudget(partyID, userID int, budget *int) error
+	// MemberBudgets 返回每位成员的额度、已消耗与剩余精力。
+	MemberBudgets(partyID int) ([]models.MemberBudget, error)
+	// FindByMember 返回用户所在的未归档 Party。
+	FindByMember(userID int) (*models.Party, error)
+}
+
+type OrderStore interface {
+	// Place 在同一事务中校验成员身份、按购物车总精力扣除 Party 精力并记录全部订单，
+	// 任一菜品无效或精力不足时整体失败。
+	// 菜品或 Party 不存在时返回 ErrNotFound，选项不属于菜品时返回 ErrInvalidModifier，
+	// Party 未开放点餐时返回 ErrPartyNotOpen，不在点餐时间窗口内时返回 ErrOutsideWindow，
+	// 超出成员个人额度时返回 ErrBudgetExceeded，Party 精力不足时返回 ErrInsufficientEnergy。
+	// 菜品已下架、不在供应时段、超出每日 / 每个 Party 限量或不在 Party 菜单集中时分别返回包装了菜品名称的
+	// ErrMenuUnavailable、ErrNotServing、ErrSoldOut、ErrNotInCollection；剩余库存由已有订单统计，删除订单即退还库存。
+	// 返回新订单 ID（与 items 一一对应）及扣除的精力值。
+	Place(partyID, userID int, items []models.CartItem) ([]int, int, error)
+	// Delete 将 orderID 所在的订单组（ListByParty 合并的同一用户、菜品、备注、选项与单价的订单）减少 quantity 份，
+	// 从最新的订单开始扣减，quantity <= 0 或不小于合计数量时删除整组，返回退还给 Party 的精力值。Party 未开放点餐或不在点餐时间窗口内时返回 ErrPartyNotOpen / ErrOutsideWindow。
+	// userID 为 0 时不限下单用户，供 Party 管理者删除成员的订单。
+	Delete(orderID, partyID, userID, quantity int) (int, error)
+	// ListByParty 返回 Party 的订单，相同用户、菜品、备注、选项与单价的订单合并为一条，ID 取其中最小的订单 ID，
+	// 并标记与下单用户饮食档案冲突的成分。
+	ListByParty(partyID int) ([]models.OrderItem, error)
+	// KitchenSummary 按菜品与选项组合汇总 Party 的订单。
+	KitchenSummary(partyID int) ([]models.KitchenItem, error)
+	// ListByUser 按下单时间倒序返回用户在所有 Party（含已删除）中的订单及符合条件的总数。
+	ListByUser(userID int, filter HistoryFilter) ([]models.UserOrder, int, error)
+}
+
+// StatsFilter 为统计的时间范围与分组，From/To 为零值表示不限制；Limit 仅对按用户、菜品分组生效，0 表示不限制。
+type StatsFilter struct {
+	From    time.Time
+	To      time.Time
+	GroupBy string
+	Limit   int
+}
+
+// StatsStore 在数据库中聚合订单与成员数据，订单按下单时间、成员按加入时间筛选，包含已删除 Party 的数据。
+type StatsStore interface {
+	Overview(filter StatsFilter) (*models.StatsOverview, error)
+	// Series 按 GroupBy 分组汇总：时间分组按服务器本地时区升序排列，用户、菜品分组按份数降序排列。
+	Series(filter StatsFilter) ([]models.StatsBucket, error)
+	// InactiveUsers 返回时间范围内未加入任何 Party 的用户。
+	InactiveUsers(filter StatsFilter) ([]models.InactiveUser, error)
+}
+
+// AuditFilter 为审计日志的筛选条件，零值字段表示不筛选，时间范围按记录时间筛选。
+type AuditFilter struct {
+	HistoryFilter
+	ActorID    int
+	Action     string
+	TargetType string
+	TargetID   string
+}
+
+type AuditStore interface {
+	Record(event *models.AuditEvent) error
+	// List 按记录时间倒序返回符合条件的审计记录及总数。
+	List(filter AuditFilter) ([]models.AuditEvent, int, error)
+}
+
+// SessionStore 保存服务端会话，不存在的会话返回 ErrNotFound。
+type SessionStore interface {
+	Get(id string) (*models.Session, error)
+	// Save 按 ID 新建或覆盖会话。
+	Save(session *models.Session) error
+	// Touch 更新会话的最近访问时间。
+	Touch(id string, at time.Time) error
+	Delete(id string) error
+	// ListByUser 按最近访问时间倒序返回用户的会话。
+	ListByUser(userID int) ([]models.Session, error)
+	// DeleteByUser 删除用户除 exceptID 外的全部会话，返回删除的数量。
+	DeleteByUser(userID int, exceptID string) (int, error)
+	// DeleteExpired 删除最近访问早于 idleBefore 或创建早于 createdBefore 的会话，返回删除的数量。
+	DeleteExpired(idleBefore, createdBefore time.Time) (int, error)
+}
+
+// InviteStore 保存 Party 邀请，不存在的邀请返回 ErrNotFound。
+type InviteStore interface {
+	Create(invite *models.PartyInvite) (int, error)
+	Get(id int) (*models.PartyInvite, error)
+	// ListByParty 按创建时间倒序返回 Party 的邀请。
+	ListByParty(partyID int) ([]models.PartyInvite, error)
+	// Revoke 作废邀请，已作废的邀请返回 ErrNotFound。
+	Revoke(id int, at time.Time) error
+	// Redeem 在同一事务中校验邀请并将用户加入 Party，返回邀请所属的 Party ID。
+	// 邀请已作废或过期返回 ErrInviteExpired，次数已用尽返回 ErrInviteExhausted，Party 未开放返回 ErrPartyNotOpen。
+	// 用户已是成员时直接返回，不消耗次数。
+	Redeem(id, userID int, now time.Time) (int, error)
+}